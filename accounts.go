@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// accountDB maps user or group names of the audited system to their IDs and back
+type accountDB struct {
+	ids   map[string]uint32
+	names map[uint32]string
+}
+
+var (
+	users  *accountDB
+	groups *accountDB
+)
+
+// loadAccounts reads a passwd- or group-style file of the audited system, so
+// ownership inside a container or image resolves against its own accounts
+func loadAccounts(path string) *accountDB {
+	db := &accountDB{ids: make(map[string]uint32), names: make(map[uint32]string)}
+	for _, line := range readLines(path) {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		db.ids[fields[0]] = uint32(id)
+		if _, ok := db.names[uint32(id)]; !ok {
+			db.names[uint32(id)] = fields[0]
+		}
+	}
+	return db
+}
+
+// lookupUser resolves a user name or numeric UID of the audited system
+func lookupUser(name string) (uint32, bool) {
+	if users == nil {
+		users = loadAccounts("/etc/passwd")
+	}
+	return users.lookup(name)
+}
+
+// lookupGroup resolves a group name or numeric GID of the audited system
+func lookupGroup(name string) (uint32, bool) {
+	if groups == nil {
+		groups = loadAccounts("/etc/group")
+	}
+	return groups.lookup(name)
+}
+
+func (db *accountDB) lookup(name string) (uint32, bool) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(id), true
+	}
+	id, ok := db.ids[name]
+	return id, ok
+}
+
+// userName names a UID of the audited system, falling back to the number
+func userName(uid uint32) string {
+	if users == nil {
+		users = loadAccounts("/etc/passwd")
+	}
+	return users.name(uid)
+}
+
+// groupName names a GID of the audited system, falling back to the number
+func groupName(gid uint32) string {
+	if groups == nil {
+		groups = loadAccounts("/etc/group")
+	}
+	return groups.name(gid)
+}
+
+func (db *accountDB) name(id uint32) string {
+	if name, ok := db.names[id]; ok {
+		return name
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
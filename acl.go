@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+//go:build acl
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// POSIX ACL entry tags as stored in system.posix_acl_* attributes
+const (
+	aclUserObj  = 0x01
+	aclUser     = 0x02
+	aclGroupObj = 0x04
+	aclGroup    = 0x08
+	aclMask     = 0x10
+	aclOther    = 0x20
+
+	aclXattrVersion = 2
+	aclUndefinedID  = 0xffffffff
+)
+
+// aclEntry is one ACL entry; the key identifies it in access or default ACLs
+type aclEntry struct {
+	def  bool
+	tag  uint16
+	id   uint32
+	perm uint16
+}
+
+func (e aclEntry) key() string {
+	return fmt.Sprintf("%t:%d:%d", e.def, e.tag, e.id)
+}
+
+// String formats the entry like setfacl, e.g. "d:u:foo:rw-"
+func (e aclEntry) String() string {
+	var b strings.Builder
+	if e.def {
+		b.WriteString("d:")
+	}
+	switch e.tag {
+	case aclUserObj:
+		b.WriteString("u:")
+	case aclUser:
+		b.WriteString("u:" + userName(e.id))
+	case aclGroupObj:
+		b.WriteString("g:")
+	case aclGroup:
+		b.WriteString("g:" + groupName(e.id))
+	case aclMask:
+		b.WriteString("m:")
+	case aclOther:
+		b.WriteString("o:")
+	}
+	b.WriteByte(':')
+	for i, c := range "rwx" {
+		if e.perm&(4>>i) != 0 {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// parseACL parses a comma-separated ACL as accepted by setfacl and a/A rules
+func parseACL(s string) ([]aclEntry, error) {
+	var entries []aclEntry
+	for _, text := range strings.Split(s, ",") {
+		fields := strings.Split(strings.TrimSpace(text), ":")
+		e := aclEntry{id: aclUndefinedID}
+		if fields[0] == "d" || fields[0] == "default" {
+			e.def = true
+			fields = fields[1:]
+		}
+		// The other and mask entries may omit the qualifier
+		if len(fields) == 2 && (fields[0] == "o" || fields[0] == "other" || fields[0] == "m" || fields[0] == "mask") {
+			fields = []string{fields[0], "", fields[1]}
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid ACL entry %q", text)
+		}
+		qualifier := fields[1]
+		switch fields[0] {
+		case "u", "user":
+			e.tag = aclUserObj
+			if qualifier != "" {
+				id, ok := lookupUser(qualifier)
+				if !ok {
+					return nil, fmt.Errorf("unknown user %s in ACL", qualifier)
+				}
+				e.tag, e.id = aclUser, id
+			}
+		case "g", "group":
+			e.tag = aclGroupObj
+			if qualifier != "" {
+				id, ok := lookupGroup(qualifier)
+				if !ok {
+					return nil, fmt.Errorf("unknown group %s in ACL", qualifier)
+				}
+				e.tag, e.id = aclGroup, id
+			}
+		case "m", "mask":
+			e.tag = aclMask
+		case "o", "other":
+			e.tag = aclOther
+		default:
+			return nil, fmt.Errorf("invalid ACL entry %q", text)
+		}
+		for _, c := range fields[2] {
+			switch c {
+			case 'r':
+				e.perm |= 4
+			case 'w':
+				e.perm |= 2
+			case 'x', 'X':
+				e.perm |= 1
+			case '-':
+			default:
+				return nil, fmt.Errorf("invalid permissions in ACL entry %q", text)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// decodeACL decodes the binary form of a system.posix_acl_* attribute
+func decodeACL(data []byte, def bool) ([]aclEntry, error) {
+	if len(data) < 4 || binary.LittleEndian.Uint32(data) != aclXattrVersion || (len(data)-4)%8 != 0 {
+		return nil, errors.New("malformed ACL attribute")
+	}
+	var entries []aclEntry
+	for p := data[4:]; len(p) >= 8; p = p[8:] {
+		entries = append(entries, aclEntry{
+			def:  def,
+			tag:  binary.LittleEndian.Uint16(p),
+			perm: binary.LittleEndian.Uint16(p[2:]),
+			id:   binary.LittleEndian.Uint32(p[4:]),
+		})
+	}
+	return entries, nil
+}
+
+// readACL returns the access and default ACL entries of a path; a path
+// without ACL attributes yields its mode as the three base entries
+func readACL(path string) ([]aclEntry, error) {
+	parent, err := resolveInRoot(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	host := rootPath(filepath.Join(parent, filepath.Base(path)))
+	var entries []aclEntry
+	for _, attr := range []struct {
+		name string
+		def  bool
+	}{{"system.posix_acl_access", false}, {"system.posix_acl_default", true}} {
+		buf := make([]byte, 4+8*64)
+		n, err := unix.Lgetxattr(host, attr.name, buf)
+		if errors.Is(err, unix.ENODATA) {
+			if !attr.def {
+				fi, err := os.Lstat(host)
+				if err != nil {
+					return nil, err
+				}
+				mode := uint16(fi.Mode().Perm())
+				entries = append(entries,
+					aclEntry{tag: aclUserObj, id: aclUndefinedID, perm: mode >> 6 & 7},
+					aclEntry{tag: aclGroupObj, id: aclUndefinedID, perm: mode >> 3 & 7},
+					aclEntry{tag: aclOther, id: aclUndefinedID, perm: mode & 7})
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := decodeACL(buf[:n], attr.def)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, decoded...)
+	}
+	return entries, nil
+}
+
+// checkACL compares the ACLs of paths an a/A rule sets with the declared
+// ACL; "a+" only adds entries, plain "a" also removes undeclared named entries
+func checkACL(r rule) {
+	declared, err := parseACL(r.Argument)
+	if err != nil {
+		fmt.Fprintf(out, "%s⚠ Cannot parse ACL of %s: %v (%s:%d)%s\n", colorYellow, r.Path, err, r.ConfFile, r.Line, colorReset)
+		return
+	}
+	for _, path := range rulePaths(r) {
+		if r.Type == "A" {
+			filepath.WalkDir(rootPath(path), func(host string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				notifyWatchdog()
+				compareACL(r, filepath.Join(path, strings.TrimPrefix(host, rootPath(path))), declared, d.IsDir())
+				return nil
+			})
+			continue
+		}
+		fi, err := lstatPath(path)
+		if err != nil {
+			continue
+		}
+		compareACL(r, path, declared, fi.IsDir())
+	}
+}
+
+// compareACL reports the entries that would have to be added or removed
+func compareACL(r rule, path string, declared []aclEntry, dir bool) {
+	actual, err := readACL(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot read ACL of %s: %v\n", path, err)
+		return
+	}
+	have := make(map[string]aclEntry)
+	for _, e := range actual {
+		have[e.key()] = e
+	}
+	want := make(map[string]bool)
+	var add, remove []string
+	for _, e := range declared {
+		// Default ACLs only exist on directories
+		if e.def && !dir {
+			continue
+		}
+		want[e.key()] = true
+		if got, ok := have[e.key()]; !ok || got.perm != e.perm {
+			add = append(add, e.String())
+		}
+	}
+	if !r.hasModifier('+') {
+		for _, e := range actual {
+			if (e.tag == aclUser || e.tag == aclGroup) && !want[e.key()] {
+				remove = append(remove, e.String())
+			}
+		}
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	var changes []string
+	if len(add) > 0 {
+		changes = append(changes, "add "+strings.Join(add, ","))
+	}
+	if len(remove) > 0 {
+		changes = append(changes, "remove "+strings.Join(remove, ","))
+	}
+	message := "ACL differs: " + strings.Join(changes, "; ")
+	fmt.Fprintf(out, "%s⚠ %s %s (%s:%d)%s\n", colorYellow, path, message, r.ConfFile, r.Line, colorReset)
+	addFinding(Finding{Category: catACLMismatch, Severity: severityWarning, Path: path, ConfFile: r.ConfFile, Line: r.Line, Message: message})
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// embeddedAdvisories is the advisory database shipped with the binary
+//
+//go:embed advisories.json
+var embeddedAdvisories []byte
+
+// advisoriesFile names an extra advisory database; entries with the ID of a
+// built-in advisory replace it
+var advisoriesFile string
+
+// advisory describes a known-dangerous rule pattern; every pattern that is
+// set must match, types lists the rule types it applies to and rules carrying
+// any of the unless modifiers are exempt
+type advisory struct {
+	ID       string `json:"id"`
+	CVE      string `json:"cve,omitempty"`
+	Severity string `json:"severity"`
+	Title    string `json:"title"`
+	Types    string `json:"types,omitempty"`
+	Unless   string `json:"unless_modifiers,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Mode     string `json:"mode,omitempty"`
+	User     string `json:"user,omitempty"`
+	Age      string `json:"age,omitempty"`
+	Argument string `json:"argument,omitempty"`
+
+	patterns []*regexp.Regexp
+	fields   []func(rule) string
+}
+
+// advisories holds the loaded database, see loadAdvisories
+var advisories []advisory
+
+// parseAdvisories decodes an advisory database and compiles its patterns
+func parseAdvisories(data []byte) ([]advisory, error) {
+	var list []advisory
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for i := range list {
+		a := &list[i]
+		if a.ID == "" {
+			return nil, fmt.Errorf("advisory %d has no id", i+1)
+		}
+		for _, p := range []struct {
+			pattern string
+			field   func(rule) string
+		}{
+			{a.Path, func(r rule) string { return r.Path }},
+			{a.Mode, func(r rule) string { return r.Mode }},
+			{a.User, func(r rule) string { return r.User }},
+			{a.Age, func(r rule) string { return r.Age }},
+			{a.Argument, func(r rule) string { return r.Argument }},
+		} {
+			if p.pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile(p.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("advisory %s: %w", a.ID, err)
+			}
+			a.patterns = append(a.patterns, re)
+			a.fields = append(a.fields, p.field)
+		}
+	}
+	return list, nil
+}
+
+// loadAdvisories reads the built-in database and merges advisoriesFile into it
+func loadAdvisories() error {
+	list, err := parseAdvisories(embeddedAdvisories)
+	if err != nil {
+		return fmt.Errorf("built-in advisories: %w", err)
+	}
+	if advisoriesFile != "" {
+		data, err := os.ReadFile(advisoriesFile)
+		if err != nil {
+			return err
+		}
+		extra, err := parseAdvisories(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", advisoriesFile, err)
+		}
+		for _, a := range extra {
+			replaced := false
+			for i := range list {
+				if list[i].ID == a.ID {
+					list[i], replaced = a, true
+				}
+			}
+			if !replaced {
+				list = append(list, a)
+			}
+		}
+	}
+	advisories = list
+	return nil
+}
+
+// matches reports whether a rule exhibits the advisory's pattern
+func (a advisory) matches(r rule) bool {
+	if a.Types != "" && !strings.Contains(a.Types, r.Type) {
+		return false
+	}
+	if a.Unless != "" && strings.ContainsAny(r.Modifiers, a.Unless) {
+		return false
+	}
+	for i, re := range a.patterns {
+		if !re.MatchString(a.fields[i](r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkAdvisories flags a rule matching any known-dangerous pattern
+func checkAdvisories(r rule) {
+	for _, a := range advisories {
+		if !a.matches(r) {
+			continue
+		}
+		severity, color, mark := severityWarning, colorYellow, "⚠"
+		if a.Severity == severityError {
+			severity, color, mark = severityError, colorRed, "✗"
+		}
+		id := a.ID
+		if a.CVE != "" {
+			id += " (" + a.CVE + ")"
+		}
+		fmt.Fprintf(out, "%s%s %s:%d matches advisory %s: %s%s\n", color, mark, r.ConfFile, r.Line, id, a.Title, colorReset)
+		addFinding(Finding{Category: catAdvisory, Severity: severity, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+			Message: id + ": " + a.Title})
+	}
+}
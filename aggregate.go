@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// fleetFinding is a finding together with every host it occurs on
+type fleetFinding struct {
+	Category string   `json:"category"`
+	Severity string   `json:"severity"`
+	Path     string   `json:"path"`
+	Target   string   `json:"target,omitempty"`
+	Message  string   `json:"message"`
+	Hosts    []string `json:"hosts"`
+}
+
+// fleetSummary is the merged view of many reports
+type fleetSummary struct {
+	Hosts      []string       `json:"hosts"`
+	Clean      []string       `json:"clean_hosts"`
+	Categories map[string]int `json:"hosts_per_category"`
+	Findings   []fleetFinding `json:"findings"`
+}
+
+// reportHost names the host a report came from, falling back to its file name
+func reportHost(r Report, file string) string {
+	if r.Host != "" {
+		return r.Host
+	}
+	return file
+}
+
+// aggregateReports merges reports, counting each finding once per host
+func aggregateReports(files []string, reports []Report) fleetSummary {
+	s := fleetSummary{Categories: make(map[string]int)}
+	byKey := make(map[string]*fleetFinding)
+	for i, r := range reports {
+		host := reportHost(r, files[i])
+		s.Hosts = append(s.Hosts, host)
+		if len(r.Findings) == 0 {
+			s.Clean = append(s.Clean, host)
+		}
+		seen := make(map[string]bool)
+		categories := make(map[string]bool)
+		for _, f := range r.Findings {
+			key := findingKey(Finding{Category: f.Category, Path: f.Path, Target: f.Target})
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			categories[f.Category] = true
+			ff, ok := byKey[key]
+			if !ok {
+				ff = &fleetFinding{Category: f.Category, Severity: f.Severity, Path: f.Path, Target: f.Target, Message: f.Message}
+				byKey[key] = ff
+			}
+			ff.Hosts = append(ff.Hosts, host)
+		}
+		for c := range categories {
+			s.Categories[c]++
+		}
+	}
+	for _, ff := range byKey {
+		s.Findings = append(s.Findings, *ff)
+	}
+	sort.Slice(s.Findings, func(i, j int) bool {
+		a, b := s.Findings[i], s.Findings[j]
+		if len(a.Hosts) != len(b.Hosts) {
+			return len(a.Hosts) > len(b.Hosts)
+		}
+		return a.Path < b.Path
+	})
+	return s
+}
+
+// runAggregate implements `aggregate REPORT...`
+func runAggregate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: aggregate REPORT...")
+		return 2
+	}
+	var reports []Report
+	for _, file := range args {
+		r, err := loadReport(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		reports = append(reports, r)
+	}
+	s := aggregateReports(args, reports)
+
+	if outputFormat == "json" {
+		data, _ := json.MarshalIndent(s, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+		return 0
+	}
+	total := len(s.Hosts)
+	fmt.Fprintf(out, "=== Fleet summary of %d host(s), %d clean ===\n", total, len(s.Clean))
+	categories := make([]string, 0, len(s.Categories))
+	for c := range s.Categories {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := categories[i], categories[j]
+		if s.Categories[a] != s.Categories[b] {
+			return s.Categories[a] > s.Categories[b]
+		}
+		return a < b
+	})
+	fmt.Fprintln(out, "\nHosts affected per category:")
+	for _, c := range categories {
+		fmt.Fprintf(out, "  %-28s %d/%d\n", c, s.Categories[c], total)
+	}
+	fmt.Fprintln(out, "\nFindings by frequency:")
+	for _, f := range s.Findings {
+		color := colorYellow
+		if f.Severity == severityError {
+			color = colorRed
+		}
+		fmt.Fprintf(out, "%s%4d/%d %s %s%s\n", color, len(f.Hosts), total, f.Category, f.Path, colorReset)
+		if len(f.Hosts) < total {
+			fmt.Fprintf(out, "         on %v\n", f.Hosts)
+		}
+	}
+	return 0
+}
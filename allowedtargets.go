@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// pathList is a flag value collecting comma-separated or repeated paths
+type pathList []string
+
+func (p *pathList) String() string { return strings.Join(*p, ",") }
+
+func (p *pathList) Set(value string) error {
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			if !filepath.IsAbs(path) {
+				return fmt.Errorf("%s is not an absolute path", path)
+			}
+			*p = append(*p, filepath.Clean(path))
+		}
+	}
+	return nil
+}
+
+// allowedTargets restricts where L rules may point; empty means anywhere
+var allowedTargets pathList
+
+// underPrefix reports whether path is prefix or lies below it
+func underPrefix(path, prefix string) bool {
+	return path == prefix || prefix == "/" || strings.HasPrefix(path, prefix+"/")
+}
+
+// targetAllowed reports whether path lies below one of the allowed prefixes
+func targetAllowed(path string) bool {
+	for _, prefix := range allowedTargets {
+		if underPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAllowedTargets flags L rules whose target, after following every
+// symlink on the way, leaves the allowed prefixes
+func checkAllowedTargets(list []rule) {
+	fmt.Fprintf(out, "\n=== Symlink targets outside %s ===\n", allowedTargets.String())
+	escapes := 0
+	for _, r := range list {
+		if r.Type != "L" {
+			continue
+		}
+		target := resolveTargetPath(r.Path, symlinkTarget(r))
+		resolved := canonicalPath(target)
+		if targetAllowed(resolved) {
+			continue
+		}
+		escapes++
+		via := ""
+		if resolved != target {
+			via = " (via " + target + ")"
+		}
+		fmt.Fprintf(out, "%s✗ %s -> %s%s is outside the allowed targets (%s:%d)%s\n", colorRed, r.Path, resolved, via, r.ConfFile, r.Line, colorReset)
+		addFinding(Finding{Category: catDisallowedTarget, Severity: severityError, Path: r.Path, Target: resolved, ConfFile: r.ConfFile, Line: r.Line,
+			Message: "symlink target" + via + " is outside the allowed target prefixes"})
+	}
+	if escapes == 0 {
+		fmt.Fprintf(out, "%s✓ All symlink targets stay within the allowed prefixes%s\n", colorGreen, colorReset)
+	}
+}
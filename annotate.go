@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// annotatePR turns findings into GitHub Actions annotations and a job summary
+var annotatePR bool
+
+// annotationEscaper escapes workflow command messages
+var annotationEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// annotationPropertyEscaper escapes workflow command property values
+var annotationPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// annotationFile maps a fragment on the audited system to the path GitHub
+// expects, relative to the checkout, so annotations land on the diff
+func annotationFile(conf string) string {
+	path := rootPath(conf)
+	if wd, err := os.Getwd(); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				return rel
+			}
+		}
+	}
+	return path
+}
+
+// printAnnotations emits one ::error or ::warning workflow command per finding
+func printAnnotations() {
+	for _, f := range findings {
+		level := "warning"
+		switch {
+		case blocksRun(f):
+			level = "error"
+		case f.Severity == severityInfo:
+			level = "notice"
+		}
+		props := []string{"title=" + annotationPropertyEscaper.Replace("tmpfiles-audit "+f.Code+": "+f.Category)}
+		if f.ConfFile != "" {
+			props = append(props, "file="+annotationPropertyEscaper.Replace(annotationFile(f.ConfFile)),
+				fmt.Sprintf("line=%d", f.Line))
+		}
+		message := f.Path + ": " + f.Message
+		if f.Target != "" {
+			message = f.Path + " -> " + f.Target + ": " + f.Message
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), annotationEscaper.Replace(message))
+	}
+}
+
+// markdownCell escapes a value for a markdown table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
+
+// writeStepSummary appends a markdown summary of the findings to GITHUB_STEP_SUMMARY
+func writeStepSummary(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	counts := make(map[string]int)
+	for _, finding := range findings {
+		counts[finding.Category]++
+	}
+	status := "✅ passed"
+	if hasErrors() {
+		status = "❌ failed"
+	}
+	fmt.Fprintf(f, "## tmpfiles-audit of %s: %s\n\n", describeRoot(), status)
+	if len(findings) == 0 {
+		fmt.Fprintln(f, "No findings.")
+		return nil
+	}
+	categories := make([]string, 0, len(counts))
+	for c := range counts {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+	fmt.Fprintln(f, "| Category | Findings |\n| --- | ---: |")
+	for _, c := range categories {
+		fmt.Fprintf(f, "| %s | %d |\n", c, counts[c])
+	}
+	fmt.Fprintln(f, "\n| Severity | Code | Category | Path | Rule | Message |\n| --- | --- | --- | --- | --- | --- |")
+	for _, finding := range findings {
+		severity := finding.Severity
+		if blocksRun(finding) {
+			severity = "**" + severity + "**"
+		}
+		location := ""
+		if finding.ConfFile != "" {
+			location = fmt.Sprintf("%s:%d", annotationFile(finding.ConfFile), finding.Line)
+		}
+		fmt.Fprintf(f, "| %s | %s | %s | `%s` | %s | %s |\n", severity, finding.Code, finding.Category,
+			markdownCell(finding.Path), markdownCell(location), markdownCell(finding.Message))
+	}
+	fmt.Fprintln(f)
+	return nil
+}
+
+// finishAnnotatePR writes the JSON report, the annotations and the job
+// summary; the report goes to TMPFILES_AUDIT_REPORT or tmpfiles-audit.json
+func finishAnnotatePR() int {
+	path := os.Getenv("TMPFILES_AUDIT_REPORT")
+	if path == "" {
+		path = imageBuildReportName
+	}
+	if err := saveReport(path, currentReport()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving report: %v\n", err)
+		return 1
+	}
+	printAnnotations()
+	if summary := os.Getenv("GITHUB_STEP_SUMMARY"); summary != "" {
+		if err := writeStepSummary(summary); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot write job summary: %v\n", err)
+		}
+	}
+	if hasErrors() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// baseDirList collects repeated --base-dir options
+type baseDirList []string
+
+func (l *baseDirList) String() string { return strings.Join(*l, ",") }
+
+func (l *baseDirList) Set(value string) error {
+	dir, err := checkBaseDir(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, dir)
+	return nil
+}
+
+var (
+	// baseDirsFlag holds the base directories given on the command line
+	baseDirsFlag baseDirList
+	// verbose makes the report list details such as exempted directories
+	verbose bool
+	// exemptedDirs records the directories isBaseDir exempted during an audit
+	exemptedDirs map[string]bool
+)
+
+// checkBaseDir validates a configured base directory; a trailing slash,
+// which makes it exempt every directory below as well, is kept
+func checkBaseDir(dir string) (string, error) {
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("base dir %q is not an absolute path", dir)
+	}
+	if dir != "/" && strings.HasSuffix(dir, "/") {
+		return filepath.Clean(dir) + "/", nil
+	}
+	return filepath.Clean(dir), nil
+}
+
+// baseDirs returns the base directories of the distribution profile,
+// the site config's base_dirs and every --base-dir
+func baseDirs() []string {
+	dirs := append([]string{}, profile.BaseDirs...)
+	dirs = append(dirs, site.BaseDirs...)
+	return append(dirs, baseDirsFlag...)
+}
+
+// isBaseDir returns true if a directory is a base system dir: one listed
+// in baseDirs, or below an entry ending in a slash
+func isBaseDir(dir string) bool {
+	for _, b := range baseDirs() {
+		prefix, isPrefix := strings.CutSuffix(b, "/")
+		if dir == b || isPrefix && underPrefix(dir, prefix) {
+			if exemptedDirs != nil {
+				exemptedDirs[dir] = true
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// printExemptedDirs lists the directories left out of completeness checks
+func printExemptedDirs() {
+	fmt.Fprintf(out, "\n=== Directories exempt from completeness checks ===\n")
+	if len(exemptedDirs) == 0 {
+		fmt.Fprintf(out, "%s✓ No directory was exempted%s\n", colorGreen, colorReset)
+		return
+	}
+	dirs := make([]string, 0, len(exemptedDirs))
+	for dir := range exemptedDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		fmt.Fprintf(out, "  %s\n", dir)
+	}
+}
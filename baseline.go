@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+var (
+	// baselineFile lists accepted findings that are no longer reported
+	baselineFile string
+	// updateBaseline records the current findings as the new baseline
+	updateBaseline bool
+)
+
+// baselineEntry is an accepted finding, optionally waived only until a date;
+// plain findings lists written by older versions load as entries without waivers
+type baselineEntry struct {
+	Finding
+	Owner   string `json:"owner,omitempty"`
+	Ticket  string `json:"ticket,omitempty"`
+	Expires string `json:"expires,omitempty"` // YYYY-MM-DD, the last day the waiver holds
+}
+
+// expired reports whether the entry's waiver has run out
+func (e baselineEntry) expired(now time.Time) (bool, error) {
+	if e.Expires == "" {
+		return false, nil
+	}
+	day, err := time.Parse(time.DateOnly, e.Expires)
+	if err != nil {
+		return false, fmt.Errorf("baseline entry for %s: invalid expiry %q", e.Path, e.Expires)
+	}
+	return now.After(day.AddDate(0, 0, 1)), nil
+}
+
+// describeWaiver names who accepted an entry and where it is tracked
+func (e baselineEntry) describeWaiver() string {
+	s := "waiver"
+	if e.Owner != "" {
+		s += " by " + e.Owner
+	}
+	if e.Ticket != "" {
+		s += " (" + e.Ticket + ")"
+	}
+	return s
+}
+
+// loadBaseline reads a baseline file
+func loadBaseline(path string) ([]baselineEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []baselineEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// saveBaseline atomically writes a baseline file
+func saveBaseline(path string, entries []baselineEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// applyBaseline drops accepted findings, or records them with --update-baseline,
+// so only regressions and findings whose waiver expired fail the run
+func applyBaseline() error {
+	previous, err := loadBaseline(baselineFile)
+	if err != nil && !(updateBaseline && errors.Is(err, fs.ErrNotExist)) {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("baseline %s does not exist; create it with --update-baseline", baselineFile)
+		}
+		return err
+	}
+	waivers := make(map[string]baselineEntry)
+	for _, e := range previous {
+		waivers[findingKey(e.Finding)] = e
+	}
+
+	if updateBaseline {
+		// Keep the owner, ticket and expiry of findings that were already accepted
+		entries := make([]baselineEntry, 0, len(findings))
+		for _, f := range findings {
+			e := waivers[findingKey(f)]
+			e.Finding = f
+			entries = append(entries, e)
+		}
+		if err := saveBaseline(baselineFile, entries); err != nil {
+			return fmt.Errorf("writing baseline: %w", err)
+		}
+		fmt.Fprintf(out, "\n%s✓ Recorded %d finding(s) as baseline in %s%s\n", colorGreen, len(findings), baselineFile, colorReset)
+		findings = nil
+		return nil
+	}
+
+	now := time.Now()
+	var accepted []Finding
+	expired := make(map[string]baselineEntry)
+	for _, e := range previous {
+		isExpired, err := e.expired(now)
+		if err != nil {
+			return err
+		}
+		if isExpired {
+			expired[findingKey(e.Finding)] = e
+			continue
+		}
+		accepted = append(accepted, e.Finding)
+	}
+	added, fixed := diffFindings(accepted, findings)
+	for i, f := range added {
+		if e, ok := expired[findingKey(f)]; ok {
+			added[i].Severity = severityError
+			added[i].Message = fmt.Sprintf("%s; %s expired on %s", f.Message, e.describeWaiver(), e.Expires)
+		}
+	}
+	findings = added
+
+	fmt.Fprintf(out, "\n=== Findings compared to baseline %s ===\n", baselineFile)
+	fmt.Fprintf(out, "Accepted in baseline: %d, expired waivers: %d, fixed since: %d\n", len(accepted)-len(fixed), len(expired), len(fixed))
+	if len(added) == 0 {
+		fmt.Fprintf(out, "%s✓ No new findings%s\n", colorGreen, colorReset)
+		return nil
+	}
+	for _, f := range added {
+		color := colorYellow
+		if f.Severity == severityError {
+			color = colorRed
+		}
+		fmt.Fprintf(out, "%s  new %s: %s %s%s\n", color, f.Category, f.Path, f.Message, colorReset)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// bisectCategory and bisectPath select the finding bisect looks for;
+	// the path may be a glob pattern
+	bisectCategory string
+	bisectPath     string
+)
+
+func bisectFlags(fs *flag.FlagSet) {
+	fs.StringVar(&bisectCategory, "category", "", "finding category to bisect for")
+	fs.StringVar(&bisectPath, "path", "", "rule path (or glob pattern) of the finding to bisect for")
+}
+
+// matchesBisect reports whether a finding is the one being bisected for
+func matchesBisect(f Finding) bool {
+	if bisectCategory != "" && f.Category != bisectCategory {
+		return false
+	}
+	if bisectPath != "" {
+		if ok, _ := filepath.Match(bisectPath, f.Path); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// bisectBuild audits one build and reports whether the finding is present
+func bisectBuild(arg string) (bool, error) {
+	r, cleanup, err := loadOperand(arg)
+	defer cleanup()
+	if err != nil {
+		return false, err
+	}
+	for _, f := range r.Findings {
+		if matchesBisect(f) {
+			fmt.Fprintf(out, "%s✗ %s: %s %s: %s%s\n", colorRed, arg, f.Category, f.Path, f.Message, colorReset)
+			return true, nil
+		}
+	}
+	fmt.Fprintf(out, "%s✓ %s: finding absent%s\n", colorGreen, arg, colorReset)
+	return false, nil
+}
+
+// runBisect implements `bisect BUILD...`: given builds oldest first, it
+// binary-searches for the first one that has the selected finding, assuming
+// that once introduced the finding stays until the newest build
+func runBisect(args []string) int {
+	if len(args) < 2 || (bisectCategory == "" && bisectPath == "") {
+		fmt.Fprintln(os.Stderr, "Usage: bisect --category CATEGORY and/or --path PATH BUILD... (oldest first)")
+		return 2
+	}
+	present, err := bisectBuild(args[len(args)-1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if !present {
+		fmt.Fprintf(out, "The finding is not present in the newest build %s\n", args[len(args)-1])
+		return 1
+	}
+	present, err = bisectBuild(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if present {
+		fmt.Fprintf(out, "The finding is already present in the oldest build %s\n", args[0])
+		return 0
+	}
+
+	// good is known to lack the finding, bad is known to have it
+	good, bad := 0, len(args)-1
+	for bad-good > 1 {
+		mid := (good + bad) / 2
+		present, err := bisectBuild(args[mid])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if present {
+			bad = mid
+		} else {
+			good = mid
+		}
+	}
+	fmt.Fprintf(out, "\n%s%s is the first build with the finding (last good: %s)%s\n", colorBoldRed, args[bad], args[good], colorReset)
+	return 0
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// verifyBoot compares what the rules declare with what exists since the last boot
+var verifyBoot bool
+
+// bootTime reads the system boot time from /proc/stat
+func bootTime() (time.Time, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no btime in /proc/stat")
+}
+
+// creationTypes maps rule types that create a path to the file type they create
+var creationTypes = map[string]os.FileMode{
+	"L": os.ModeSymlink,
+	"d": os.ModeDir, "D": os.ModeDir, "v": os.ModeDir, "q": os.ModeDir, "Q": os.ModeDir,
+	"f": 0, "F": 0,
+	"p": os.ModeNamedPipe,
+	"c": os.ModeDevice | os.ModeCharDevice,
+	"b": os.ModeDevice,
+}
+
+// symlinkTarget returns the target an L rule declares, applying the factory default
+func symlinkTarget(r rule) string {
+	if r.Argument != "" {
+		return r.Argument
+	}
+	return factoryPath(r.Path)
+}
+
+// runBootVerification checks each creating rule against the file system,
+// telling rules that systemd-tmpfiles never applied apart from paths that it
+// created during this boot but that were broken afterwards
+func runBootVerification(list []rule) {
+	boot, err := bootTime()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot determine boot time: %v\n", err)
+		return
+	}
+	if rootDir != "" {
+		fmt.Fprintf(os.Stderr, "Warning: --verify-boot compares %s against the running kernel's boot time\n", rootDir)
+	}
+	fmt.Fprintf(out, "\n=== Boot verification (booted %s) ===\n", boot.Format(time.RFC3339))
+	problems := 0
+	for _, r := range list {
+		want, ok := creationTypes[r.Type]
+		if !ok || strings.ContainsAny(r.Path, "*?[%") {
+			continue
+		}
+		fi, err := lstatPath(r.Path)
+		if err != nil {
+			problems++
+			fmt.Fprintf(out, "%s✗ Never created: %s (%s:%d)%s\n", colorRed, r.Path, r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catNeverCreated, Severity: severityError, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: fmt.Sprintf("%s rule path does not exist after boot", r.Type)})
+			continue
+		}
+
+		// A different file type, or a symlink pointing elsewhere, breaks the rule
+		broken := ""
+		if fi.Mode().Type() != want {
+			broken = fmt.Sprintf("expected %s, found %s", describeFileType(want), describeFileType(fi.Mode().Type()))
+		} else if r.Type == "L" {
+			target := symlinkTarget(r)
+			if link, err := readlinkPath(r.Path); err != nil || link != target {
+				broken = fmt.Sprintf("points to %s instead of %s", link, target)
+			} else if _, err := statPath(r.Path); err != nil {
+				broken = "dangles"
+			}
+		}
+		if broken == "" {
+			continue
+		}
+		problems++
+
+		// ctime tells whether the path was (re)created since boot
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok && !time.Unix(st.Ctim.Unix()).Before(boot) {
+			fmt.Fprintf(out, "%s✗ Created then broken: %s %s (%s:%d)%s\n", colorRed, r.Path, broken, r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catBrokenAfterBoot, Severity: severityError, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: "changed after boot: " + broken})
+		} else {
+			fmt.Fprintf(out, "%s✗ Never created: %s predates this boot and %s (%s:%d)%s\n", colorRed, r.Path, broken, r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catNeverCreated, Severity: severityError, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: "pre-existing path was never replaced: " + broken})
+		}
+	}
+	if problems == 0 {
+		fmt.Fprintf(out, "%s✓ Every rule's path exists as declared%s\n", colorGreen, colorReset)
+	}
+}
+
+// describeFileType names a file type for messages
+func describeFileType(t os.FileMode) string {
+	switch {
+	case t&os.ModeSymlink != 0:
+		return "symlink"
+	case t&os.ModeDir != 0:
+		return "directory"
+	case t&os.ModeNamedPipe != 0:
+		return "fifo"
+	case t&os.ModeCharDevice != 0:
+		return "character device"
+	case t&os.ModeDevice != 0:
+		return "block device"
+	case t&os.ModeSocket != 0:
+		return "socket"
+	}
+	return "regular file"
+}
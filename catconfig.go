@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const colorStrike = "\033[9m"
+
+// confDirColors tells the tmpfiles.d directories apart in cat-config output
+var confDirColors = map[string]string{
+	"/etc/tmpfiles.d":           "\033[36m",
+	"/run/tmpfiles.d":           "\033[35m",
+	"/usr/local/lib/tmpfiles.d": "\033[34m",
+	"/usr/lib/tmpfiles.d":       colorGreen,
+}
+
+// catConfigAnnotate adds audit findings below the lines they refer to
+var catConfigAnnotate bool
+
+func catConfigFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&catConfigAnnotate, "annotate", true, "run an audit and show its findings inline")
+}
+
+// runCatConfig prints the merged tmpfiles.d config like `systemd-analyze
+// cat-config tmpfiles.d`, listing shadowed fragments struck through
+func runCatConfig(args []string) int {
+	annotations := make(map[string]map[int][]Finding)
+	if catConfigAnnotate {
+		if err := quietAudit(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, f := range findings {
+			if f.ConfFile == "" {
+				continue
+			}
+			if annotations[f.ConfFile] == nil {
+				annotations[f.ConfFile] = make(map[int][]Finding)
+			}
+			annotations[f.ConfFile][f.Line] = append(annotations[f.ConfFile][f.Line], f)
+		}
+	}
+
+	for i, frag := range effectiveConfFiles() {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s# %s%s\n", confDirColors[filepath.Dir(frag.Path)], frag.Path, colorReset)
+		for _, shadowed := range frag.Shadows {
+			fmt.Fprintf(out, "%s# %s%s%s (shadowed)%s\n", confDirColors[filepath.Dir(shadowed)], colorStrike, shadowed, colorReset, colorReset)
+		}
+		for n, line := range readLines(frag.Path) {
+			fmt.Fprintln(out, line)
+			for _, f := range annotations[frag.Path][n+1] {
+				color, mark := colorYellow, "⚠"
+				switch f.Severity {
+				case severityError:
+					color, mark = colorRed, "✗"
+				case severityInfo:
+					color, mark = "", "ℹ"
+				}
+				note := f.Message
+				if f.Target != "" {
+					note += ": " + f.Target
+				}
+				fmt.Fprintf(out, "%s#   %s %s [%s]%s\n", color, mark, strings.TrimSpace(note), f.Category, colorReset)
+			}
+		}
+	}
+	return 0
+}
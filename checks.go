@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// checkRule runs the checks that apply to any rule type, then the per-type
+// ones; L rules are additionally handled by processLine
+func checkRule(r rule) {
+	checkAccounts(r)
+	checkAdvisories(r)
+	checkLints(r)
+	if strings.Contains("dDevqQfFpcbzZ", r.Type) {
+		checkAttributes(r)
+	}
+	switch r.Type {
+	case "z", "Z":
+		checkSELinux(r)
+	case "a", "A":
+		checkACL(r)
+	case "h", "H":
+		checkAttributeFlags(r)
+	}
+}
+
+// rulePaths expands the glob a rule path may contain into existing paths;
+// paths with unresolved specifiers are skipped
+func rulePaths(r rule) []string {
+	if strings.Contains(r.Path, "%") {
+		return nil
+	}
+	if !strings.ContainsAny(r.Path, "*?[") {
+		if _, err := lstatPath(r.Path); err != nil {
+			return nil
+		}
+		return []string{r.Path}
+	}
+	matches, _ := globPath(r.Path)
+	return matches
+}
+
+// checkAccounts reports user and group names the audited system does not
+// know and sysusers.d does not declare, which make systemd-tmpfiles reject
+// the line; --check-sysusers also reports known ones only this system has
+func checkAccounts(r rule) {
+	for _, account := range []struct{ kind, name string }{{"user", r.User}, {"group", r.Group}} {
+		// A leading ':' only restricts the ownership to newly created paths
+		name := strings.TrimPrefix(account.name, ":")
+		if name == "" || name == "-" || strings.Contains(name, "%") {
+			continue
+		}
+		lookup := lookupUser
+		if account.kind == "group" {
+			lookup = lookupGroup
+		}
+		_, known := lookup(name)
+		decl, declared := declaredBySysusers(account.kind, name)
+		switch {
+		case known && (!checkSysusers || declared || isNumericID(name) || inBaseAccounts(account.kind, name)):
+			continue
+		case known:
+			fmt.Fprintf(out, "%s⚠ %s %s exists here but is not declared by sysusers.d (%s:%d)%s\n", colorYellow, strings.ToUpper(account.kind[:1])+account.kind[1:], name, r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catUndeclaredAccount, Severity: severityWarning, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: fmt.Sprintf("%s %s is not declared by sysusers.d, so the rule fails on a freshly provisioned system", account.kind, name)})
+			continue
+		case declared:
+			fmt.Fprintf(out, "  %s⤷ %s %s is created by sysusers.d before tmpfiles runs (%s:%d)%s\n", colorYellow, account.kind, name, decl.ConfFile, decl.Line, colorReset)
+			continue
+		}
+		fmt.Fprintf(out, "%s✗ Unknown %s %s in %s:%d%s\n", colorRed, account.kind, name, r.ConfFile, r.Line, colorReset)
+		addFinding(Finding{Category: catUnknownAccount, Severity: severityError, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+			Message: fmt.Sprintf("%s %s does not exist on %s", account.kind, name, describeRoot())})
+	}
+}
+
+// checkAttributes compares the mode and ownership of existing paths with the
+// rule's declaration; world-writable paths the rule does not ask for are errors
+func checkAttributes(r rule) {
+	// '~' masks the mode against the existing one and ':' only applies it on
+	// creation, so neither can be compared with an existing path
+	var wantMode uint32
+	checkMode := r.Mode != "" && r.Mode != "-" && !strings.ContainsAny(r.Mode, "~:")
+	if checkMode {
+		m, err := strconv.ParseUint(r.Mode, 8, 32)
+		if err != nil {
+			return
+		}
+		wantMode = uint32(m)
+	}
+	owner := func(name string, lookup func(string) (uint32, bool)) (uint32, bool) {
+		if name == "" || name == "-" || strings.HasPrefix(name, ":") || strings.Contains(name, "%") {
+			return 0, false
+		}
+		return lookup(name)
+	}
+	wantUID, checkUID := owner(r.User, lookupUser)
+	wantGID, checkGID := owner(r.Group, lookupGroup)
+
+	for _, path := range rulePaths(r) {
+		fi, err := lstatPath(path)
+		if err != nil {
+			continue
+		}
+		st, ok := fi.Sys().(*syscall.Stat_t)
+		if !ok || fi.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		mode := st.Mode & 07777
+		// Without a declared mode the rule leaves the mode alone, and a
+		// sticky world-writable directory such as /var/tmp is intended
+		stickyDir := fi.IsDir() && mode&01000 != 0
+		if mode&0002 != 0 && (!checkMode && !stickyDir || checkMode && wantMode&0002 == 0) {
+			fmt.Fprintf(out, "%s✗ WORLD-WRITABLE: %s is %04o (%s:%d)%s\n", colorBoldRed, path, mode, r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catWorldWritable, Severity: severityError, Path: path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: fmt.Sprintf("path is world-writable (%04o) but the rule declares %s", mode, r.Mode)})
+		}
+		if checkMode && mode != wantMode {
+			fmt.Fprintf(out, "%s⚠ Mode mismatch: %s is %04o, rule declares %04o (%s:%d)%s\n", colorYellow, path, mode, wantMode, r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catModeMismatch, Severity: severityWarning, Path: path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: fmt.Sprintf("mode is %04o, rule declares %04o", mode, wantMode)})
+		}
+		if checkUID && st.Uid != wantUID || checkGID && st.Gid != wantGID {
+			got := userName(st.Uid) + ":" + groupName(st.Gid)
+			want := r.User + ":" + r.Group
+			fmt.Fprintf(out, "%s⚠ Ownership mismatch: %s is owned by %s, rule declares %s (%s:%d)%s\n", colorYellow, path, got, want, r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catOwnerMismatch, Severity: severityWarning, Path: path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: fmt.Sprintf("owned by %s, rule declares %s", got, want)})
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is a subcommand given after the global options
+type command struct {
+	usage string
+	flags func(fs *flag.FlagSet) // registers command-specific options, may be nil
+	run   func(args []string) int
+}
+
+// commands lists the available subcommands by name
+var commands = map[string]command{
+	"aggregate": {
+		usage: "merge JSON reports from many hosts into a fleet summary (REPORT...)",
+		run:   runAggregate,
+	},
+	"bisect": {
+		usage: "find the first of several builds, oldest first, that has a finding (--category/--path BUILD...)",
+		flags: bisectFlags,
+		run:   runBisect,
+	},
+	"cat-config": {
+		usage: "print the merged tmpfiles.d config annotated with audit findings",
+		flags: catConfigFlags,
+		run:   runCatConfig,
+	},
+	"diff": {
+		usage: "compare rules and findings of two reports, snapshots, roots or images (A B)",
+		run:   runDiff,
+	},
+	"doctor": {
+		usage: "check that the auditor's environment is usable and suggest fixes",
+		run:   runDoctor,
+	},
+	"graph": {
+		usage: "export the provenance graph of conf files, rules, paths, targets and owning packages as DOT, or JSON with --format json",
+		run:   runGraph,
+	},
+	"history": {
+		usage: "export findings per category of all snapshots as a time series (--export csv|json)",
+		flags: historyFlags,
+		run:   runHistory,
+	},
+	"keygen": {
+		usage: "generate an Ed25519 key pair for signing reports (PREFIX, writes PREFIX and PREFIX.pub)",
+		run:   runKeygen,
+	},
+	"manifest": {
+		usage: "record SHA-256 digests of the factory files rules refer to ([FILE], default stdout)",
+		run:   runManifest,
+	},
+	"prefix-map": {
+		usage: "map which packages claim which path prefixes and how many prefixes each pair shares (--depth N)",
+		flags: prefixMapFlags,
+		run:   runPrefixMap,
+	},
+	"serve": {
+		usage: "serve audits over HTTP: a web UI at /, POST /audit, GET /report, /snapshots[/NAME], /events (SSE), /metrics (--listen ADDR, default :8090)",
+		run:   runServe,
+	},
+	"simulate-reset": {
+		usage: "predict what a factory reset of /etc and /var (or DIR...) links, copies, creates and loses",
+		run:   runSimulateReset,
+	},
+	"sign": {
+		usage: "write a detached Ed25519 signature of a JSON report (--key PRIVATE-KEY REPORT)",
+		flags: signingFlags,
+		run:   runSign,
+	},
+	"slot-diff": {
+		usage: "compare rules, factory content and findings of the two slots of an A/B system, failing if they differ (A B)",
+		run:   runSlotDiff,
+	},
+	"snapshot": {
+		usage: "save the full report under a name (save NAME) or list saved snapshots (list)",
+		run:   runSnapshot,
+	},
+	"sysupdate": {
+		usage: "audit sysupdate versions that are installed but not activated yet ([TRANSFER...], default every transfer)",
+		run:   runSysupdate,
+	},
+	"tail": {
+		usage: "print the daemon's recent audit events from its Varlink socket (-f to follow, -n N)",
+		flags: tailFlags,
+		run:   runTail,
+	},
+	"verify-signature": {
+		usage: "check a JSON report against its signature (--key PUBLIC-KEY REPORT [SIGNATURE])",
+		flags: signingFlags,
+		run:   runVerifySignature,
+	},
+	"verify-manifest": {
+		usage: "check the factory files rules refer to against a recorded manifest (MANIFEST)",
+		run:   runVerifyManifest,
+	},
+}
+
+// commandFlags builds the option set of a subcommand; global options are
+// accepted after the subcommand name as well
+func commandFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	flag.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	if cmd := commands[name]; cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	return fs
+}
+
+// printCommands appends the subcommand list to the usage message
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(flag.CommandLine.Output(), "\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s\n    \t%s\n", name, commands[name].usage)
+	}
+}
+
+// parseCommand selects the subcommand named by the first argument, if any,
+// and parses its options
+func parseCommand() (command, []string, bool) {
+	// In --staged mode the arguments are the files to audit
+	if flag.NArg() == 0 || stagedMode {
+		return command{}, nil, false
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+	fs := commandFlags(flag.Arg(0))
+	fs.Parse(flag.Args()[1:])
+	return cmd, fs.Args(), true
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// composefsRedirect is the xattr of a composefs metadata file naming the
+// object that holds its content, relative to the object store
+const composefsRedirect = "trusted.overlay.redirect"
+
+// composefsRoot describes a root mounted from a composefs image: an
+// overlay of EROFS metadata layers over data-only object store layers
+type composefsRoot struct {
+	Metadata []string // host paths of the metadata layers
+	Data     []string // host paths of the object stores
+}
+
+// composefs is the composefs mount of the audited root, nil if it is none
+var composefs *composefsRoot
+
+// detectComposefs recognizes a composefs root by its overlay having
+// data-only layers, given as "::"-separated lowerdir= entries or with
+// the datadir+= option of newer kernels
+func detectComposefs() *composefsRoot {
+	mounts, err := readMounts()
+	if err != nil {
+		return nil
+	}
+	m, ok := mountOf(mounts, "/")
+	if !ok || m.FSType != "overlay" {
+		return nil
+	}
+	c := &composefsRoot{}
+	for _, opt := range strings.Split(m.Options, ",") {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "lowerdir":
+			layers := strings.Split(value, "::")
+			c.Metadata = append(c.Metadata, strings.Split(layers[0], ":")...)
+			for _, data := range layers[1:] {
+				c.Data = append(c.Data, strings.Split(data, ":")...)
+			}
+		case "lowerdir+":
+			c.Metadata = append(c.Metadata, value)
+		case "datadir+":
+			c.Data = append(c.Data, value)
+		}
+	}
+	if len(c.Data) == 0 {
+		return nil
+	}
+	return c
+}
+
+// backingObject returns the object holding a path's content, looked up in
+// the metadata layers since the overlay hides its own xattrs
+func (c *composefsRoot) backingObject(path string) (string, bool) {
+	logical := canonicalPath(path)
+	buf := make([]byte, unix.PathMax)
+	for _, layer := range c.Metadata {
+		if n, err := unix.Lgetxattr(filepath.Join(layer, logical), composefsRedirect, buf); err == nil && n > 0 {
+			return string(buf[:n]), true
+		}
+	}
+	return "", false
+}
+
+// objectExists reports whether an object is present in one of the stores
+func (c *composefsRoot) objectExists(object string) bool {
+	for _, store := range c.Data {
+		if _, err := os.Stat(filepath.Join(store, object)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBackingObject reports an existing target whose content object is
+// missing from the store: its metadata stats fine, but it cannot be read.
+// The finding names the logical path; the object only appears in the message
+func checkBackingObject(path, target, conf string, lineNo int) {
+	if composefs == nil {
+		return
+	}
+	object, ok := composefs.backingObject(target)
+	if !ok || composefs.objectExists(object) {
+		return
+	}
+	fmt.Fprintf(out, "  %s✗ Content missing: composefs object %s is not in the object store%s\n", colorRed, object, colorReset)
+	addFinding(Finding{Category: catMissingObject, Severity: severityError, Path: path, Target: target, ConfFile: conf, Line: lineNo,
+		Message: fmt.Sprintf("target exists, but its composefs object %s is missing", object)})
+}
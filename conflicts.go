@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ownershipTypes are the rule types that claim a path, of which systemd
+// applies only the first for each path (takes_ownership() in tmpfiles.c)
+const ownershipTypes = "fFwdDevqQpLcbCxXrR"
+
+// effectiveRules parses the effective config in the order systemd-tmpfiles
+// processes it: fragments by file name, lines in file order
+func effectiveRules() []rule {
+	var rules []rule
+	for _, frag := range effectiveConfFiles() {
+		for i, raw := range readLines(frag.Path) {
+			line := strings.TrimSpace(raw)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if r, err := parseRuleCached(expandSpecifiers(line)); err == nil {
+				r.ConfFile, r.Line = frag.Path, i+1
+				rules = append(rules, r)
+			}
+		}
+	}
+	return rules
+}
+
+// sameClaim reports whether two rules for a path would be merged by systemd
+// rather than one of them being ignored
+func sameClaim(a, b rule) bool {
+	return a.Type == b.Type && a.Argument == b.Argument && a.Mode == b.Mode &&
+		a.User == b.User && a.Group == b.Group && a.Age == b.Age
+}
+
+// inAuditScope reports whether a rule belongs to what is being audited
+func inAuditScope(r rule) bool {
+	return packagePayload == nil || packagePayload[r.ConfFile]
+}
+
+// checkConflicts reports paths claimed by several rules that disagree; systemd
+// applies the first one in processing order and ignores the others
+func checkConflicts(rules []rule) {
+	fmt.Fprintln(out, "\n=== Conflicting rules ===")
+	first := make(map[string]rule)
+	conflicts := 0
+	for _, r := range rules {
+		if !strings.Contains(ownershipTypes, r.Type) {
+			continue
+		}
+		winner, ok := first[r.Path]
+		if !ok {
+			first[r.Path] = r
+			continue
+		}
+		if sameClaim(winner, r) || (!inAuditScope(winner) && !inAuditScope(r)) {
+			continue
+		}
+		conflicts++
+		category, severity, color, what := catConflictingRule, severityWarning, colorYellow, "different parameters"
+		if winner.Type != r.Type {
+			severity, color, what = severityError, colorRed, "a different type"
+		}
+		fmt.Fprintf(out, "%s✗ %s is declared with %s%s\n", color, r.Path, what, colorReset)
+		fmt.Fprintf(out, "   applied: %s (%s:%d)\n   ignored: %s (%s:%d)\n",
+			formatRule(winner), winner.ConfFile, winner.Line, formatRule(r), r.ConfFile, r.Line)
+		if linkCopyPair(winner, r) {
+			category = catLinkCopyConflict
+			explainLinkCopy(winner, r)
+		}
+		addFinding(Finding{Category: category, Severity: severity, Path: r.Path, Target: r.Argument, ConfFile: r.ConfFile, Line: r.Line,
+			Message: fmt.Sprintf("ignored by systemd: conflicts with %q at %s:%d, which is applied first", formatRule(winner), winner.ConfFile, winner.Line)})
+	}
+	conflicts += checkNestedLinkCopy(rules)
+	if conflicts == 0 {
+		fmt.Fprintf(out, "%s✓ No path is claimed by conflicting rules%s\n", colorGreen, colorReset)
+	}
+}
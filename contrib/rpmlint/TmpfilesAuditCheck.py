@@ -0,0 +1,27 @@
+# SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+# SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+#
+# rpmlint check running tmpfiles-audit against each binary package.
+# Install into the rpmlint/checks directory and enable it with
+# tmpfiles-audit.toml, e.g. in an OBS project's rpmlintrc setup.
+
+import subprocess
+
+from rpmlint.checks.AbstractCheck import AbstractCheck
+
+
+class TmpfilesAuditCheck(AbstractCheck):
+    def check_binary(self, pkg):
+        try:
+            result = subprocess.run(
+                ['tmpfiles-audit', '--format=rpmlint', '--package-file', pkg.filename],
+                capture_output=True, text=True, check=False)
+        except FileNotFoundError:
+            return
+        for line in result.stdout.splitlines():
+            # "<subject>: <E|W|I>: <check-name> <details>"
+            parts = line.split(': ', 2)
+            if len(parts) != 3 or parts[1] not in ('E', 'W', 'I'):
+                continue
+            name, _, details = parts[2].partition(' ')
+            self.output.add_info(parts[1], pkg, name, details)
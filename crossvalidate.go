@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tmpfilesDirs are systemd's tmpfiles.d search directories, highest precedence first
+var tmpfilesDirs = []string{"/etc/tmpfiles.d", "/run/tmpfiles.d", "/usr/local/lib/tmpfiles.d", "/usr/lib/tmpfiles.d"}
+
+// crossValidate enables comparing the auditor's view of the config with systemd's
+var crossValidate bool
+
+// confFragment is a tmpfiles.d fragment selected by systemd's precedence rules
+type confFragment struct {
+	Path    string
+	Shadows []string // same-named fragments in lower-precedence directories
+}
+
+// isMasked reports whether a fragment is masked, i.e. a symlink to /dev/null or empty
+func isMasked(path string) bool {
+	if target, err := os.Readlink(rootPath(path)); err == nil && target == "/dev/null" {
+		return true
+	}
+	fi, err := statPath(path)
+	return err == nil && fi.Size() == 0
+}
+
+// effectiveConfFiles returns the tmpfiles.d fragments systemd-tmpfiles reads
+func effectiveConfFiles() []confFragment {
+	return confFragments(tmpfilesDirs)
+}
+
+// confFragments reimplements systemd's conf_files_list(): fragments are
+// ordered by file name, and a name in a higher-precedence directory shadows
+// (or, if masked, removes) the same name in lower ones
+func confFragments(dirs []string) []confFragment {
+	byName := make(map[string]*confFragment)
+	masked := make(map[string]bool)
+	for _, dir := range dirs {
+		matches, _ := globPath(dir + "/*.conf")
+		for _, path := range matches {
+			name := filepath.Base(path)
+			if frag, ok := byName[name]; ok {
+				frag.Shadows = append(frag.Shadows, path)
+				continue
+			}
+			if masked[name] {
+				continue
+			}
+			if isMasked(path) {
+				masked[name] = true
+				continue
+			}
+			byName[name] = &confFragment{Path: path}
+		}
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	frags := make([]confFragment, 0, len(names))
+	for _, name := range names {
+		frags = append(frags, *byName[name])
+	}
+	return frags
+}
+
+// readLines returns the lines of a file on the audited system
+func readLines(path string) []string {
+	f, err := openPath(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	return scanLines(f)
+}
+
+// scanLines splits a reader into lines
+func scanLines(r io.Reader) []string {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// systemdCatConfig returns the effective config as systemd-tmpfiles --cat-config
+// prints it, keyed by fragment path, falling back to the built-in concatenation
+// if systemd-tmpfiles is not available
+func systemdCatConfig() (order []string, contents map[string][]string, source string) {
+	contents = make(map[string][]string)
+	args := []string{"--cat-config"}
+	if rootDir != "" {
+		args = append(args, "--root="+rootDir)
+	}
+	output, err := exec.Command("systemd-tmpfiles", args...).Output()
+	if err != nil {
+		for _, frag := range effectiveConfFiles() {
+			order = append(order, frag.Path)
+			contents[frag.Path] = readLines(frag.Path)
+		}
+		return order, contents, "built-in concatenation"
+	}
+
+	// Fragments are introduced by "# /path/to/file.conf" header lines
+	current := ""
+	for _, line := range scanLines(bytes.NewReader(output)) {
+		if header, ok := strings.CutPrefix(line, "# "); ok && strings.HasSuffix(header, ".conf") {
+			if rootDir != "" {
+				header = "/" + strings.TrimPrefix(strings.TrimPrefix(header, filepath.Clean(rootDir)), "/")
+			}
+			if isTmpfilesFragment(header) {
+				// Drop the blank separator line printed before each header
+				if n := len(contents[current]); current != "" && n > 0 && contents[current][n-1] == "" {
+					contents[current] = contents[current][:n-1]
+				}
+				current = header
+				order = append(order, current)
+				continue
+			}
+		}
+		if current != "" {
+			contents[current] = append(contents[current], line)
+		}
+	}
+	return order, contents, "systemd-tmpfiles --cat-config"
+}
+
+// isTmpfilesFragment reports whether path names a fragment in a tmpfiles.d directory
+func isTmpfilesFragment(path string) bool {
+	for _, dir := range tmpfilesDirs {
+		if filepath.Dir(path) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// runCrossValidation diffs the rules systemd applies against the auditor's
+// own parse of the fragments it read, reporting any interpretation drift
+func runCrossValidation(audited []string) {
+	order, contents, source := systemdCatConfig()
+	fmt.Fprintf(out, "\n=== Cross-validation against %s ===\n", source)
+
+	auditedSet := make(map[string]bool)
+	for _, file := range audited {
+		auditedSet[file] = true
+	}
+	applied := make(map[string]bool)
+	drift := 0
+
+	for _, file := range order {
+		applied[file] = true
+		if !auditedSet[file] {
+			fmt.Fprintf(out, "%s⚠ %s is applied by systemd but not read by the auditor%s\n", colorYellow, file, colorReset)
+			addFinding(Finding{Category: catUnauditedConf, Severity: severityWarning, Path: file,
+				Message: "fragment is part of systemd's effective config but was not audited"})
+			continue
+		}
+		for i, raw := range contents[file] {
+			line := strings.TrimSpace(raw)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			r, err := parseRule(line)
+			if err != nil || r.Type != "L" {
+				continue
+			}
+			want := r.Argument
+			if want == "" {
+				want = factoryPath(r.Path)
+			}
+
+			path, target, ok := parseSymlinkLine(line)
+			var got string
+			switch {
+			case !ok:
+				got = "(line skipped)"
+			case target == "" || target == "-":
+				got = path + " -> " + factoryTarget(path)
+			default:
+				got = path + " -> " + target
+			}
+			if got == r.Path+" -> "+want {
+				continue
+			}
+			drift++
+			fmt.Fprintf(out, "%s✗ %s:%d interpreted differently%s\n", colorRed, file, i+1, colorReset)
+			fmt.Fprintf(out, "   systemd: %s -> %s\n   auditor: %s\n", r.Path, want, got)
+			addFinding(Finding{Category: catParserDrift, Severity: severityWarning, Path: r.Path, Target: want, ConfFile: file, Line: i + 1,
+				Message: "auditor interprets this line as " + got})
+		}
+	}
+
+	for _, file := range audited {
+		if !applied[file] {
+			fmt.Fprintf(out, "%s⚠ %s was audited but systemd does not apply it (shadowed or masked)%s\n", colorYellow, file, colorReset)
+			addFinding(Finding{Category: catShadowedConf, Severity: severityWarning, Path: file,
+				Message: "fragment was audited but is not part of systemd's effective config"})
+		}
+	}
+	if drift == 0 {
+		fmt.Fprintf(out, "%s✓ All L rules are interpreted identically%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// listenAddr is where the daemon serves its HTTP endpoints; "" disables them
+var listenAddr string
+
+// daemonRun summarizes one audit of the resident daemon
+type daemonRun struct {
+	Started  time.Time
+	Duration time.Duration
+	Report   Report
+	Err      error
+	Audits   int // audits attempted so far, including this one
+	Complete int // audits that completed so far
+}
+
+// daemonState holds what the resident daemon knows about the audited
+// system; audits are serialized, readers get a consistent copy
+type daemonState struct {
+	mu     sync.Mutex
+	latest daemonRun
+
+	subMu       sync.Mutex
+	subscribers map[chan watchEvent]bool
+	events      []watchEvent // the latest eventLogSize events, oldest first
+}
+
+// eventLogSize bounds the daemon's in-memory event log
+const eventLogSize = 1000
+
+// daemon is the state of the resident audit process
+var daemon daemonState
+
+// reaudit runs a new audit and returns the findings that appeared and
+// disappeared since the previous successful one
+func (d *daemonState) reaudit() (added, resolved []Finding, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	run := daemonRun{Started: time.Now(), Audits: d.latest.Audits + 1, Complete: d.latest.Complete}
+	run.Err = runAudit()
+	run.Duration = time.Since(run.Started)
+	if run.Err != nil {
+		// Keep the last good report so a transient failure doesn't look
+		// like every finding was resolved
+		run.Report = d.latest.Report
+		d.latest = run
+		return nil, nil, run.Err
+	}
+	run.Report = currentReport()
+	if d.latest.Complete > 0 {
+		added, resolved = diffFindings(d.latest.Report.Findings, run.Report.Findings)
+	} else {
+		added = run.Report.Findings
+	}
+	run.Complete++
+	d.latest = run
+	return added, resolved, nil
+}
+
+// snapshot returns the latest run
+func (d *daemonState) snapshot() daemonRun {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.latest
+}
+
+// subscribe registers a listener for the events of future audits; the
+// returned function unregisters it
+func (d *daemonState) subscribe() (<-chan watchEvent, func()) {
+	ch := make(chan watchEvent, 256)
+	d.subMu.Lock()
+	if d.subscribers == nil {
+		d.subscribers = make(map[chan watchEvent]bool)
+	}
+	d.subscribers[ch] = true
+	d.subMu.Unlock()
+	return ch, func() {
+		d.subMu.Lock()
+		delete(d.subscribers, ch)
+		d.subMu.Unlock()
+	}
+}
+
+// broadcast records an event in the log and hands it to every subscriber;
+// slow subscribers miss events rather than stalling the daemon
+func (d *daemonState) broadcast(e watchEvent) {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+	if len(d.events) == eventLogSize {
+		d.events = append(d.events[:0], d.events[1:]...)
+	}
+	d.events = append(d.events, e)
+	for ch := range d.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// recentEvents returns up to n of the latest logged events, oldest first
+func (d *daemonState) recentEvents(n int) []watchEvent {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+	if n < 0 || n > len(d.events) {
+		n = len(d.events)
+	}
+	return append([]watchEvent{}, d.events[len(d.events)-n:]...)
+}
+
+// daemonAudit runs one audit of the daemon and publishes what changed
+func daemonAudit() (added, resolved []Finding, err error) {
+	added, resolved, err = daemon.reaudit()
+	now := time.Now()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: audit failed: %v\n", err)
+		e := watchEvent{Event: "error", Time: now, Error: err.Error()}
+		daemon.broadcast(e)
+		if !journalEnabled() {
+			json.NewEncoder(os.Stdout).Encode(e)
+		}
+	}
+	publishDelta(os.Stdout, added, resolved)
+	run := daemon.snapshot()
+	// The first audit has nothing to compare with, so everything is "new"
+	if run.Complete > 1 {
+		notifyWebhooks(added)
+	}
+	for i := range added {
+		daemon.broadcast(watchEvent{Event: "added", Time: now, Finding: &added[i]})
+	}
+	for i := range resolved {
+		daemon.broadcast(watchEvent{Event: "resolved", Time: now, Finding: &resolved[i]})
+	}
+	if err == nil {
+		daemon.broadcast(watchEvent{Event: "audit", Time: now, Summary: fmt.Sprintf("audit %d: %d finding(s), %d new, %d resolved in %s",
+			run.Audits, len(run.Report.Findings), len(added), len(resolved), run.Duration.Round(time.Millisecond))})
+	}
+	sdNotify(fmt.Sprintf("STATUS=%d finding(s) at %s", len(run.Report.Findings), run.Started.Format(time.TimeOnly)))
+	return added, resolved, err
+}
+
+// startHTTP serves the daemon's HTTP endpoints on listenAddr in the
+// background; the listener is opened right away so errors are reported
+// before the daemon declares itself ready
+func startHTTP() error {
+	if listenAddr == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", serveWebUI)
+	mux.HandleFunc("GET /metrics", serveMetrics)
+	mux.HandleFunc("POST /audit", serveAudit)
+	mux.HandleFunc("GET /report", serveReport)
+	mux.HandleFunc("GET /snapshots", serveSnapshotList)
+	mux.HandleFunc("GET /snapshots/{name}", serveSnapshot)
+	mux.HandleFunc("GET /events", serveEvents)
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: HTTP server: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+	return nil
+}
+
+// startListeners opens the daemon's HTTP, Varlink and query endpoints, if configured
+func startListeners() error {
+	for _, start := range []func() error{startHTTP, startVarlink, startQuerySocket} {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
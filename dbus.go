@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	dbusName      = "org.kde.TmpfilesAudit"
+	dbusPath      = "/org/kde/TmpfilesAudit"
+	dbusInterface = "org.kde.TmpfilesAudit1"
+)
+
+var (
+	// dbusService makes the process serve audit results on D-Bus instead of auditing once
+	dbusService bool
+	// dbusBus selects the bus to register on: "system" or "session"
+	dbusBus = "system"
+)
+
+// dbusFinding is the wire form of a Finding, signature (sssssiss)
+type dbusFinding struct {
+	Category string
+	Severity string
+	Path     string
+	Target   string
+	ConfFile string
+	Line     int32
+	Package  string
+	Message  string
+}
+
+// dbusAuditor is the object exported at dbusPath
+type dbusAuditor struct {
+	mu     sync.Mutex
+	conn   *dbus.Conn
+	props  *prop.Properties
+	latest []Finding
+}
+
+// audit runs a new audit and publishes its results as properties and a signal
+func (d *dbusAuditor) audit() (errors, warnings uint32, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.props.SetMust(dbusInterface, "Running", true)
+	defer d.props.SetMust(dbusInterface, "Running", false)
+	if err := runAudit(); err != nil {
+		return 0, 0, err
+	}
+	d.latest = findings
+	blocking, other := countBlocking(d.latest)
+	errors, warnings = uint32(blocking), uint32(other)
+	d.props.SetMust(dbusInterface, "LastAudit", uint64(time.Now().UnixMicro()))
+	d.props.SetMust(dbusInterface, "FindingCount", uint32(len(d.latest)))
+	d.props.SetMust(dbusInterface, "ErrorCount", errors)
+	d.props.SetMust(dbusInterface, "WarningCount", warnings)
+	d.conn.Emit(dbusPath, dbusInterface+".AuditFinished", errors, warnings)
+	return errors, warnings, nil
+}
+
+// Audit triggers a new audit and returns the number of errors and warnings;
+// audits run plugins as root, so on the system bus only root may start one
+func (d *dbusAuditor) Audit(sender dbus.Sender) (uint32, uint32, *dbus.Error) {
+	if dbusBus == "system" {
+		var uid uint32
+		if err := d.conn.BusObject().Call("org.freedesktop.DBus.GetConnectionUnixUser", 0, string(sender)).Store(&uid); err != nil {
+			return 0, 0, dbus.MakeFailedError(err)
+		}
+		if uid != 0 {
+			return 0, 0, dbus.NewError("org.freedesktop.DBus.Error.AccessDenied", []any{"only root may start an audit"})
+		}
+	}
+	errors, warnings, err := d.audit()
+	if err != nil {
+		return 0, 0, dbus.MakeFailedError(err)
+	}
+	return errors, warnings, nil
+}
+
+// GetFindings returns the findings of the latest audit
+func (d *dbusAuditor) GetFindings() ([]dbusFinding, *dbus.Error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]dbusFinding, 0, len(d.latest))
+	for _, f := range d.latest {
+		list = append(list, dbusFinding{f.Category, f.Severity, f.Path, f.Target, f.ConfFile, int32(f.Line), f.Package, f.Message})
+	}
+	return list, nil
+}
+
+// runDBusService registers dbusName and serves audits until terminated
+func runDBusService() int {
+	var conn *dbus.Conn
+	var err error
+	if dbusBus == "session" {
+		conn, err = dbus.ConnectSessionBus()
+	} else {
+		conn, err = dbus.ConnectSystemBus()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to the %s bus: %v\n", dbusBus, err)
+		return 1
+	}
+	defer conn.Close()
+
+	// Results are only exposed over the bus
+	out = io.Discard
+
+	d := &dbusAuditor{conn: conn}
+	if err := conn.Export(d, dbusPath, dbusInterface); err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting object: %v\n", err)
+		return 1
+	}
+	readOnly := func(v any) *prop.Prop { return &prop.Prop{Value: v, Emit: prop.EmitTrue} }
+	d.props, err = prop.Export(conn, dbusPath, prop.Map{
+		dbusInterface: {
+			"Running":      readOnly(false),
+			"LastAudit":    readOnly(uint64(0)),
+			"FindingCount": readOnly(uint32(0)),
+			"ErrorCount":   readOnly(uint32(0)),
+			"WarningCount": readOnly(uint32(0)),
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting properties: %v\n", err)
+		return 1
+	}
+	node := &introspect.Node{
+		Name: dbusPath,
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       dbusInterface,
+				Methods:    introspect.Methods(d),
+				Properties: d.props.Introspection(dbusInterface),
+				Signals: []introspect.Signal{{
+					Name: "AuditFinished",
+					Args: []introspect.Arg{{Name: "errors", Type: "u"}, {Name: "warnings", Type: "u"}},
+				}},
+			},
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), dbusPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting introspection data: %v\n", err)
+		return 1
+	}
+
+	reply, err := conn.RequestName(dbusName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		fmt.Fprintf(os.Stderr, "Error: cannot acquire bus name %s\n", dbusName)
+		return 1
+	}
+
+	// Publish results right away so clients never see an empty state
+	if _, _, err := d.audit(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: initial audit failed: %v\n", err)
+	}
+	sdNotify("READY=1")
+	select {}
+}
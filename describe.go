@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"runtime/debug"
+	"sort"
+)
+
+// Versions of the documents the tool reads and writes; bumped on
+// incompatible changes so harnesses can tell what they are talking to
+const (
+	describeSchema = 1
+	reportSchema   = 1
+)
+
+// describeAudit prints the capability document instead of auditing
+var describeAudit bool
+
+// flagInfo documents a command line option
+type flagInfo struct {
+	Name    string `json:"name"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+}
+
+// commandInfo documents a subcommand
+type commandInfo struct {
+	Name  string     `json:"name"`
+	Usage string     `json:"usage"`
+	Flags []flagInfo `json:"flags,omitempty"` // options specific to the command
+}
+
+// capabilities is the document printed by --describe
+type capabilities struct {
+	Tool       string         `json:"tool"`
+	Version    string         `json:"version"`
+	Schema     int            `json:"schema"`
+	Schemas    map[string]int `json:"schemas"`
+	Formats    []string       `json:"formats"`
+	Severities []string       `json:"severities"`
+	Checks     []checkInfo    `json:"checks"`
+	Flags      []flagInfo     `json:"flags"`
+	Commands   []commandInfo  `json:"commands"`
+}
+
+// visitFlags lists the options registered in a flag set
+func visitFlags(fs *flag.FlagSet) []flagInfo {
+	var list []flagInfo
+	fs.VisitAll(func(f *flag.Flag) {
+		list = append(list, flagInfo{f.Name, f.DefValue, f.Usage})
+	})
+	return list
+}
+
+// toolVersion is the module version the binary was built from
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+// printCapabilities writes the --describe document to stdout
+func printCapabilities() {
+	c := capabilities{
+		Tool:       "tmpfiles-audit",
+		Version:    toolVersion(),
+		Schema:     describeSchema,
+		Schemas:    map[string]int{"report": reportSchema, "advisories": 1, "baseline": 1},
+		Formats:    outputFormats,
+		Severities: []string{severityError, severityWarning, severityInfo},
+		Checks:     checks,
+		Flags:      visitFlags(flag.CommandLine),
+	}
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		info := commandInfo{Name: name, Usage: commands[name].usage}
+		if setup := commands[name].flags; setup != nil {
+			fs := flag.NewFlagSet(name, flag.ContinueOnError)
+			setup(fs)
+			info.Flags = visitFlags(fs)
+		}
+		c.Commands = append(c.Commands, info)
+	}
+	data, _ := json.MarshalIndent(c, "", "  ")
+	os.Stdout.Write(append(data, '\n'))
+}
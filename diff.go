@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+)
+
+// ruleChange pairs the two versions of a rule that changed between reports
+type ruleChange struct {
+	Old rule `json:"old"`
+	New rule `json:"new"`
+}
+
+// reportDiff is the structured difference between two reports
+type reportDiff struct {
+	From             string       `json:"from"`
+	To               string       `json:"to"`
+	AddedRules       []rule       `json:"added_rules"`
+	RemovedRules     []rule       `json:"removed_rules"`
+	ChangedRules     []ruleChange `json:"changed_rules"`
+	AddedFindings    []Finding    `json:"added_findings"`
+	ResolvedFindings []Finding    `json:"resolved_findings"`
+}
+
+// ruleKey identifies a rule across reports by what it applies to, so moving
+// it to another fragment or line is not a change
+func ruleKey(r rule) string {
+	return r.Type + "\x00" + r.Path
+}
+
+// sameRule reports whether two rules with the same key declare the same thing
+func sameRule(a, b rule) bool {
+	return a.Modifiers == b.Modifiers && a.Mode == b.Mode && a.User == b.User && a.Group == b.Group &&
+		a.Age == b.Age && a.Argument == b.Argument
+}
+
+// diffReports compares the rules and findings of two reports
+func diffReports(from, to Report) reportDiff {
+	d := reportDiff{From: from.Root, To: to.Root}
+	before := make(map[string]rule)
+	for _, r := range from.Rules {
+		before[ruleKey(r)] = r
+	}
+	after := make(map[string]rule)
+	for _, r := range to.Rules {
+		after[ruleKey(r)] = r
+	}
+	for key, r := range after {
+		old, ok := before[key]
+		switch {
+		case !ok:
+			d.AddedRules = append(d.AddedRules, r)
+		case !sameRule(old, r):
+			d.ChangedRules = append(d.ChangedRules, ruleChange{old, r})
+		}
+	}
+	for key, r := range before {
+		if _, ok := after[key]; !ok {
+			d.RemovedRules = append(d.RemovedRules, r)
+		}
+	}
+	byPath := func(list []rule) {
+		sort.Slice(list, func(i, j int) bool { return ruleKey(list[i]) < ruleKey(list[j]) })
+	}
+	byPath(d.AddedRules)
+	byPath(d.RemovedRules)
+	sort.Slice(d.ChangedRules, func(i, j int) bool { return ruleKey(d.ChangedRules[i].New) < ruleKey(d.ChangedRules[j].New) })
+	d.AddedFindings, d.ResolvedFindings = diffFindings(from.Findings, to.Findings)
+	return d
+}
+
+// formatRule renders a rule as a tmpfiles.d line
+func formatRule(r rule) string {
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	line := fmt.Sprintf("%s%s %s %s %s %s %s", r.Type, r.Modifiers, r.Path, orDash(r.Mode), orDash(r.User), orDash(r.Group), orDash(r.Age))
+	if r.Argument != "" {
+		line += " " + r.Argument
+	}
+	return line
+}
+
+// auditRoot audits the system installed under dir and returns its report,
+// detecting profile, layout and systemd version for it and restoring the
+// previously audited root afterwards
+func auditRoot(dir string) (Report, error) {
+	savedRoot, savedDB, savedTrees := rootDir, pkgDB, runtimeTrees
+	savedProfile, savedLayout, savedEtc, savedSystemd := profile, layout, etcInUsr, targetSystemd
+	defer func() {
+		rootDir, pkgDB, runtimeTrees = savedRoot, savedDB, savedTrees
+		profile, layout, etcInUsr, targetSystemd = savedProfile, savedLayout, savedEtc, savedSystemd
+		users, groups = nil, nil
+	}()
+	rootDir = dir
+	if err := detectRootSettings(); err != nil {
+		return Report{}, err
+	}
+	if err := quietAudit(); err != nil {
+		return Report{}, err
+	}
+	return currentReport(), nil
+}
+
+// loadOperand turns a diff argument into a report: a saved report file, a
+// snapshot name, a root directory, or a disk image mounted with systemd-dissect
+func loadOperand(arg string) (Report, func(), error) {
+	noop := func() {}
+	fi, err := os.Stat(arg)
+	if err != nil {
+		if path, perr := snapshotPath(arg); perr == nil {
+			if r, lerr := loadReport(path); lerr == nil {
+				return r, noop, nil
+			}
+		}
+		return Report{}, noop, err
+	}
+	if fi.IsDir() {
+		r, err := auditRoot(arg)
+		return r, noop, err
+	}
+	if r, err := loadReport(arg); err == nil {
+		return r, noop, nil
+	}
+
+	// Anything else is treated as a disk image
+	mnt, cleanup, err := mountImage(arg)
+	if err != nil {
+		return Report{}, noop, fmt.Errorf("%s is neither a report nor a mountable image: %w", arg, err)
+	}
+	r, err := auditRoot(mnt)
+	r.Root = arg
+	return r, cleanup, err
+}
+
+// mountImage mounts a disk image or block device read-only with
+// systemd-dissect, returning the mount point and how to unmount it
+func mountImage(image string) (string, func(), error) {
+	if os.Getenv(sandboxedEnv) != "" {
+		return "", nil, errors.New("disk images cannot be mounted inside the read-only sandbox")
+	}
+	mnt, err := os.MkdirTemp("", "tmpfiles-audit-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		exec.Command("systemd-dissect", "--umount", mnt).Run()
+		os.Remove(mnt)
+	}
+	if output, err := exec.Command("systemd-dissect", "--read-only", "--mount", image, mnt).CombinedOutput(); err != nil {
+		os.Remove(mnt)
+		return "", nil, fmt.Errorf("%s", bytes.TrimSpace(output))
+	}
+	return mnt, cleanup, nil
+}
+
+// runDiff implements `diff A B`, failing when B introduces error findings
+func runDiff(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: diff A B (reports, snapshot names, root directories or images)")
+		return 2
+	}
+	var reports [2]Report
+	for i, arg := range args {
+		r, cleanup, err := loadOperand(arg)
+		defer cleanup()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		reports[i] = r
+	}
+	d := diffReports(reports[0], reports[1])
+	d.From, d.To = args[0], args[1]
+
+	if outputFormat == "json" {
+		data, _ := json.MarshalIndent(d, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+	} else {
+		printDiff(d)
+	}
+	if slices.ContainsFunc(d.AddedFindings, blocksRun) {
+		return 1
+	}
+	return 0
+}
+
+// printDiff renders a report diff for humans
+func printDiff(d reportDiff) {
+	fmt.Fprintf(out, "=== %s → %s ===\n", d.From, d.To)
+	fmt.Fprintf(out, "\nRules: %d added, %d removed, %d changed\n", len(d.AddedRules), len(d.RemovedRules), len(d.ChangedRules))
+	for _, r := range d.AddedRules {
+		fmt.Fprintf(out, "%s+ %s%s\n", colorGreen, formatRule(r), colorReset)
+	}
+	for _, r := range d.RemovedRules {
+		fmt.Fprintf(out, "%s- %s%s\n", colorRed, formatRule(r), colorReset)
+	}
+	for _, c := range d.ChangedRules {
+		fmt.Fprintf(out, "%s~ %s\n  → %s%s\n", colorYellow, formatRule(c.Old), formatRule(c.New), colorReset)
+	}
+	fmt.Fprintf(out, "\nFindings: %d new, %d resolved\n", len(d.AddedFindings), len(d.ResolvedFindings))
+	for _, f := range d.AddedFindings {
+		fmt.Fprintf(out, "%s+ %s %s: %s%s\n", colorRed, f.Category, f.Path, f.Message, colorReset)
+	}
+	for _, f := range d.ResolvedFindings {
+		fmt.Fprintf(out, "%s- %s %s: %s%s\n", colorGreen, f.Category, f.Path, f.Message, colorReset)
+	}
+}
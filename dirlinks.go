@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// liveCounterpart returns the live path whose default a factory path holds
+func liveCounterpart(path string) (string, bool) {
+	if rest, ok := strings.CutPrefix(path, usrEtcDir+"/"); ok && layout == layoutUsrEtc {
+		return "/etc/" + rest, true
+	}
+	for _, r := range factoryRoots() {
+		if rest, ok := strings.CutPrefix(path, r+"/"); ok {
+			return "/" + rest, true
+		}
+	}
+	return "", false
+}
+
+// directoryLink returns the link that covers a factory directory as a
+// whole: an L rule targeting the directory or one of its parents, or a
+// live directory that is itself a symlink to its factory counterpart.
+// Every file below such a directory is linked without a rule of its own
+func directoryLink(dir string, linkedDirs map[string]map[string]bool) (string, bool) {
+	for d := dir; inFactoryRoot(d) && !slices.Contains(factoryRoots(), d) && d != usrEtcDir; d = filepath.Dir(d) {
+		live, ok := liveCounterpart(d)
+		if !ok {
+			return "", false
+		}
+		if linkedDirs[filepath.Dir(d)][filepath.Base(d)] {
+			return live, true
+		}
+		if fi, err := lstatPath(live); err == nil && fi.Mode()&os.ModeSymlink != 0 && canonicalPath(live) == canonicalPath(d) {
+			return live, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,12 @@
+#!/bin/sh
+# SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+# SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+#
+# Reference from an rpm-ostree treefile to fail the compose when an L rule
+# points at factory content the tree does not ship:
+#
+#   postprocess-script: tmpfiles-audit-postprocess.sh
+#
+# Set TMPFILES_AUDIT_REPORT to keep a JSON report outside the tree
+
+exec tmpfiles-audit --rpm-ostree-postprocess
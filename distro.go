@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// distroProfile captures how a distribution lays out its factory defaults
+type distroProfile struct {
+	Name         string
+	IDs          []string // os-release ID or ID_LIKE values the profile is picked for
+	FactoryRoots []string // default factory roots, highest priority first
+	Layout       string   // layout of /etc defaults; "" detects it
+	BaseDirs     []string // directories never tracked for completeness
+	UsrMerged    bool     // /bin, /sbin and /lib are aliases of their /usr counterparts
+}
+
+// defaultBaseDirs are the top-level directories of every Linux system
+var defaultBaseDirs = []string{"/etc", "/var", "/usr", "/bin", "/sbin", "/lib", "/lib64", "/proc", "/run", "/opt", "/srv", "/boot"}
+
+// genericProfile is used when no profile matches the audited system
+var genericProfile = distroProfile{Name: "generic", FactoryRoots: []string{defaultFactoryRoot}, BaseDirs: defaultBaseDirs, UsrMerged: true}
+
+// distroProfiles are the built-in profiles, matched in order
+var distroProfiles = []distroProfile{
+	{Name: "fedora", IDs: []string{"fedora", "rhel", "centos"}, FactoryRoots: []string{defaultFactoryRoot},
+		Layout: layoutFactory, BaseDirs: defaultBaseDirs, UsrMerged: true},
+	{Name: "opensuse", IDs: []string{"opensuse", "opensuse-tumbleweed", "opensuse-leap", "suse", "sles"}, FactoryRoots: []string{defaultFactoryRoot},
+		Layout: layoutUsrEtc, BaseDirs: defaultBaseDirs, UsrMerged: true},
+	{Name: "kde-linux", IDs: []string{"kde-linux"}, FactoryRoots: []string{defaultFactoryRoot},
+		Layout: layoutFactory, BaseDirs: defaultBaseDirs, UsrMerged: true},
+	{Name: "debian", IDs: []string{"debian", "ubuntu"}, FactoryRoots: []string{defaultFactoryRoot},
+		BaseDirs: append(slices.Clone(defaultBaseDirs), "/lib32", "/libx32"), UsrMerged: true},
+}
+
+var (
+	// profileName is the --profile option; auto picks one from os-release
+	profileName = "auto"
+	// profile is the selected distribution profile
+	profile = genericProfile
+)
+
+// lookupProfile returns the built-in profile with the given name
+func lookupProfile(name string) (distroProfile, bool) {
+	if name == genericProfile.Name {
+		return genericProfile, true
+	}
+	for _, p := range distroProfiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return distroProfile{}, false
+}
+
+// profileNames lists the names accepted by --profile
+func profileNames() []string {
+	names := []string{"auto", genericProfile.Name}
+	for _, p := range distroProfiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// readOSRelease parses the audited system's os-release file, preferring
+// /etc/os-release over /usr/lib/os-release like os-release(5) says
+func readOSRelease() map[string]string {
+	lines := readLines("/etc/os-release")
+	if lines == nil {
+		lines = readLines("/usr/lib/os-release")
+	}
+	fields := make(map[string]string)
+	for _, line := range lines {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'"`)
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// detectProfile picks the profile whose IDs match the audited system's
+// ID, then the first one matching an ID_LIKE entry
+func detectProfile() distroProfile {
+	release := readOSRelease()
+	for _, id := range append([]string{release["ID"]}, strings.Fields(release["ID_LIKE"])...) {
+		for _, p := range distroProfiles {
+			if slices.Contains(p.IDs, id) {
+				return p
+			}
+		}
+	}
+	return genericProfile
+}
+
+// selectProfile applies --profile, or the detected profile for auto
+func selectProfile() error {
+	if profileName == "auto" {
+		profile = detectProfile()
+		return nil
+	}
+	p, ok := lookupProfile(profileName)
+	if !ok {
+		return fmt.Errorf("unknown profile %q (known: %s)", profileName, strings.Join(profileNames(), ", "))
+	}
+	profile = p
+	return nil
+}
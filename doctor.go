@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+)
+
+// doctorCheck is one self-test: run returns a problem description, or "" if all is well
+type doctorCheck struct {
+	name   string
+	run    func() (problem, remedy string)
+	severe bool // whether the audit cannot produce meaningful results without it
+}
+
+// doctorChecks validates the environment the audit depends on
+var doctorChecks = []doctorCheck{
+	{"tmpfiles.d directories are readable", func() (string, string) {
+		found := 0
+		for _, dir := range tmpfilesDirs {
+			_, err := listDir(dir)
+			switch {
+			case err == nil:
+				found++
+			case !errors.Is(err, fs.ErrNotExist):
+				return fmt.Sprintf("cannot read %s: %v", dir, err), "run as root or grant read access to the tmpfiles.d directories"
+			}
+		}
+		if found == 0 {
+			return fmt.Sprintf("no tmpfiles.d directory exists on %s", describeRoot()), "check that --root points at the root of an installed system"
+		}
+		return "", ""
+	}, true},
+	{"factory root resolves", func() (string, string) {
+		root := factoryRoot()
+		fi, err := statPath(root)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return root + " does not exist", "this is fine if no rule uses factory defaults; otherwise install the packages shipping " + root + " or pass --factory-root"
+		case err != nil:
+			return fmt.Sprintf("%s does not resolve: %v", root, err), "fix the symlink loop or permissions on the path to " + root
+		case !fi.IsDir():
+			return root + " is not a directory", "remove whatever occupies " + root + " and reinstall the packages shipping it"
+		}
+		return "", ""
+	}, false},
+	{"systemd-tmpfiles is available", func() (string, string) {
+		if _, err := exec.LookPath("systemd-tmpfiles"); err != nil {
+			return "systemd-tmpfiles not found in $PATH", "install systemd to use --cross-validate and cat-config against systemd's own parser"
+		}
+		return "", ""
+	}, false},
+	{"package database detected", func() (string, string) {
+		if detectPackageDB() == nil {
+			return "no dpkg or pacman database found", "--verify-conf and package ownership hints need the package database of the audited system"
+		}
+		return "", ""
+	}, false},
+	{"state directory is writable", func() (string, string) {
+		dir := stateDir()
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Sprintf("cannot create %s: %v", dir, err), "set STATE_DIRECTORY to a writable directory or run as root"
+		}
+		f, err := os.CreateTemp(dir, ".doctor-")
+		if err != nil {
+			return fmt.Sprintf("cannot write to %s: %v", dir, err), "set STATE_DIRECTORY to a writable directory or run as root"
+		}
+		f.Close()
+		os.Remove(f.Name())
+		return "", ""
+	}, true},
+	{"site configuration is valid", func() (string, string) {
+		if err := loadSiteConfig(); err != nil {
+			return err.Error(), "fix or remove " + defaultSiteConfig
+		}
+		return "", ""
+	}, true},
+}
+
+// runDoctor implements `doctor`, checking the auditor's own environment and
+// suggesting a remedy for every problem; it fails on problems that make the
+// audit meaningless
+func runDoctor(args []string) int {
+	fmt.Fprintf(out, "=== Checking the audit environment for %s ===\n", describeRoot())
+	failed := false
+	for _, c := range doctorChecks {
+		problem, remedy := c.run()
+		switch {
+		case problem == "":
+			fmt.Fprintf(out, "%s✓ %s%s\n", colorGreen, c.name, colorReset)
+			continue
+		case c.severe:
+			failed = true
+			fmt.Fprintf(out, "%s✗ %s: %s%s\n", colorRed, c.name, problem, colorReset)
+		default:
+			fmt.Fprintf(out, "%s⚠ %s: %s%s\n", colorYellow, c.name, problem, colorReset)
+		}
+		fmt.Fprintf(out, "   ⤷ %s\n", remedy)
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import "fmt"
+
+// checkDuplicates reports rules repeating an earlier one, either as the
+// exact same line or with the same parameters but different modifiers
+func checkDuplicates(rules []rule) {
+	fmt.Fprintln(out, "\n=== Duplicate rules ===")
+	type claim struct{ typ, path, argument string }
+	first := make(map[claim]rule)
+	duplicates := 0
+	for _, r := range rules {
+		key := claim{r.Type, r.Path, r.Argument}
+		original, ok := first[key]
+		if !ok {
+			first[key] = r
+			continue
+		}
+		// Rules differing in mode or ownership are conflicts, not duplicates
+		if !sameClaim(original, r) || (!inAuditScope(original) && !inAuditScope(r)) {
+			continue
+		}
+		duplicates++
+		what := "has the same path and target as"
+		if formatRule(original) == formatRule(r) {
+			what = "is an exact duplicate of"
+		}
+		fmt.Fprintf(out, "%s✗ %s:%d %s %s:%d%s\n   %s\n", colorRed, r.ConfFile, r.Line, what, original.ConfFile, original.Line, colorReset, formatRule(r))
+		addFinding(Finding{Category: catDuplicateRule, Severity: severityError, Path: r.Path, Target: r.Argument, ConfFile: r.ConfFile, Line: r.Line,
+			Message: fmt.Sprintf("%s %s:%d", what, original.ConfFile, original.Line)})
+	}
+	if duplicates == 0 {
+		fmt.Fprintf(out, "%s✓ No duplicate rules%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// cleaningTypes are the rule types whose age makes systemd-tmpfiles
+// --clean remove old content below the path
+const cleaningTypes = "dDevqQC"
+
+// excludes reports whether an x rule keeps path out of cleaning: it covers
+// the matched paths and everything below them
+func excludes(x rule, path string) bool {
+	for p := path; ; p = filepath.Dir(p) {
+		if ok, _ := filepath.Match(x.Path, p); ok {
+			return true
+		}
+		if p == "/" || p == "." {
+			return false
+		}
+	}
+}
+
+// checkExclusions reports rules whose age-based cleanup an x line in
+// another fragment switches off; x only affects cleaning, so creation still
+// happens, but the content is never aged out as the rule declares. X is
+// not reported as it leaves the contents of the matched path to cleaning,
+// and an x for the very same path is a conflict checkConflicts reports
+func checkExclusions(rules []rule) {
+	fmt.Fprintln(out, "\n=== Exclusions ===")
+	var exclusions []rule
+	for _, r := range rules {
+		if r.Type == "x" && !strings.Contains(r.Path, "%") {
+			exclusions = append(exclusions, r)
+		}
+	}
+	shadowed := 0
+	for _, r := range rules {
+		if !strings.Contains(cleaningTypes, r.Type) || r.Age == "" || r.Age == "-" || strings.Contains(r.Path, "%") {
+			continue
+		}
+		for _, x := range exclusions {
+			if x.ConfFile == r.ConfFile || x.Path == r.Path || (!inAuditScope(r) && !inAuditScope(x)) || !excludes(x, r.Path) {
+				continue
+			}
+			shadowed++
+			fmt.Fprintf(out, "%s⚠ %s %s with age %s is never cleaned (%s:%d)%s\n", colorYellow, r.Type, r.Path, r.Age, r.ConfFile, r.Line, colorReset)
+			fmt.Fprintf(out, "   ⤷ excluded by x %s (%s:%d); the path is still created\n", x.Path, x.ConfFile, x.Line)
+			addFinding(Finding{Category: catExcludedByGlob, Severity: severityWarning, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: fmt.Sprintf("age %s has no effect: x %s at %s:%d excludes the path from cleaning", r.Age, x.Path, x.ConfFile, x.Line)})
+			break
+		}
+	}
+	if shadowed == 0 {
+		fmt.Fprintf(out, "%s✓ No rule is neutralized by an exclusion in another fragment%s\n", colorGreen, colorReset)
+	}
+}
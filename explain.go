@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// checkInfo documents a finding category
+type checkInfo struct {
+	Code        string `json:"code"` // stable lint ID, never reused for another check
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	EnabledBy   string `json:"enabled_by,omitempty"` // option that turns the check on, if not always run
+	Fix         string `json:"fix"`
+}
+
+// checks lists every finding category the tool can report
+var checks = []checkInfo{
+	{Code: "TFA001", Category: catMissingTarget, Description: "symlink target of an L rule does not exist",
+		Fix: "Ship the target in the package that owns the rule, correct the target path, or mark the rule L? if the target is optional."},
+	{Code: "TFA002", Category: catIncompleteDir, Description: "factory directory contains files no rule links to",
+		Fix: "Add an L or C rule for each file, or list deliberately unlinked files in a /usr/share/tmpfiles.d/*.ignore file."},
+	{Code: "TFA003", Category: catOptionalMissing, Description: "target of an L? rule marked optional is missing",
+		Fix: "Nothing needs to be done if the target is expected to be absent on this system."},
+	{Code: "TFA004", Category: catUnverifiable, Description: "symlink target cannot be checked because its storage keeps failing with EIO, ESTALE or ENOTCONN",
+		Fix: "Check the network file system or FUSE mount the target lives on, or raise --stat-retries and --stat-backoff."},
+	{Code: "TFA005", Category: catUnreadableConf, Description: "tmpfiles.d fragment cannot be read",
+		Fix: "Fix the permissions of the fragment or remove the dangling fragment symlink."},
+	{Code: "TFA006", Category: catStrayConf, Description: "fragment is not owned by any package", EnabledBy: "--verify-conf",
+		Fix: "Package the fragment, or move local configuration to /etc/tmpfiles.d."},
+	{Code: "TFA007", Category: catModifiedConf, Description: "fragment differs from the packaged version", EnabledBy: "--verify-conf",
+		Fix: "Reinstall the package to restore the fragment; put local changes into an override in /etc/tmpfiles.d."},
+	{Code: "TFA008", Category: catUnverifiedConf, Description: "package database has no checksum for the fragment", EnabledBy: "--verify-conf",
+		Fix: "Rebuild the package so its file list records digests for the fragment."},
+	{Code: "TFA009", Category: catDanglingAfterRemoval, Description: "symlink would dangle after removing a package", EnabledBy: "--simulate-remove",
+		Fix: "Make the rule's package depend on the package providing the target, or ship the rule with the target."},
+	{Code: "TFA010", Category: catParserDrift, Description: "auditor and systemd-tmpfiles interpret a line differently", EnabledBy: "--cross-validate",
+		Fix: "Simplify the quoting or escaping of the line; please report the line as an auditor bug."},
+	{Code: "TFA011", Category: catUnauditedConf, Description: "systemd applies a fragment the auditor did not read", EnabledBy: "--cross-validate",
+		Fix: "Audit the directory the fragment lives in, or move the fragment to /usr/lib/tmpfiles.d."},
+	{Code: "TFA012", Category: catShadowedConf, Description: "audited fragment is shadowed or masked", EnabledBy: "--cross-validate",
+		Fix: "Remove the overriding fragment of the same name in a higher-precedence directory if the override is unintended."},
+	{Code: "TFA013", Category: catNeverCreated, Description: "path declared by a rule was not created this boot", EnabledBy: "--verify-boot",
+		Fix: "Check systemd-tmpfiles-setup.service for errors; the path may predate the rule or the rule may fail to apply."},
+	{Code: "TFA014", Category: catBrokenAfterBoot, Description: "path created at boot was changed or removed since", EnabledBy: "--verify-boot",
+		Fix: "Find the service that modifies the path after boot, or run systemd-tmpfiles --create to restore it."},
+	{Code: "TFA015", Category: catSELinuxLabel, Description: "file context differs from the policy",
+		Fix: "Run restorecon on the path, or add a z/Z rule so systemd-tmpfiles relabels it."},
+	{Code: "TFA016", Category: catUnknownAccount, Description: "rule refers to a user or group that does not exist",
+		Fix: "Ship a sysusers.d entry creating the account, or correct the user or group name in the rule."},
+	{Code: "TFA017", Category: catModeMismatch, Description: "file mode differs from the rule",
+		Fix: "Run systemd-tmpfiles --create to apply the mode, or correct the rule; prefix the mode with ~ or : if drift is intended."},
+	{Code: "TFA018", Category: catOwnerMismatch, Description: "file owner differs from the rule",
+		Fix: "Run systemd-tmpfiles --create to apply the ownership, or correct the rule."},
+	{Code: "TFA019", Category: catACLMismatch, Description: "POSIX ACL differs from the a/A rule",
+		Fix: "Run systemd-tmpfiles --create to apply the ACL, or update the rule's argument."},
+	{Code: "TFA020", Category: catAttributeDrift, Description: "file attributes differ from the h/H rule",
+		Fix: "Run systemd-tmpfiles --create to apply the attributes; some file systems do not support every attribute."},
+	{Code: "TFA021", Category: catWorldWritable, Description: "path is world-writable although the rule is not",
+		Fix: "Restrict the mode with chmod and find out what widened it."},
+	{Code: "TFA022", Category: catSetuidTarget, Description: "symlink target or factory file is setuid or setgid", EnabledBy: "--security",
+		Fix: "Drop the setuid and setgid bits from files that end up in /etc or /var via tmpfiles.d."},
+	{Code: "TFA023", Category: catNonRootOwner, Description: "symlink target or factory file is owned by an unprivileged user", EnabledBy: "--security",
+		Fix: "Make the file root-owned so unprivileged users cannot alter configuration linked into /etc."},
+	{Code: "TFA024", Category: catDisallowedTarget, Description: "symlink target resolves outside the allowed prefixes", EnabledBy: "--allowed-targets",
+		Fix: "Point the rule into an allowed prefix such as /usr/share/factory, or extend --allowed-targets."},
+	{Code: "TFA025", Category: catUnmeasuredFactory, Description: "factory file behind /etc has neither fs-verity nor IMA protection", EnabledBy: "--measured",
+		Fix: "Enable fs-verity on the file or sign it for IMA appraisal when building the image."},
+	{Code: "TFA026", Category: catManifestMismatch, Description: "factory file digest differs from the manifest", EnabledBy: "verify-manifest",
+		Fix: "Restore the file from the image, or record a new manifest if the change is intended."},
+	{Code: "TFA027", Category: catManifestMissing, Description: "factory file recorded in the manifest is missing", EnabledBy: "verify-manifest",
+		Fix: "Restore the file, or record a new manifest if it was removed on purpose."},
+	{Code: "TFA028", Category: catUnrecordedFactory, Description: "factory file is not recorded in the manifest", EnabledBy: "verify-manifest",
+		Fix: "Record a new manifest that includes the file."},
+	{Code: "TFA029", Category: catAdvisory, Description: "rule matches a known risky pattern",
+		Fix: "Follow the advice in the finding's message; the advisory ID names the pattern."},
+	{Code: "TFA030", Category: catGoldenMissingRule, Description: "rule of the golden image is missing", EnabledBy: "--golden",
+		Fix: "Restore the rule, or regenerate the golden report if it was dropped on purpose."},
+	{Code: "TFA031", Category: catGoldenChangedRule, Description: "rule differs from the golden image", EnabledBy: "--golden",
+		Fix: "Revert the change, or regenerate the golden report if it is intended."},
+	{Code: "TFA032", Category: catGoldenExtraRule, Description: "rule is not part of the golden image", EnabledBy: "--golden",
+		Fix: "Remove the rule, or regenerate the golden report if it is intended."},
+	{Code: "TFA033", Category: catGoldenUnmanaged, Description: "factory file no rule links to did not exist in the golden image", EnabledBy: "--golden",
+		Fix: "Add a rule for the file or remove it from the image."},
+	{Code: "TFA034", Category: catConflictingRule, Description: "path is claimed by rules with different types or parameters, of which systemd applies only the first",
+		Fix: "Remove the ignored rule, or override the whole fragment by file name in /etc/tmpfiles.d to make the intended rule win."},
+	{Code: "TFA035", Category: catDuplicateRule, Description: "rule repeats an earlier one for the same type, path and target",
+		Fix: "Drop the redundant line; pass --warn-on=duplicate-rule to report duplicates as warnings only."},
+	{Code: "TFA036", Category: catOrderSensitive, Description: "rule behaves differently depending on the order fragments are processed in",
+		Fix: "Declare parent directories before the entries below them and removals after creations, ideally in the same fragment."},
+	{Code: "TFA037", Category: catUnknownSpecifier, Description: "specifier is unknown or newer than the target systemd version",
+		Fix: "Use a specifier listed in tmpfiles.d(5) of the target version, or write %% for a literal percent sign."},
+	{Code: "TFA038", Category: catDeprecatedSyntax, Description: "syntax is only accepted for compatibility",
+		Fix: "Replace type m with z, and paths below /var/run and /var/lock with their /run equivalents."},
+	{Code: "TFA039", Category: catIgnoredField, Description: "field is ignored by the rule's type",
+		Fix: "Replace the field with -, or use a type that honours it; ages only apply to d, D, e, v, q, Q, C, x and X."},
+	{Code: "TFA040", Category: catVolatileTarget, Description: "symlink target or copy source lies in /tmp, /run or /var/run",
+		Fix: "Link or copy from persistent storage such as /usr/share/factory; keep /run targets only for links that may dangle until a service starts."},
+	{Code: "TFA041", Category: catPolicyMissingRule, Description: "rule required by the site policy is missing", EnabledBy: "--policy",
+		Fix: "Install the package shipping the rule, or add it to a fragment in /etc/tmpfiles.d."},
+	{Code: "TFA042", Category: catPolicyForbiddenPath, Description: "rule touches a path prefix the site policy forbids", EnabledBy: "--policy",
+		Fix: "Mask the fragment with a same-named /dev/null symlink in /etc/tmpfiles.d, or ask for a policy exception."},
+	{Code: "TFA043", Category: catPolicyMode, Description: "rule declares a mode other than the one the site policy mandates", EnabledBy: "--policy",
+		Fix: "Override the fragment in /etc/tmpfiles.d with the mandated mode."},
+	{Code: "TFA044", Category: catUnsupportedSyntax, Description: "line the target systemd version cannot parse",
+		Fix: "Fix the line, or ship a variant without the newer rule type or modifier for older systemd branches."},
+	{Code: "TFA045", Category: catLowerFactoryRoot, Description: "factory target is only provided by a lower-priority factory root", EnabledBy: "--factory-root",
+		Fix: "Ship the file in the primary factory root, or reorder the factory roots if the lower one is meant to provide it."},
+	{Code: "TFA046", Category: catUnmaterialized, Description: "factory file has no live counterpart in /etc or /var", EnabledBy: "--reverse-completeness",
+		Fix: "Add an L or C rule for the path, list it in a /usr/share/tmpfiles.d/*.ignore file, or stop shipping it."},
+	{Code: "TFA047", Category: catLinkStyle, Description: "L rule target is not in the form the site policy mandates", EnabledBy: "--link-style; --policy",
+		Fix: "Spell out the target in the suggested form; factory defaults need an explicit relative target."},
+	{Code: "TFA048", Category: catFirstBootDeferred, Description: "missing target lies on a partition systemd-repart creates at first boot", EnabledBy: "--pre-firstboot; unbooted image",
+		Fix: "Nothing if repart copies the target in; otherwise add a CopyFiles= entry for it to the repart.d definition."},
+	{Code: "TFA049", Category: catReadOnlyMount, Description: "rule creates or adjusts a path on a read-only mount", EnabledBy: "--check-readonly",
+		Fix: "Ship the path in the image, move the rule's path to a writable location, or drop the rule."},
+	{Code: "TFA050", Category: catMissingObject, Description: "target of a composefs root has no content object in the object store",
+		Fix: "Repair the object store, e.g. with ostree fsck --delete, then pull the deployment again."},
+	{Code: "TFA051", Category: catFirstBootPopulated, Description: "missing /var target is created at first boot by a C or d rule", EnabledBy: "--phase firstboot; unbooted image",
+		Fix: "Nothing before first boot; audit with --phase steady afterwards to make sure it was created."},
+	{Code: "TFA052", Category: catStaleLink, Description: "symlink points elsewhere and no systemd-tmpfiles pass on next boot replaces it", EnabledBy: "--check-needs-update",
+		Fix: "Use L+ so the link is replaced, or touch /usr so ConditionNeedsUpdate= triggers the update pass."},
+	{Code: "TFA053", Category: catSoftRebootStale, Description: "rule in /run or /dev keeps content from before a soft-reboot", EnabledBy: "--check-soft-reboot",
+		Fix: "Add the + modifier so the path is replaced, or remove it with an r rule, if the next root may declare something else."},
+	{Code: "TFA054", Category: catLinkCopyConflict, Description: "path is both symlinked by an L rule and copied by a C rule, directly or nested",
+		Fix: "Decide between linking and copying and drop the other rule; add + to the one kept so existing systems converge."},
+	{Code: "TFA055", Category: catUndeclaredAccount, Description: "rule refers to an account that exists locally but is not declared by sysusers.d", EnabledBy: "--check-sysusers",
+		Fix: "Ship a sysusers.d entry for the account so it exists on freshly provisioned systems."},
+	{Code: "TFA056", Category: catUnitManagedDir, Description: "rule duplicates or conflicts with a directory a unit manages through StateDirectory= and friends", EnabledBy: "--check-unit-dirs",
+		Fix: "Drop the rule and rely on the unit setting, or make the unit's User=, Group= and mode agree with the rule."},
+	{Code: "TFA057", Category: catExcludedByGlob, Description: "age of a rule has no effect because an x line in another fragment excludes the path from cleaning",
+		Fix: "Narrow the x glob, or drop the age if the path is meant to be kept."},
+	{Code: "TFA058", Category: catNoResetStory, Description: "L rule under /etc neither targets the factory tree nor is waived", EnabledBy: "--require-factory-reset",
+		Fix: "Ship the file in the factory tree and link to it, or add a factory_reset_waivers entry to the policy explaining what restores it."},
+	{Code: "TFA059", Category: catOptionalAbsent, Description: "missing target is provided by an optional package that is not installed", EnabledBy: "provides in the site config",
+		Fix: "Nothing if the package is left out on purpose; otherwise install it, or use L? so the rule says the target is optional."},
+	{Code: "TFA060", Category: catPermissionFlip, Description: "rule mode or owner disagrees with dpkg-statoverride or rpm's file attributes", EnabledBy: "--check-pkg-perms",
+		Fix: "Make the rule and the package's %attr or statoverride entry agree, or drop the mode and owner from the rule."},
+	{Code: "TFA061", Category: catLinkFlapping, Description: "L rule without + is shadowed by a regular file while the factory ships content for the path",
+		Fix: "Use L+ so upgraded systems get the symlink too, or drop the rule if the local file is intended."},
+	{Code: "TFA062", Category: catPluginFailed, Description: "check plugin failed, returned invalid findings or is writable by group or others",
+		Fix: "Fix the plugin or its permissions; plugins read the rules as JSON on stdin and print {\"findings\": [...]} on stdout."},
+}
+
+// explainCode names the lint code --explain describes
+var explainCode string
+
+// lookupCheck finds a check by its code or its category
+func lookupCheck(name string) (checkInfo, bool) {
+	for _, c := range checks {
+		if strings.EqualFold(c.Code, name) || c.Category == name {
+			return c, true
+		}
+	}
+	return checkInfo{}, false
+}
+
+// categoryCode returns the lint code of a finding category
+func categoryCode(category string) string {
+	c, _ := lookupCheck(category)
+	return c.Code
+}
+
+// explain prints the cause and typical fixes of a lint code
+func explain(name string) int {
+	c, ok := lookupCheck(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown code %q\n", name)
+		return 2
+	}
+	fmt.Printf("%s (%s): %s\n", c.Code, c.Category, c.Description)
+	if c.EnabledBy != "" {
+		fmt.Printf("\nReported with %s.\n", c.EnabledBy)
+	}
+	fmt.Printf("\nFix: %s\n", c.Fix)
+	return 0
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// defaultFactoryRoot is where systemd-tmpfiles takes the targets of L and
+// the sources of C rules without an argument from
+const defaultFactoryRoot = "/usr/share/factory"
+
+// factoryRootList collects repeated --factory-root options, highest priority first
+type factoryRootList []string
+
+func (l *factoryRootList) String() string { return strings.Join(*l, ",") }
+
+func (l *factoryRootList) Set(value string) error {
+	root, err := checkFactoryRoot(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, root)
+	return nil
+}
+
+// factoryRootsFlag holds the factory roots given on the command line
+var factoryRootsFlag factoryRootList
+
+// factoryRoots returns the factory roots of the audited system in lookup
+// order: every --factory-root, else the site config's factory_roots or
+// factory_root, else those of the distribution profile. Layered systems ship factory
+// files from a base image and from extensions, which are searched in turn
+func factoryRoots() []string {
+	switch {
+	case len(factoryRootsFlag) > 0:
+		return factoryRootsFlag
+	case len(site.FactoryRoots) > 0:
+		return site.FactoryRoots
+	case site.FactoryRoot != "":
+		return []string{site.FactoryRoot}
+	case len(profile.FactoryRoots) > 0:
+		return profile.FactoryRoots
+	}
+	return []string{defaultFactoryRoot}
+}
+
+// factoryRoot returns the primary factory root
+func factoryRoot() string {
+	return factoryRoots()[0]
+}
+
+// Layouts select where the defaults of /etc paths live
+const (
+	layoutAuto    = "auto"
+	layoutFactory = "factory" // /etc/X defaults to <factory root>/etc/X
+	layoutUsrEtc  = "usretc"  // /etc/X defaults to /usr/etc/X, as on openSUSE
+)
+
+// usrEtcDir holds the distribution defaults of /etc in the usretc layout
+const usrEtcDir = "/usr/etc"
+
+// layout is the --layout option; auto is replaced by the detected layout
+var layout = layoutAuto
+
+// detectLayout picks usretc for systems that ship /usr/etc but no factory
+// copy of /etc, and factory otherwise
+func detectLayout() string {
+	if fi, err := statPath(usrEtcDir); err != nil || !fi.IsDir() {
+		return layoutFactory
+	}
+	for _, r := range factoryRoots() {
+		if _, err := statPath(r + "/etc"); err == nil {
+			return layoutFactory
+		}
+	}
+	return layoutUsrEtc
+}
+
+// locateFactory returns the factory copy of a path from the first root
+// that has one, and that root; if none has it, the copy in the primary
+// root is returned with an empty root. In the usretc layout /etc paths
+// are looked up in /usr/etc, which then counts as the primary root
+func locateFactory(path string) (target, root string) {
+	if rest, ok := strings.CutPrefix(path, "/etc/"); ok && layout == layoutUsrEtc {
+		target = usrEtcDir + "/" + rest
+		if _, err := statPath(target); err == nil {
+			return target, usrEtcDir
+		}
+		return target, ""
+	}
+	for _, r := range factoryRoots() {
+		if _, err := statPath(r + path); err == nil {
+			return r + path, r
+		}
+	}
+	return factoryRoot() + path, ""
+}
+
+// factoryPath returns the factory copy of a path
+func factoryPath(path string) string {
+	target, _ := locateFactory(path)
+	return target
+}
+
+// primaryFactoryRoot reports whether a root returned by locateFactory is
+// the one the defaults are expected in
+func primaryFactoryRoot(root string) bool {
+	return root == factoryRoot() || layout == layoutUsrEtc && root == usrEtcDir
+}
+
+// inFactoryRoot reports whether a path lies in any factory root
+func inFactoryRoot(path string) bool {
+	if layout == layoutUsrEtc && underPrefix(path, usrEtcDir) {
+		return true
+	}
+	for _, r := range factoryRoots() {
+		if underPrefix(path, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFactoryRoot validates a configured factory root and returns it cleaned
+func checkFactoryRoot(root string) (string, error) {
+	if !filepath.IsAbs(root) {
+		return "", fmt.Errorf("factory root %q is not an absolute path", root)
+	}
+	return filepath.Clean(root), nil
+}
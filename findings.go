@@ -0,0 +1,328 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Finding severities
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityInfo    = "info"
+)
+
+// Finding categories
+const (
+	catMissingTarget   = "missing-target"
+	catOptionalMissing = "optional-missing"
+	catUnverifiable    = "unverifiable"
+	catIncompleteDir   = "incomplete-dir"
+	catUnreadableConf  = "unreadable-conf"
+	catStrayConf       = "stray-conf"
+	catModifiedConf    = "modified-conf"
+	catUnverifiedConf  = "unverified-conf"
+
+	catDanglingAfterRemoval = "dangling-after-removal"
+
+	catParserDrift   = "parser-drift"
+	catUnauditedConf = "unaudited-conf"
+	catShadowedConf  = "shadowed-conf"
+
+	catNeverCreated    = "never-created"
+	catBrokenAfterBoot = "broken-after-boot"
+
+	catSELinuxLabel   = "selinux-label"
+	catUnknownAccount = "unknown-account"
+	catModeMismatch   = "mode-mismatch"
+	catOwnerMismatch  = "owner-mismatch"
+	catACLMismatch    = "acl-mismatch"
+	catAttributeDrift = "attribute-drift"
+	catWorldWritable  = "world-writable"
+
+	catSetuidTarget = "setuid-target"
+	catNonRootOwner = "non-root-owner"
+
+	catDisallowedTarget  = "disallowed-target"
+	catUnmeasuredFactory = "unmeasured-factory-file"
+
+	catManifestMismatch  = "manifest-mismatch"
+	catManifestMissing   = "manifest-missing"
+	catUnrecordedFactory = "unrecorded-factory-file"
+
+	catAdvisory = "advisory"
+
+	catGoldenMissingRule = "golden-missing-rule"
+	catGoldenChangedRule = "golden-changed-rule"
+	catGoldenExtraRule   = "golden-extra-rule"
+	catGoldenUnmanaged   = "golden-unmanaged-file"
+
+	catConflictingRule = "conflicting-rule"
+	catDuplicateRule   = "duplicate-rule"
+	catOrderSensitive  = "order-sensitive"
+
+	catUnknownSpecifier = "unknown-specifier"
+	catDeprecatedSyntax = "deprecated-syntax"
+	catIgnoredField     = "ignored-field"
+	catVolatileTarget   = "volatile-target"
+
+	catPolicyMissingRule   = "policy-missing-rule"
+	catPolicyForbiddenPath = "policy-forbidden-path"
+	catPolicyMode          = "policy-mode"
+	catUnsupportedSyntax   = "unsupported-syntax"
+	catLowerFactoryRoot    = "lower-factory-root"
+	catUnmaterialized      = "unmaterialized-factory"
+	catLinkStyle           = "link-style"
+	catFirstBootDeferred   = "first-boot-deferred"
+	catReadOnlyMount       = "read-only-mount"
+	catMissingObject       = "composefs-object-missing"
+	catFirstBootPopulated  = "first-boot-populated"
+	catStaleLink           = "stale-link"
+	catSoftRebootStale     = "soft-reboot-stale"
+	catLinkCopyConflict    = "link-copy-conflict"
+	catUndeclaredAccount   = "undeclared-account"
+	catUnitManagedDir      = "unit-managed-dir"
+	catExcludedByGlob      = "excluded-by-glob"
+	catNoResetStory        = "no-factory-reset-story"
+	catOptionalAbsent      = "optional-dependency-absent"
+	catPermissionFlip      = "permission-flip"
+	catLinkFlapping        = "link-flapping"
+	catPluginFailed        = "plugin-failed"
+)
+
+// Finding is a single audit result, collected alongside the human-readable
+// output so it can be rendered in machine-readable formats
+type Finding struct {
+	Code     string `json:"code,omitempty"` // stable lint ID of the category, see checks
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Target   string `json:"target,omitempty"`
+	ConfFile string `json:"conf_file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Package  string `json:"package,omitempty"`
+	Runtime  string `json:"runtime,omitempty"`
+	Scope    string `json:"scope,omitempty"` // "volatile" or "persistent" config the rule came from
+	Message  string `json:"message"`
+}
+
+var (
+	// findings collects every result of the current audit run
+	findings []Finding
+
+	// out receives the human-readable report; it is discarded when another
+	// output format is selected so stdout stays machine-readable
+	out io.Writer = os.Stdout
+
+	// outputFormat selects how findings are rendered (see writeFindings)
+	outputFormat = "text"
+)
+
+// addFinding records a result of the current audit
+func addFinding(f Finding) {
+	classifyRuntime(&f)
+	applySeverityOverrides(&f)
+	applyGatePolicy(&f)
+	f.Code = categoryCode(f.Category)
+	if f.ConfFile != "" {
+		f.Scope = confScope(f.ConfFile)
+	}
+	findings = append(findings, f)
+	if failFast && blocksRun(f) {
+		stopAtFailure(f)
+	}
+}
+
+// volatileConfDir holds fragments written at runtime by generators and services
+const volatileConfDir = "/run/tmpfiles.d"
+
+// confScope tells runtime-generated fragments, which vanish on reboot, from
+// persistent configuration
+func confScope(conf string) string {
+	if filepath.Dir(conf) == volatileConfDir {
+		return "volatile"
+	}
+	return "persistent"
+}
+
+// printScopeSummary lists findings caused by runtime-generated fragments,
+// which point at the generator or service that wrote them
+func printScopeSummary() {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		if f.Scope == "volatile" {
+			counts[f.ConfFile]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n=== Findings from volatile runtime config ===")
+	confs := make([]string, 0, len(counts))
+	for conf := range counts {
+		confs = append(confs, conf)
+	}
+	sort.Strings(confs)
+	for _, conf := range confs {
+		fmt.Fprintf(out, "  %s: %d finding(s)\n", conf, counts[conf])
+	}
+}
+
+// hasErrors reports whether any recorded finding fails the run
+func hasErrors() bool {
+	for _, f := range findings {
+		if blocksRun(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputFormats lists the accepted --format values
+var outputFormats = []string{"text", "rpmlint", "json", "warnings-ng"}
+
+// writeFindings renders the collected findings in the selected output format;
+// the text format has already been printed while auditing
+func writeFindings(w io.Writer) {
+	switch outputFormat {
+	case "text":
+		printCodeSummary()
+	case "rpmlint":
+		writeRpmlint(w)
+	case "json":
+		writeReport(w)
+	case "warnings-ng":
+		writeWarningsNG(w)
+	}
+}
+
+// printCodeSummary closes the text report with the lint codes that were found
+func printCodeSummary() {
+	if len(findings) == 0 {
+		return
+	}
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Code]++
+	}
+	fmt.Fprintln(out, "\n=== Findings by code ===")
+	for _, c := range checks {
+		if counts[c.Code] > 0 {
+			fmt.Fprintf(out, "%s %-28s %d\n", c.Code, c.Category, counts[c.Code])
+		}
+	}
+	fmt.Fprintln(out, "Run with --explain CODE for causes and fixes.")
+}
+
+// rpmlintBadness scores each category like rpmlint's [Scoring] table, so OBS
+// can fail a build once the summed badness passes its threshold
+var rpmlintBadness = map[string]int{
+	catMissingTarget: 1000,
+	catIncompleteDir: 1000,
+	catStrayConf:     100,
+	catModifiedConf:  100,
+}
+
+// rpmlintSubject names the "package" column of rpmlint output
+func rpmlintSubject() string {
+	switch {
+	case packageName != "":
+		return packageName
+	case packageFile != "":
+		return filepath.Base(packageFile)
+	}
+	return "tmpfiles-audit"
+}
+
+// writeRpmlint prints findings in rpmlint's "pkg: E: check-name details" format
+// followed by its usual summary line
+func writeRpmlint(w io.Writer) {
+	sorted := append([]Finding(nil), findings...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	errors, warnings, badness := 0, 0, 0
+	for _, f := range sorted {
+		level := "I"
+		switch f.Severity {
+		case severityError:
+			level = "E"
+			errors++
+		case severityWarning:
+			level = "W"
+			warnings++
+		}
+		badness += rpmlintBadness[f.Category]
+		details := f.Path
+		if f.Target != "" {
+			details += " " + f.Target
+		}
+		if f.ConfFile != "" {
+			details += fmt.Sprintf(" (%s:%d)", f.ConfFile, f.Line)
+		}
+		fmt.Fprintf(w, "%s: %s: tmpfiles-%s %s [%s]\n", rpmlintSubject(), level, f.Category, details, f.Code)
+	}
+	fmt.Fprintf(w, "1 packages and 0 specfiles checked; %d errors, %d warnings, %d badness\n", errors, warnings, badness)
+}
+
+// warningsNGIssue is an issue in the native JSON format of Jenkins' warnings-ng plugin
+type warningsNGIssue struct {
+	FileName    string `json:"fileName"`
+	LineStart   int    `json:"lineStart,omitempty"`
+	Severity    string `json:"severity"`
+	Category    string `json:"category"`
+	Type        string `json:"type"`
+	PackageName string `json:"packageName,omitempty"`
+	Message     string `json:"message"`
+	Description string `json:"description,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// warningsNGSeverity maps severities to warnings-ng's ERROR, NORMAL and LOW;
+// blocking findings are ERROR so quality gates can count them separately
+func warningsNGSeverity(f Finding) string {
+	switch {
+	case blocksRun(f):
+		return "ERROR"
+	case f.Severity == severityWarning:
+		return "NORMAL"
+	}
+	return "LOW"
+}
+
+// writeWarningsNG prints findings as a warnings-ng issues report, to be read
+// with recordIssues(tool: issues(pattern: ...)); the fingerprint keeps issues
+// stable across builds so new and fixed ones can be trended
+func writeWarningsNG(w io.Writer) {
+	issues := make([]warningsNGIssue, 0, len(findings))
+	for _, f := range findings {
+		issue := warningsNGIssue{
+			FileName:    f.ConfFile,
+			LineStart:   f.Line,
+			Severity:    warningsNGSeverity(f),
+			Category:    f.Category,
+			Type:        f.Code,
+			PackageName: f.Package,
+			Message:     f.Path + ": " + f.Message,
+			Fingerprint: fmt.Sprintf("%x", sha256.Sum256([]byte(findingKey(f)))),
+		}
+		if issue.FileName == "" {
+			issue.FileName = f.Path
+		}
+		if f.Target != "" {
+			issue.Description = "target: " + f.Target
+		}
+		issues = append(issues, issue)
+	}
+	data, _ := json.MarshalIndent(struct {
+		Issues []warningsNGIssue `json:"issues"`
+	}{issues}, "", "  ")
+	w.Write(append(data, '\n'))
+}
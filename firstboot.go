@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// repartDirs are systemd-repart's repart.d search directories, highest precedence first
+var repartDirs = []string{"/etc/repart.d", "/run/repart.d", "/usr/local/lib/repart.d", "/usr/lib/repart.d"}
+
+// partitionMountPoints are where the Discoverable Partitions Specification
+// mounts partitions of these types when MountPoint= is not given
+var partitionMountPoints = map[string]string{
+	"esp": "/efi", "xbootldr": "/boot", "home": "/home", "srv": "/srv", "var": "/var", "tmp": "/var/tmp",
+}
+
+// copyFiles is one CopyFiles= source and its destination in the partition
+type copyFiles struct {
+	Source, Dest string
+}
+
+// firstBootPartition is a repart.d partition created at first boot
+type firstBootPartition struct {
+	File       string
+	MountPoint string
+	CopyFiles  []copyFiles
+}
+
+var (
+	// preFirstBoot forces treating the audited system as not booted yet
+	preFirstBoot bool
+	// firstBootPartitions are the partitions the next boot's systemd-repart creates
+	firstBootPartitions []firstBootPartition
+)
+
+// unbooted reports whether the audited system is audited in the firstboot
+// phase: if --phase does not say, whether it never booted, which leaves
+// /etc/machine-id missing, empty or "uninitialized"
+func unbooted() bool {
+	switch {
+	case phase == phaseFirstBoot || preFirstBoot:
+		return true
+	case phase == phaseSteady:
+		return false
+	}
+	lines := readLines("/etc/machine-id")
+	return len(lines) == 0 || strings.TrimSpace(lines[0]) == "" || strings.TrimSpace(lines[0]) == "uninitialized"
+}
+
+// readRepartDefinition parses the [Partition] section of a repart.d file
+func readRepartDefinition(path string) (firstBootPartition, bool) {
+	p := firstBootPartition{File: path}
+	partType := ""
+	for _, raw := range readLines(path) {
+		key, value, ok := strings.Cut(strings.TrimSpace(raw), "=")
+		if !ok || strings.HasPrefix(key, "#") || strings.HasPrefix(key, ";") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Type":
+			partType = value
+		case "MountPoint":
+			p.MountPoint, _, _ = strings.Cut(value, ":")
+		case "CopyFiles":
+			src, dst, ok := strings.Cut(value, ":")
+			if !ok {
+				dst = src
+			}
+			p.CopyFiles = append(p.CopyFiles, copyFiles{Source: src, Dest: dst})
+		}
+	}
+	if p.MountPoint == "" {
+		p.MountPoint = partitionMountPoints[partType]
+	}
+	// Root and /usr partitions make up the image itself
+	if p.MountPoint == "" || p.MountPoint == "/" || p.MountPoint == "/usr" {
+		return p, false
+	}
+	p.MountPoint = filepath.Clean(p.MountPoint)
+	return p, true
+}
+
+// detectFirstBootPartitions returns the partitions repart.d defines with
+// a mount point, if the audited system has not booted yet; fragments are
+// selected by name like tmpfiles.d fragments
+func detectFirstBootPartitions() []firstBootPartition {
+	if !firstBoot {
+		return nil
+	}
+	byName := make(map[string]string)
+	for _, dir := range repartDirs {
+		matches, _ := globPath(dir + "/*.conf")
+		for _, path := range matches {
+			if _, ok := byName[filepath.Base(path)]; !ok && !isMasked(path) {
+				byName[filepath.Base(path)] = path
+			}
+		}
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []firstBootPartition
+	for _, name := range names {
+		if p, ok := readRepartDefinition(byName[name]); ok {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// onFirstBootPartition returns the partition with the deepest mount point
+// holding a path
+func onFirstBootPartition(path string) (firstBootPartition, bool) {
+	var best firstBootPartition
+	found := false
+	for _, p := range firstBootPartitions {
+		if underPrefix(path, p.MountPoint) && (!found || len(p.MountPoint) > len(best.MountPoint)) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// copySource returns the file in the image that repart copies to a path
+// on the partition, if a CopyFiles= entry covers it
+func (p firstBootPartition) copySource(path string) (string, bool) {
+	inPartition := "/" + strings.TrimPrefix(strings.TrimPrefix(path, p.MountPoint), "/")
+	for _, c := range p.CopyFiles {
+		if underPrefix(inPartition, c.Dest) {
+			return c.Source + strings.TrimPrefix(inPartition, strings.TrimSuffix(c.Dest, "/")), true
+		}
+	}
+	return "", false
+}
+
+// reportDeferred records a missing target on a partition created at first
+// boot: it is expected to exist then if repart copies it in from the image,
+// otherwise the partition starts out without it
+func reportDeferred(p firstBootPartition, path, target, conf string, lineNo int) {
+	if source, ok := p.copySource(target); ok {
+		if _, err := statPath(source); err == nil {
+			fmt.Fprintf(out, "  %s⤷ Target deferred to first boot: %s copies it from %s%s\n", colorYellow, p.File, source, colorReset)
+			addFinding(Finding{Category: catFirstBootDeferred, Severity: severityInfo, Path: path, Target: target, ConfFile: conf, Line: lineNo,
+				Message: fmt.Sprintf("target is populated at first boot from %s by %s", source, p.File)})
+			return
+		}
+	}
+	fmt.Fprintf(out, "  %s⚠ Target deferred to first boot, but %s creates %s without it%s\n", colorYellow, p.File, p.MountPoint, colorReset)
+	addFinding(Finding{Category: catFirstBootDeferred, Severity: severityWarning, Path: path, Target: target, ConfFile: conf, Line: lineNo,
+		Message: fmt.Sprintf("target lies on %s, which %s creates at first boot without copying it in", p.MountPoint, p.File)})
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// fixMode creates missing L symlinks whose targets exist
+var fixMode bool
+
+// openBeneath opens dir of the audited system as an O_PATH descriptor; any
+// symlink or ".." on the way fails the lookup instead of being followed, so a
+// planted symlink cannot redirect a fix outside the tree
+func openBeneath(dir string) (int, error) {
+	top := rootDir
+	if top == "" {
+		top = "/"
+	}
+	rootFd, err := unix.Open(top, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, err
+	}
+	defer unix.Close(rootFd)
+	rel := strings.TrimPrefix(filepath.Clean(dir), "/")
+	if rel == "" {
+		rel = "."
+	}
+	fd, err := unix.Openat2(rootFd, rel, &unix.OpenHow{
+		Flags:   unix.O_PATH | unix.O_DIRECTORY | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_SYMLINKS | unix.RESOLVE_NO_MAGICLINKS,
+	})
+	switch {
+	case errors.Is(err, unix.ENOSYS):
+		return -1, errors.New("openat2() is not supported by this kernel; refusing to modify paths without it")
+	case errors.Is(err, unix.ELOOP), errors.Is(err, unix.EXDEV):
+		return -1, fmt.Errorf("%s is reached through a symlink", dir)
+	}
+	return fd, err
+}
+
+// createSymlink creates path -> target relative to a descriptor opened with openBeneath
+func createSymlink(path, target string) error {
+	fd, err := openBeneath(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	return unix.Symlinkat(target, fd, filepath.Base(path))
+}
+
+// fixableLink returns the target --fix would create an L rule's missing
+// symlink with, if its target exists
+func fixableLink(r rule) (string, bool) {
+	if r.Type != "L" || strings.ContainsAny(r.Path, "*?[%") {
+		return "", false
+	}
+	if _, err := lstatPath(r.Path); !errors.Is(err, fs.ErrNotExist) {
+		return "", false
+	}
+	target := symlinkTarget(r)
+	if style := linkStyle(); style != "" {
+		target = styledTarget(r, style)
+	}
+	return target, targetExists(resolveTargetPath(r.Path, target))
+}
+
+// runFixes creates the symlinks of L rules whose path is missing but whose
+// target exists, like systemd-tmpfiles --create would
+func runFixes(list []rule) {
+	fmt.Fprintln(out, "\n=== Fixes ===")
+	fixed := 0
+	for _, r := range list {
+		target, ok := fixableLink(r)
+		if !ok {
+			continue
+		}
+		if err := createSymlink(r.Path, target); err != nil {
+			fmt.Fprintf(out, "%s✗ Cannot create %s -> %s: %v%s\n", colorRed, r.Path, target, err, colorReset)
+			continue
+		}
+		fixed++
+		fmt.Fprintf(out, "%s✓ Created %s -> %s%s\n", colorGreen, r.Path, target, colorReset)
+	}
+	if fixed == 0 {
+		fmt.Fprintln(out, "Nothing to fix")
+	}
+}
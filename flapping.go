@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// factoryContent returns the factory file an L rule's path is meant to
+// reflect: its target when that lies in a factory root, otherwise the
+// factory copy of the path itself
+func factoryContent(r rule) (string, bool) {
+	target := resolveTargetPath(r.Path, symlinkTarget(r))
+	if !inFactoryRoot(target) {
+		target = factoryPath(factoryRelative(r.Path))
+	}
+	if fi, err := statPath(target); err != nil || !fi.Mode().IsRegular() {
+		return target, false
+	}
+	return target, true
+}
+
+// readContent reads a file of the audited system
+func readContent(path string) ([]byte, error) {
+	f, err := openPath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// checkLinkFlapping reports L rules without + whose path is a regular file
+// on the live system while the factory ships content for it. A fresh
+// install gets the symlink and follows the factory from then on; an
+// upgraded system keeps its file, as L never replaces one, so the two end
+// up configured differently
+func checkLinkFlapping(rules []rule) {
+	fmt.Fprintln(out, "\n=== Symlink/file flapping ===")
+	flapping := 0
+	for _, r := range rules {
+		if r.Type != "L" || strings.Contains(r.Modifiers, "+") || strings.ContainsAny(r.Path, "*?[%") || !inAuditScope(r) {
+			continue
+		}
+		fi, err := lstatPath(r.Path)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		content, ok := factoryContent(r)
+		if !ok {
+			continue
+		}
+		flapping++
+		fmt.Fprintf(out, "%s⚠ %s is a regular file, but %s:%d declares a symlink to %s%s\n", colorYellow, r.Path, r.ConfFile, r.Line, content, colorReset)
+		live, errLive := readContent(r.Path)
+		factory, errFactory := readContent(content)
+		if errLive == nil && errFactory == nil && bytes.Equal(live, factory) {
+			fmt.Fprintln(out, "   ⤷ Contents match the factory today, but later factory updates only reach fresh installs")
+		} else {
+			fmt.Fprintln(out, "   ⤷ Contents differ from the factory: fresh installs and upgraded systems behave differently")
+		}
+		fmt.Fprintln(out, "   ⤷ Use L+ to replace the file on upgrades, or drop the rule if the local file is intended")
+		addFinding(Finding{Category: catLinkFlapping, Severity: severityWarning, Path: r.Path, Target: content, ConfFile: r.ConfFile, Line: r.Line,
+			Message: "live path is a regular file the L rule never replaces, while fresh installs get a symlink to the factory content"})
+	}
+	if flapping == 0 {
+		fmt.Fprintf(out, "%s✓ No L rule is shadowed by a regular file with factory content%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileAttributes maps chattr letters accepted by h/H rules to FS_*_FL flags
+var fileAttributes = []struct {
+	letter byte
+	flag   uint32
+}{
+	{'a', 0x00000020}, // append only
+	{'A', 0x00000080}, // no atime updates
+	{'c', 0x00000004}, // compress
+	{'C', 0x00800000}, // no copy on write
+	{'d', 0x00000040}, // no dump
+	{'D', 0x00010000}, // synchronous directory updates
+	{'e', 0x00080000}, // extents
+	{'i', 0x00000010}, // immutable
+	{'j', 0x00004000}, // data journalling
+	{'P', 0x20000000}, // project hierarchy
+	{'s', 0x00000001}, // secure deletion
+	{'S', 0x00000008}, // synchronous updates
+	{'t', 0x00008000}, // no tail merging
+	{'T', 0x00020000}, // top of directory hierarchy
+	{'u', 0x00000002}, // undeletable
+}
+
+// parseAttributes parses an h/H argument such as "+iC" or "=a" into the
+// flags to compare and their expected values
+func parseAttributes(arg string) (mask, value uint32, err error) {
+	op := byte('+')
+	if arg != "" && strings.IndexByte("+-=", arg[0]) >= 0 {
+		op, arg = arg[0], arg[1:]
+	}
+	for i := 0; i < len(arg); i++ {
+		found := false
+		for _, a := range fileAttributes {
+			if a.letter == arg[i] {
+				mask |= a.flag
+				found = true
+			}
+		}
+		if !found {
+			return 0, 0, fmt.Errorf("unknown file attribute %q", arg[i])
+		}
+	}
+	switch op {
+	case '+':
+		value = mask
+	case '=':
+		// Every attribute not listed must be cleared
+		value = mask
+		mask = 0
+		for _, a := range fileAttributes {
+			mask |= a.flag
+		}
+	}
+	return mask, value, nil
+}
+
+// formatAttributes renders flags like lsattr, limited to the given mask
+func formatAttributes(flags, mask uint32) string {
+	var b strings.Builder
+	for _, a := range fileAttributes {
+		if mask&a.flag == 0 {
+			continue
+		}
+		if flags&a.flag != 0 {
+			b.WriteByte(a.letter)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// getAttributes reads a path's flags with FS_IOC_GETFLAGS
+func getAttributes(path string) (uint32, error) {
+	parent, err := resolveInRoot(filepath.Dir(path))
+	if err != nil {
+		return 0, err
+	}
+	fd, err := unix.Open(rootPath(filepath.Join(parent, filepath.Base(path))), unix.O_RDONLY|unix.O_NONBLOCK|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer unix.Close(fd)
+	return unix.IoctlGetUint32(fd, unix.FS_IOC_GETFLAGS)
+}
+
+// checkAttributeFlags reports paths of h/H rules whose file attributes drifted
+// from the declaration
+func checkAttributeFlags(r rule) {
+	mask, value, err := parseAttributes(r.Argument)
+	if err != nil {
+		fmt.Fprintf(out, "%s⚠ Cannot parse attributes of %s: %v (%s:%d)%s\n", colorYellow, r.Path, err, r.ConfFile, r.Line, colorReset)
+		return
+	}
+	for _, path := range rulePaths(r) {
+		if r.Type == "H" {
+			filepath.WalkDir(rootPath(path), func(host string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				notifyWatchdog()
+				compareAttributes(r, filepath.Join(path, strings.TrimPrefix(host, rootPath(path))), mask, value)
+				return nil
+			})
+			continue
+		}
+		compareAttributes(r, path, mask, value)
+	}
+}
+
+// compareAttributes reports a single path whose attributes differ from value within mask
+func compareAttributes(r rule, path string, mask, value uint32) {
+	flags, err := getAttributes(path)
+	if err != nil {
+		// Symlinks and special files cannot carry attributes
+		if err != unix.ELOOP && err != unix.ENOTTY && err != unix.ENXIO {
+			fmt.Fprintf(os.Stderr, "Warning: cannot read file attributes of %s: %v\n", path, err)
+		}
+		return
+	}
+	if flags&mask == value {
+		return
+	}
+	message := fmt.Sprintf("file attributes are %s, rule declares %s", formatAttributes(flags, mask), formatAttributes(value, mask))
+	fmt.Fprintf(out, "%s⚠ %s: %s (%s:%d)%s\n", colorYellow, path, message, r.ConfFile, r.Line, colorReset)
+	addFinding(Finding{Category: catAttributeDrift, Severity: severityWarning, Path: path, ConfFile: r.ConfFile, Line: r.Line, Message: message})
+}
@@ -0,0 +1,266 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// rootDir is the directory the audited system lives in; "" means the running system
+	rootDir string
+
+	// overlayFiles holds files unpacked from a package being audited, keyed by
+	// absolute path; they are visible on top of rootDir like an installed package
+	overlayFiles map[string][]byte
+	overlayDirs  map[string]bool
+
+	// usrOverride is a host directory that replaces /usr of the audited
+	// system, such as a pending /usr partition; "" keeps its own
+	usrOverride string
+)
+
+// maxSymlinkHops mirrors the kernel's limit on nested symlinks during lookup
+const maxSymlinkHops = 40
+
+// rootPath maps an absolute path on the audited system to a path on the host
+func rootPath(path string) string {
+	if usrOverride != "" && underPrefix(path, "/usr") {
+		return usrOverride + strings.TrimPrefix(path, "/usr")
+	}
+	if etcInUsr && underPrefix(path, "/etc") {
+		path = usrEtcDir + strings.TrimPrefix(path, "/etc")
+	}
+	if rootDir == "" {
+		return path
+	}
+	return filepath.Join(rootDir, path)
+}
+
+// resolveInRoot follows symlinks in path as if rootDir were /, so absolute
+// link targets inside an image never escape to the host
+func resolveInRoot(path string) (string, error) {
+	if rootDir == "" {
+		return path, nil
+	}
+	resolved := "/"
+	rest := strings.Split(filepath.Clean(path), "/")
+	hops := 0
+	for len(rest) > 0 {
+		name := rest[0]
+		rest = rest[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+		next := filepath.Join(resolved, name)
+		fi, err := retryStat(func() (os.FileInfo, error) { return os.Lstat(rootPath(next)) })
+		if err != nil {
+			return filepath.Join(append([]string{next}, rest...)...), err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+		if hops++; hops > maxSymlinkHops {
+			return next, &fs.PathError{Op: "resolve", Path: path, Err: syscall.ELOOP}
+		}
+		link, err := os.Readlink(rootPath(next))
+		if err != nil {
+			return next, err
+		}
+		if filepath.IsAbs(link) {
+			resolved = "/"
+		}
+		rest = append(strings.Split(link, "/"), rest...)
+	}
+	return resolved, nil
+}
+
+// canonicalPath follows every symlink in path on the audited system; the
+// unresolvable remainder of a dangling path is kept as is
+func canonicalPath(path string) string {
+	if rootDir != "" {
+		resolved, _ := resolveInRoot(path)
+		return resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if dir := filepath.Dir(path); dir != path {
+		return filepath.Join(canonicalPath(dir), filepath.Base(path))
+	}
+	return path
+}
+
+// overlayInfo describes an unpacked package file for stat and directory listings
+type overlayInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (o overlayInfo) Name() string       { return o.name }
+func (o overlayInfo) Size() int64        { return o.size }
+func (o overlayInfo) ModTime() time.Time { return time.Time{} }
+func (o overlayInfo) IsDir() bool        { return o.dir }
+func (o overlayInfo) Sys() any           { return nil }
+func (o overlayInfo) Mode() fs.FileMode {
+	if o.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// setOverlay installs unpacked package files and records their parent directories
+func setOverlay(files map[string][]byte) {
+	overlayFiles = files
+	overlayDirs = make(map[string]bool)
+	for path := range files {
+		for dir := filepath.Dir(path); dir != "/"; dir = filepath.Dir(dir) {
+			overlayDirs[dir] = true
+		}
+	}
+}
+
+// statPath stats a path on the audited system, following symlinks
+func statPath(path string) (os.FileInfo, error) {
+	if removedFiles[path] {
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+	}
+	if data, ok := overlayFiles[path]; ok {
+		return overlayInfo{name: filepath.Base(path), size: int64(len(data))}, nil
+	}
+	if overlayDirs[path] {
+		return overlayInfo{name: filepath.Base(path), dir: true}, nil
+	}
+	resolved, err := resolveInRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	return retryStat(func() (os.FileInfo, error) { return os.Stat(rootPath(resolved)) })
+}
+
+// lstatPath stats a path on the audited system without following its last component
+func lstatPath(path string) (os.FileInfo, error) {
+	parent, err := resolveInRoot(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return os.Lstat(rootPath(filepath.Join(parent, filepath.Base(path))))
+}
+
+// readlinkPath reads a symlink on the audited system
+func readlinkPath(path string) (string, error) {
+	parent, err := resolveInRoot(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return os.Readlink(rootPath(filepath.Join(parent, filepath.Base(path))))
+}
+
+// openPath opens a file on the audited system for reading
+func openPath(path string) (io.ReadCloser, error) {
+	if removedFiles[path] {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	if data, ok := overlayFiles[path]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	resolved, err := resolveInRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(rootPath(resolved))
+}
+
+// globPath expands a glob pattern on the audited system, returning absolute paths
+func globPath(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(rootPath(pattern))
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, m := range matches {
+		switch {
+		case usrOverride != "" && underPrefix(pattern, "/usr"):
+			m = "/usr" + strings.TrimPrefix(m, usrOverride)
+		case rootDir != "":
+			m = "/" + strings.TrimPrefix(strings.TrimPrefix(m, filepath.Clean(rootDir)), "/")
+		}
+		if etcInUsr && underPrefix(pattern, "/etc") {
+			m = "/etc" + strings.TrimPrefix(m, usrEtcDir)
+		}
+		if removedFiles[m] {
+			continue
+		}
+		seen[m] = true
+		paths = append(paths, m)
+	}
+	for path := range overlayFiles {
+		if ok, _ := filepath.Match(pattern, path); ok && !seen[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// listDir reads a directory on the audited system, merging in overlay files
+func listDir(dir string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	resolved, err := resolveInRoot(dir)
+	if err == nil {
+		entries, err = os.ReadDir(rootPath(resolved))
+	}
+	if removedFiles != nil {
+		entries = slices.DeleteFunc(entries, func(e fs.DirEntry) bool {
+			return removedFiles[filepath.Join(dir, e.Name())]
+		})
+	}
+	if !overlayDirs[dir] {
+		return entries, err
+	}
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		seen[e.Name()] = true
+	}
+	for path, data := range overlayFiles {
+		if filepath.Dir(path) == dir && !seen[filepath.Base(path)] {
+			entries = append(entries, fs.FileInfoToDirEntry(overlayInfo{name: filepath.Base(path), size: int64(len(data))}))
+		}
+	}
+	for path := range overlayDirs {
+		if filepath.Dir(path) == dir && !seen[filepath.Base(path)] {
+			entries = append(entries, fs.FileInfoToDirEntry(overlayInfo{name: filepath.Base(path), dir: true}))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// describeRoot names the audited system for report headers
+func describeRoot() string {
+	switch {
+	case machineName != "":
+		return fmt.Sprintf("machine %s (%s)", machineName, rootDir)
+	case rootDir == "":
+		return "/"
+	}
+	return fmt.Sprintf("%s (root)", rootDir)
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// categoryList is a flag value collecting comma-separated or repeated finding
+// categories; lint codes are accepted and stored as their category
+type categoryList []string
+
+func (c *categoryList) String() string { return strings.Join(*c, ",") }
+
+func (c *categoryList) Set(value string) error {
+	for _, category := range strings.Split(value, ",") {
+		if category = strings.TrimSpace(category); category == "" {
+			continue
+		}
+		check, ok := lookupCheck(category)
+		if !ok {
+			return fmt.Errorf("unknown category or code %q", category)
+		}
+		*c = append(*c, check.Category)
+	}
+	return nil
+}
+
+var (
+	// failOn lists the categories that fail the run; when set, findings of
+	// other categories never affect the exit status
+	failOn categoryList
+	// warnOn lists categories that are reported as warnings only
+	warnOn categoryList
+	// failFast ends the run at the first finding that fails it
+	failFast bool
+)
+
+// applyGatePolicy adjusts a finding's severity to the --fail-on/--warn-on
+// policy; runtime-managed findings stay informational
+func applyGatePolicy(f *Finding) {
+	switch {
+	case f.Runtime != "":
+	case slices.Contains(failOn, f.Category):
+		f.Severity = severityError
+	case slices.Contains(warnOn, f.Category) && f.Severity == severityError:
+		f.Severity = severityWarning
+	}
+}
+
+// blocksRun reports whether a finding makes the run fail
+func blocksRun(f Finding) bool {
+	if f.Severity != severityError {
+		return false
+	}
+	return len(failOn) == 0 || slices.Contains(failOn, f.Category)
+}
+
+// countBlocking splits findings into those that fail the run and the other
+// errors and warnings, which are counted as warnings
+func countBlocking(list []Finding) (errors, warnings int) {
+	for _, f := range list {
+		switch {
+		case blocksRun(f):
+			errors++
+		case f.Severity != severityInfo:
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// stopAtFailure ends the run right after the first blocking finding,
+// rendering what was found so far in the selected output format
+func stopAtFailure(f Finding) {
+	writeFindings(os.Stdout)
+	fmt.Fprintf(os.Stderr, "tmpfiles-audit: stopping at first failure: %s: %s [%s]\n", f.Path, f.Message, f.Category)
+	os.Exit(1)
+}
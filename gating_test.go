@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import "testing"
+
+func TestGatePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		failOn   categoryList
+		warnOn   categoryList
+		finding  Finding
+		severity string
+		blocks   bool
+	}{
+		{"no policy, error", nil, nil, Finding{Category: catModeMismatch, Severity: severityError}, severityError, true},
+		{"no policy, warning", nil, nil, Finding{Category: catModeMismatch, Severity: severityWarning}, severityWarning, false},
+		{"fail-on escalates", categoryList{catModeMismatch}, nil, Finding{Category: catModeMismatch, Severity: severityWarning}, severityError, true},
+		{"fail-on leaves other categories", categoryList{catModeMismatch}, nil, Finding{Category: catOwnerMismatch, Severity: severityError}, severityError, false},
+		{"warn-on downgrades", nil, categoryList{catModeMismatch}, Finding{Category: catModeMismatch, Severity: severityError}, severityWarning, false},
+		{"warn-on keeps info", nil, categoryList{catModeMismatch}, Finding{Category: catModeMismatch, Severity: severityInfo}, severityInfo, false},
+		{"runtime finding not escalated", categoryList{catModeMismatch}, nil, Finding{Category: catModeMismatch, Severity: severityInfo, Runtime: "podman"}, severityInfo, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failOn, warnOn = tt.failOn, tt.warnOn
+			defer func() { failOn, warnOn = nil, nil }()
+			f := tt.finding
+			applyGatePolicy(&f)
+			if f.Severity != tt.severity {
+				t.Errorf("severity = %s, want %s", f.Severity, tt.severity)
+			}
+			if got := blocksRun(f); got != tt.blocks {
+				t.Errorf("blocksRun = %v, want %v", got, tt.blocks)
+			}
+		})
+	}
+}
+
+func TestCategoryListSet(t *testing.T) {
+	var c categoryList
+	if err := c.Set(catModeMismatch + ", " + categoryCode(catOwnerMismatch)); err != nil {
+		t.Fatal(err)
+	}
+	if want := catModeMismatch + "," + catOwnerMismatch; c.String() != want {
+		t.Errorf("categories = %s, want %s", c.String(), want)
+	}
+	if err := c.Set("no-such-check"); err == nil {
+		t.Error("unknown category accepted")
+	}
+}
@@ -1,3 +1,7 @@
 module github.com/silverhadch/tmpfiles-audit
 
 go 1.24.2
+
+require github.com/godbus/dbus/v5 v5.2.2
+
+require golang.org/x/sys v0.27.0
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import "fmt"
+
+// goldenFile is a report from a known-good image the audited system must conform to
+var goldenFile string
+
+// checkGolden reports drift from the golden report: rules the system lacks
+// or declares differently, and unmanaged files the golden image did not have
+func checkGolden() error {
+	golden, err := loadReport(goldenFile)
+	if err != nil {
+		return err
+	}
+	d := diffReports(golden, currentReport())
+	fmt.Fprintf(out, "\n=== Conformance to golden report %s (%s, %s) ===\n", goldenFile, golden.Root, golden.Created.Format("2006-01-02"))
+
+	for _, r := range d.RemovedRules {
+		fmt.Fprintf(out, "%s✗ Missing rule: %s (%s)%s\n", colorRed, formatRule(r), r.ConfFile, colorReset)
+		addFinding(Finding{Category: catGoldenMissingRule, Severity: severityError, Path: r.Path, Target: r.Argument,
+			Message: "rule of the golden image is not declared: " + formatRule(r)})
+	}
+	for _, c := range d.ChangedRules {
+		fmt.Fprintf(out, "%s✗ Rule differs: %s\n  golden: %s%s\n", colorRed, formatRule(c.New), formatRule(c.Old), colorReset)
+		addFinding(Finding{Category: catGoldenChangedRule, Severity: severityError, Path: c.New.Path, Target: c.New.Argument, ConfFile: c.New.ConfFile, Line: c.New.Line,
+			Message: "golden image declares " + formatRule(c.Old)})
+	}
+	for _, r := range d.AddedRules {
+		fmt.Fprintf(out, "%s⚠ Extra rule: %s (%s:%d)%s\n", colorYellow, formatRule(r), r.ConfFile, r.Line, colorReset)
+		addFinding(Finding{Category: catGoldenExtraRule, Severity: severityWarning, Path: r.Path, Target: r.Argument, ConfFile: r.ConfFile, Line: r.Line,
+			Message: "rule is not part of the golden image"})
+	}
+	unmanaged := 0
+	for _, f := range d.AddedFindings {
+		if f.Category != catIncompleteDir {
+			continue
+		}
+		unmanaged++
+		fmt.Fprintf(out, "%s✗ Unmanaged file not present in the golden image: %s%s\n", colorRed, f.Path, colorReset)
+		addFinding(Finding{Category: catGoldenUnmanaged, Severity: severityError, Path: f.Path, Package: f.Package,
+			Message: "file is not linked by any rule and was not present in the golden image"})
+	}
+	if len(d.RemovedRules)+len(d.ChangedRules)+unmanaged == 0 {
+		fmt.Fprintf(out, "%s✓ Conforms to the golden image%s\n", colorGreen, colorReset)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+//go:build goplugin
+
+package main
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// goCheckFunc is the symbol a Go plugin exports as Check. It takes and
+// returns the same JSON as an executable plugin, so plugins need not share
+// any type with this program
+type goCheckFunc = func(input []byte) ([]byte, error)
+
+// runGoPlugin loads a Go plugin and calls its Check function
+func runGoPlugin(path string, input []byte) ([]byte, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("Check")
+	if err != nil {
+		return nil, err
+	}
+	check, ok := sym.(goCheckFunc)
+	if !ok {
+		return nil, fmt.Errorf("Check is %T, want func([]byte) ([]byte, error)", sym)
+	}
+	return check(input)
+}
@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+//go:build !goplugin
+
+package main
+
+import "errors"
+
+// runGoPlugin fails unless built with the goplugin tag
+func runGoPlugin(path string, input []byte) ([]byte, error) {
+	return nil, errors.New("Go plugins need a build with the goplugin tag")
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Kinds of nodes in the provenance graph
+const (
+	nodeConf    = "conf"
+	nodeRule    = "rule"
+	nodePath    = "path"
+	nodePackage = "package"
+)
+
+// graphNode is a conf file, rule, path or package in the provenance graph
+type graphNode struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	Label   string `json:"label"`
+	Missing bool   `json:"missing,omitempty"` // a path that does not exist
+	Orphan  bool   `json:"orphan,omitempty"`  // in a cluster no package owns any part of
+}
+
+// graphEdge connects two nodes of the provenance graph
+type graphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label"`
+}
+
+// provenanceGraph links conf files to their rules, the paths those manage,
+// the targets they point to and the packages owning any of them
+type provenanceGraph struct {
+	Nodes   []graphNode `json:"nodes"`
+	Edges   []graphEdge `json:"edges"`
+	Orphans []string    `json:"orphans,omitempty"` // conf files of orphan clusters
+	index   map[string]int
+	seen    map[graphEdge]bool
+}
+
+// node adds a node unless it exists and returns its ID
+func (g *provenanceGraph) node(kind, label string) string {
+	id := kind + ":" + label
+	if _, ok := g.index[id]; !ok {
+		g.index[id] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, graphNode{ID: id, Kind: kind, Label: label})
+	}
+	return id
+}
+
+// pathNode adds a path, marking it missing on the audited system, and
+// links it to its owning package
+func (g *provenanceGraph) pathNode(path string, owners map[string]string) string {
+	id := g.node(nodePath, path)
+	if _, err := lstatPath(path); err != nil && !strings.ContainsAny(path, "*?[%") {
+		g.Nodes[g.index[id]].Missing = true
+	}
+	if pkg := ruleOwner(path, owners); pkg != path {
+		g.edge(id, g.node(nodePackage, pkg), "owned by")
+	}
+	return id
+}
+
+// edge adds an edge unless it exists
+func (g *provenanceGraph) edge(from, to, label string) {
+	e := graphEdge{From: from, To: to, Label: label}
+	if !g.seen[e] {
+		g.seen[e] = true
+		g.Edges = append(g.Edges, e)
+	}
+}
+
+// buildProvenanceGraph builds the graph of the effective rules
+func buildProvenanceGraph(rules []rule) provenanceGraph {
+	g := provenanceGraph{index: make(map[string]int), seen: make(map[graphEdge]bool)}
+	owners := make(map[string]string)
+	for _, r := range rules {
+		conf := g.node(nodeConf, r.ConfFile)
+		if pkg := ruleOwner(r.ConfFile, owners); pkg != r.ConfFile {
+			g.edge(conf, g.node(nodePackage, pkg), "shipped by")
+		}
+		ruleID := g.node(nodeRule, fmt.Sprintf("%s:%d", r.ConfFile, r.Line))
+		g.Nodes[g.index[ruleID]].Label = formatRule(r)
+		g.edge(conf, ruleID, "declares")
+		path := g.pathNode(r.Path, owners)
+		g.edge(ruleID, path, "manages")
+		switch r.Type {
+		case "L":
+			g.edge(path, g.pathNode(resolveTargetPath(r.Path, symlinkTarget(r)), owners), "links to")
+		case "C":
+			g.edge(path, g.pathNode(symlinkTarget(r), owners), "copied from")
+		}
+	}
+	if pkgDB != nil {
+		g.markOrphans()
+	}
+	return g
+}
+
+// markOrphans flags the connected clusters that contain no package, which
+// nothing installed accounts for
+func (g *provenanceGraph) markOrphans() {
+	parent := make([]int, len(g.Nodes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, e := range g.Edges {
+		parent[find(g.index[e.From])] = find(g.index[e.To])
+	}
+	owned := make(map[int]bool)
+	for i, n := range g.Nodes {
+		if n.Kind == nodePackage {
+			owned[find(i)] = true
+		}
+	}
+	for i := range g.Nodes {
+		if owned[find(i)] {
+			continue
+		}
+		g.Nodes[i].Orphan = true
+		if g.Nodes[i].Kind == nodeConf {
+			g.Orphans = append(g.Orphans, g.Nodes[i].Label)
+		}
+	}
+	sort.Strings(g.Orphans)
+}
+
+// writeDOT renders the graph in Graphviz DOT
+func (g provenanceGraph) writeDOT() {
+	shapes := map[string]string{nodeConf: "note", nodeRule: "box", nodePath: "ellipse", nodePackage: "component"}
+	fmt.Fprintln(out, "digraph provenance {")
+	fmt.Fprintln(out, "\trankdir=LR;")
+	if len(g.Orphans) > 0 {
+		fmt.Fprintf(out, "\t// %d orphan cluster conf file(s): %s\n", len(g.Orphans), strings.Join(g.Orphans, ", "))
+	}
+	for _, n := range g.Nodes {
+		attrs := fmt.Sprintf("label=%q, shape=%s", n.Label, shapes[n.Kind])
+		switch {
+		case n.Missing:
+			attrs += ", style=dashed, color=red"
+		case n.Orphan:
+			attrs += ", color=orange"
+		}
+		fmt.Fprintf(out, "\t%q [%s];\n", n.ID, attrs)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(out, "\t%q -> %q [label=%q];\n", e.From, e.To, e.Label)
+	}
+	fmt.Fprintln(out, "}")
+}
+
+// runGraph exports the rule provenance graph as DOT, or JSON with --format json
+func runGraph(args []string) int {
+	g := buildProvenanceGraph(effectiveRules())
+	if outputFormat == "json" {
+		data, _ := json.MarshalIndent(g, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+		return 0
+	}
+	g.writeDOT()
+	return 0
+}
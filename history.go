@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// historyExport selects the history output: "csv" or "json"
+var historyExport string
+
+func historyFlags(fs *flag.FlagSet) {
+	fs.StringVar(&historyExport, "export", "csv", "history output format: csv or json")
+}
+
+// historyPoint is one snapshot in the time series
+type historyPoint struct {
+	Snapshot string         `json:"snapshot"`
+	Date     time.Time      `json:"date"`
+	Rules    int            `json:"rules"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Counts   map[string]int `json:"counts"` // findings per category
+}
+
+// runHistory prints the findings per category of every saved snapshot,
+// oldest first, so image quality can be charted across releases
+func runHistory(args []string) int {
+	list, err := listSnapshots()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	points := make([]historyPoint, 0, len(list))
+	categories := make(map[string]bool)
+	for _, snap := range list {
+		p := historyPoint{Snapshot: snap.name, Date: snap.report.Created, Rules: len(snap.report.Rules), Counts: make(map[string]int)}
+		for _, f := range snap.report.Findings {
+			p.Counts[f.Category]++
+			categories[f.Category] = true
+			switch f.Severity {
+			case severityError:
+				p.Errors++
+			case severityWarning:
+				p.Warnings++
+			}
+		}
+		points = append(points, p)
+	}
+
+	switch historyExport {
+	case "json":
+		data, _ := json.MarshalIndent(points, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+	case "csv":
+		names := make([]string, 0, len(categories))
+		for c := range categories {
+			names = append(names, c)
+		}
+		sort.Strings(names)
+		w := csv.NewWriter(os.Stdout)
+		w.Write(append([]string{"date", "snapshot", "rules", "errors", "warnings"}, names...))
+		for _, p := range points {
+			row := []string{p.Date.Format(time.RFC3339), p.Snapshot, strconv.Itoa(p.Rules), strconv.Itoa(p.Errors), strconv.Itoa(p.Warnings)}
+			for _, c := range names {
+				row = append(row, strconv.Itoa(p.Counts[c]))
+			}
+			w.Write(row)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown history format %q\n", historyExport)
+		return 2
+	}
+	return 0
+}
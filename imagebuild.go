@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// imageBuildHook runs the audit as a post-build step of an image build tool
+var imageBuildHook bool
+
+// imageBuildReportName is the file the hook writes its JSON report to
+const imageBuildReportName = "tmpfiles-audit.json"
+
+// setupImageBuildHook takes the image root from the environment: explicitly
+// from TMPFILES_AUDIT_ROOT, or from mkosi's BUILDROOT; tools that run hooks
+// chrooted into the image, like kiwi, need neither
+func setupImageBuildHook() {
+	if rootDir == "" {
+		for _, env := range []string{"TMPFILES_AUDIT_ROOT", "BUILDROOT"} {
+			if dir := os.Getenv(env); dir != "" {
+				rootDir = dir
+				break
+			}
+		}
+	}
+	// Build logs get one diagnostic line per finding instead of the full report
+	out = io.Discard
+}
+
+// imageBuildReportPath is TMPFILES_AUDIT_REPORT, else the report in mkosi's
+// OUTPUTDIR, else the report in the working directory; rpm-ostree runs
+// postprocess scripts inside the tree, so there it is "" for no report
+func imageBuildReportPath() string {
+	if path := os.Getenv("TMPFILES_AUDIT_REPORT"); path != "" {
+		return path
+	}
+	if ostreeCompose {
+		return ""
+	}
+	if dir := os.Getenv("OUTPUTDIR"); dir != "" {
+		return filepath.Join(dir, imageBuildReportName)
+	}
+	return imageBuildReportName
+}
+
+// finishImageBuildHook saves the report and maps findings to the build
+// result: blocking findings fail the build, warnings are logged and only
+// fail it when TMPFILES_AUDIT_STRICT=1
+func finishImageBuildHook() int {
+	path := imageBuildReportPath()
+	if path != "" {
+		if err := saveReport(path, currentReport()); err != nil {
+			fmt.Fprintf(os.Stderr, "tmpfiles-audit: saving report: %v\n", err)
+			return 1
+		}
+	}
+	warnings := 0
+	for _, f := range findings {
+		location, message := f.Path, f.Message
+		if f.ConfFile != "" {
+			location, message = fmt.Sprintf("%s:%d", f.ConfFile, f.Line), f.Path+": "+f.Message
+		}
+		switch {
+		case blocksRun(f):
+			fmt.Fprintf(os.Stderr, "%s: error: %s [%s %s]\n", location, message, f.Code, f.Category)
+		case f.Severity != severityInfo:
+			warnings++
+			fmt.Fprintf(os.Stderr, "%s: warning: %s [%s %s]\n", location, message, f.Code, f.Category)
+		}
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "tmpfiles-audit: report of %s written to %s\n", describeRoot(), path)
+	}
+	if hasErrors() || (warnings > 0 && os.Getenv("TMPFILES_AUDIT_STRICT") == "1") {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// journalSocket is journald's native protocol socket
+const journalSocket = "/run/systemd/journal/socket"
+
+// logTarget selects where findings are logged: "auto", "journal" or "console"
+var logTarget = "auto"
+
+// stdoutIsJournal reports whether stdout is connected to the journal, which
+// systemd announces through JOURNAL_STREAM=<device>:<inode>
+func stdoutIsJournal() bool {
+	dev, ino, ok := strings.Cut(os.Getenv("JOURNAL_STREAM"), ":")
+	if !ok {
+		return false
+	}
+	var st syscall.Stat_t
+	if err := syscall.Fstat(int(os.Stdout.Fd()), &st); err != nil {
+		return false
+	}
+	return strconv.FormatUint(uint64(st.Dev), 10) == dev && strconv.FormatUint(st.Ino, 10) == ino
+}
+
+// journalEnabled reports whether findings should be sent to journald; in
+// auto mode only when stdout itself is the journal, as JOURNAL_STREAM is
+// inherited by children whose output goes elsewhere
+func journalEnabled() bool {
+	switch logTarget {
+	case "journal":
+		return true
+	case "console":
+		return false
+	}
+	return stdoutIsJournal()
+}
+
+// messageID derives a stable 128-bit journal MESSAGE_ID for a finding
+// category, so `journalctl MESSAGE_ID=...` keeps working across releases
+func messageID(category string) string {
+	sum := md5.Sum([]byte("tmpfiles-audit:" + category))
+	return hex.EncodeToString(sum[:])
+}
+
+// journalPriority maps finding severities to syslog priorities
+func journalPriority(severity string) int {
+	switch severity {
+	case severityError:
+		return 3
+	case severityWarning:
+		return 4
+	}
+	return 6
+}
+
+// appendJournalField serializes one field in journald's native format, using
+// the length-prefixed form for values containing newlines
+func appendJournalField(buf *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n", key, value)
+		return
+	}
+	buf.WriteString(key + "\n")
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
+
+// journalEntry builds the structured journal entry for a finding
+func journalEntry(f Finding) []byte {
+	var buf bytes.Buffer
+	message := f.Message + ": " + f.Path
+	if f.Target != "" {
+		message += " -> " + f.Target
+	}
+	appendJournalField(&buf, "MESSAGE", message)
+	appendJournalField(&buf, "MESSAGE_ID", messageID(f.Category))
+	appendJournalField(&buf, "PRIORITY", strconv.Itoa(journalPriority(f.Severity)))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", "tmpfiles-audit")
+	appendJournalField(&buf, "FINDING_CATEGORY", f.Category)
+	appendJournalField(&buf, "FINDING_CODE", f.Code)
+	appendJournalField(&buf, "RULE_PATH", f.Path)
+	if f.Target != "" {
+		appendJournalField(&buf, "RULE_TARGET", f.Target)
+	}
+	if f.ConfFile != "" {
+		appendJournalField(&buf, "CONF_FILE", f.ConfFile)
+		appendJournalField(&buf, "CONF_LINE", strconv.Itoa(f.Line))
+		appendJournalField(&buf, "CONF_SCOPE", f.Scope)
+	}
+	if f.Package != "" {
+		appendJournalField(&buf, "PACKAGE", f.Package)
+	}
+	if f.Runtime != "" {
+		appendJournalField(&buf, "RUNTIME", f.Runtime)
+	}
+	return buf.Bytes()
+}
+
+// logToJournal sends each finding to journald as a structured entry
+func logToJournal(list []Finding) error {
+	if len(list) == 0 {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("connecting to journald: %w", err)
+	}
+	defer conn.Close()
+	for _, f := range list {
+		if _, err := conn.Write(journalEntry(f)); err != nil {
+			return fmt.Errorf("logging to journald: %w", err)
+		}
+	}
+	return nil
+}
+
+// logMessageToJournal sends a plain message to journald
+func logMessageToJournal(priority int, message string) error {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("connecting to journald: %w", err)
+	}
+	defer conn.Close()
+	var buf bytes.Buffer
+	appendJournalField(&buf, "MESSAGE", message)
+	appendJournalField(&buf, "PRIORITY", strconv.Itoa(priority))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", "tmpfiles-audit")
+	_, err = conn.Write(buf.Bytes())
+	return err
+}
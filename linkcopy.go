@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// linkCopyPair reports whether one rule symlinks and the other copies
+func linkCopyPair(a, b rule) bool {
+	return (a.Type == "L" && b.Type == "C") || (a.Type == "C" && b.Type == "L")
+}
+
+// describeLinkCopy names what an L or C rule leaves at its path
+func describeLinkCopy(r rule) string {
+	if r.Type == "L" {
+		return "a symlink to " + symlinkTarget(r)
+	}
+	return "a copy of " + symlinkTarget(r)
+}
+
+// explainLinkCopy explains an L and a C rule for the same path: systemd
+// only applies the first, and since neither replaces an existing path
+// without +, a system keeps whatever an earlier config left there
+func explainLinkCopy(applied, ignored rule) {
+	fmt.Fprintf(out, "   ⤷ systemd creates %s; the C and L rules disagree\n", describeLinkCopy(applied))
+	fi, err := lstatPath(applied.Path)
+	switch {
+	case err != nil:
+		fmt.Fprintf(out, "   ⤷ %s does not exist yet and becomes %s at next boot\n", applied.Path, describeLinkCopy(applied))
+	case (fi.Mode().Type() == 0 || fi.IsDir()) == (applied.Type == "C"):
+		fmt.Fprintf(out, "   ⤷ This system has what the applied rule creates\n")
+	default:
+		fmt.Fprintf(out, "   %s⤷ This system has %s, as the ignored rule creates%s\n", colorYellow, describeFileType(fi.Mode().Type()), colorReset)
+	}
+	if applied.hasModifier('+') {
+		fmt.Fprintf(out, "   ⤷ %s%s replaces an existing path, so systems converge\n", applied.Type, applied.Modifiers)
+	} else {
+		fmt.Fprintf(out, "   ⤷ Without + neither rule replaces an existing path: systems provisioned while %s:%d came first keep %s\n",
+			ignored.ConfFile, ignored.Line, describeLinkCopy(ignored))
+	}
+}
+
+// checkNestedLinkCopy reports L rules below a C rule, which the copy fills
+// in first, and C rules below an L rule, which copy through the symlink;
+// systemd-tmpfiles creates parents before their children
+func checkNestedLinkCopy(rules []rule) int {
+	problems := 0
+	for _, c := range rules {
+		if c.Type != "C" || strings.ContainsAny(c.Path, "*?[%") {
+			continue
+		}
+		for _, l := range rules {
+			if l.Type != "L" || l.Path == c.Path || strings.ContainsAny(l.Path, "*?[%") || (!inAuditScope(c) && !inAuditScope(l)) {
+				continue
+			}
+			var at, parent rule
+			var message string
+			switch {
+			case underPrefix(l.Path, c.Path):
+				copied := symlinkTarget(c) + strings.TrimPrefix(l.Path, c.Path)
+				if _, err := statPath(copied); err != nil || l.hasModifier('+') {
+					continue
+				}
+				at, parent = l, c
+				message = fmt.Sprintf("copy by %s:%d creates it from %s first, so the symlink is never made", c.ConfFile, c.Line, copied)
+			case underPrefix(c.Path, l.Path):
+				at, parent = c, l
+				message = fmt.Sprintf("symlink by %s:%d makes %s copy into %s", l.ConfFile, l.Line, c.Path, resolveTargetPath(l.Path, symlinkTarget(l)))
+			default:
+				continue
+			}
+			problems++
+			fmt.Fprintf(out, "%s⚠ %s %s is below %s %s%s\n", colorYellow, at.Type, at.Path, parent.Type, parent.Path, colorReset)
+			fmt.Fprintf(out, "   ⤷ %s\n", message)
+			addFinding(Finding{Category: catLinkCopyConflict, Severity: severityWarning, Path: at.Path, Target: at.Argument, ConfFile: at.ConfFile, Line: at.Line,
+				Message: message})
+		}
+	}
+	return problems
+}
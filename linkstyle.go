@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Link styles a site can mandate for L rule targets
+const (
+	linkAbsolute = "absolute"
+	linkRelative = "relative"
+)
+
+// linkStyleFlag overrides the link_style of the site policy
+var linkStyleFlag string
+
+// linkStyle returns the mandated form of L rule targets, or "" if any is fine
+func linkStyle() string {
+	if linkStyleFlag != "" {
+		return linkStyleFlag
+	}
+	return sitePolicy.LinkStyle
+}
+
+// styledTarget returns an L rule's target in the given style. A relative
+// target is resolved by the kernel against the directory the link really
+// lives in, so it is computed from the canonical parent directory
+func styledTarget(r rule, style string) string {
+	target := symlinkTarget(r)
+	abs := resolveTargetPath(r.Path, target)
+	switch style {
+	case linkAbsolute:
+		return abs
+	case linkRelative:
+		if rel, err := filepath.Rel(canonicalPath(filepath.Dir(r.Path)), abs); err == nil {
+			return rel
+		}
+	}
+	return target
+}
+
+// checkLinkStyle reports L rules whose target is not in the mandated form,
+// including rules relying on the factory default, which is always absolute
+func checkLinkStyle(list []rule) {
+	style := linkStyle()
+	fmt.Fprintf(out, "\n=== L rules with %s targets ===\n", style)
+	violations := 0
+	for _, r := range list {
+		if r.Type != "L" || strings.ContainsAny(r.Path, "*?[%") || strings.Contains(r.Argument, "%") || !inAuditScope(r) {
+			continue
+		}
+		target := symlinkTarget(r)
+		if filepath.IsAbs(target) == (style == linkAbsolute) {
+			continue
+		}
+		want := styledTarget(r, style)
+		violations++
+		fmt.Fprintf(out, "%s⚠ %s -> %s should be %s -> %s (%s:%d)%s\n", colorYellow, r.Path, target, r.Path, want, r.ConfFile, r.Line, colorReset)
+		addFinding(Finding{Category: catLinkStyle, Severity: severityWarning, Path: r.Path, Target: target, ConfFile: r.ConfFile, Line: r.Line,
+			Message: fmt.Sprintf("target is not %s, use %s", style, want)})
+	}
+	if violations == 0 {
+		fmt.Fprintf(out, "%s✓ Every L rule uses %s targets%s\n", colorGreen, style, colorReset)
+	}
+}
+
+// validLinkStyle reports whether a link style setting is known
+func validLinkStyle(style string) bool {
+	return style == "" || style == linkAbsolute || style == linkRelative
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// targetSystemd is the systemd version the config is checked against; 0
+// means the version found on the audited system
+var targetSystemd int
+
+// specifierVersions lists the specifiers systemd-tmpfiles expands, with the
+// version each was introduced in (0 for those predating version checks)
+var specifierVersions = map[byte]int{
+	'%': 0, 'a': 245, 'A': 248, 'b': 0, 'B': 242, 'C': 0, 'g': 0, 'G': 0, 'h': 0, 'H': 0,
+	'l': 254, 'L': 0, 'm': 0, 'M': 248, 'o': 242, 'q': 255, 'S': 0, 't': 0, 'T': 246,
+	'u': 0, 'U': 0, 'v': 0, 'V': 246, 'w': 242, 'W': 242,
+}
+
+// Per-type rules for which fields systemd-tmpfiles reads, see tmpfiles.d(5)
+const (
+	ageTypes      = "dDevqQCxX"
+	argumentTypes = "fFwWLcbCaAhH"
+	noModeTypes   = "LrRxX"
+)
+
+// systemdLibDirs hold libsystemd-shared-<version>.so on the usual layouts
+var systemdLibDirs = []string{"/usr/lib/systemd", "/usr/lib64/systemd", "/usr/lib/*-linux-gnu*/systemd"}
+
+// detectSystemdVersion finds the systemd version of the audited system,
+// or returns 0 if it cannot be determined
+func detectSystemdVersion() int {
+	for _, dir := range systemdLibDirs {
+		matches, _ := globPath(dir + "/libsystemd-shared-*.so")
+		for _, m := range matches {
+			digits := strings.TrimPrefix(filepath.Base(m), "libsystemd-shared-")
+			if end := strings.IndexFunc(digits, func(c rune) bool { return c < '0' || c > '9' }); end > 0 {
+				v, _ := strconv.Atoi(digits[:end])
+				return v
+			}
+		}
+	}
+	if rootDir == "" {
+		if output, err := exec.Command("systemd-tmpfiles", "--version").Output(); err == nil {
+			if fields := strings.Fields(string(output)); len(fields) > 1 && fields[0] == "systemd" {
+				v, _ := strconv.Atoi(fields[1])
+				return v
+			}
+		}
+	}
+	return 0
+}
+
+// lintWarning records one lint finding for a rule
+func lintWarning(r rule, category, message string) {
+	fmt.Fprintf(out, "%s⚠ %s%s in %s:%d%s\n", colorYellow, strings.ToUpper(message[:1]), message[1:], r.ConfFile, r.Line, colorReset)
+	addFinding(Finding{Category: category, Severity: severityWarning, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line, Message: message})
+}
+
+// checkSpecifiers reports specifiers systemd-tmpfiles does not know, or
+// that are newer than the target version; they make it reject the line
+func checkSpecifiers(r rule, version int) {
+	fields := []string{r.Path}
+	if r.Type == "L" || r.Type == "C" {
+		fields = append(fields, r.Argument)
+	}
+	for _, field := range fields {
+		for i := 0; i < len(field); i++ {
+			if field[i] != '%' {
+				continue
+			}
+			if i+1 == len(field) {
+				lintWarning(r, catUnknownSpecifier, "trailing % without a specifier")
+				break
+			}
+			i++
+			since, known := specifierVersions[field[i]]
+			switch {
+			case !known:
+				lintWarning(r, catUnknownSpecifier, fmt.Sprintf("unknown specifier %%%c", field[i]))
+			case version > 0 && since > version:
+				lintWarning(r, catUnknownSpecifier, fmt.Sprintf("specifier %%%c needs systemd %d, target is %d", field[i], since, version))
+			}
+		}
+	}
+}
+
+// checkDeprecated reports syntax systemd-tmpfiles only accepts for compatibility
+func checkDeprecated(r rule) {
+	switch {
+	case r.Type == "m":
+		lintWarning(r, catDeprecatedSyntax, "type m is a deprecated alias of z")
+	// The compatibility symlinks /var/run and /var/lock themselves are fine
+	case strings.HasPrefix(r.Path, "/var/run/"):
+		lintWarning(r, catDeprecatedSyntax, "path below legacy /var/run, use /run"+strings.TrimPrefix(r.Path, "/var/run"))
+	case strings.HasPrefix(r.Path, "/var/lock/"):
+		lintWarning(r, catDeprecatedSyntax, "path below legacy /var/lock, use /run/lock"+strings.TrimPrefix(r.Path, "/var/lock"))
+	}
+}
+
+// checkIgnoredFields reports fields the rule's type does not use, which
+// usually means the line does not do what its author expects
+func checkIgnoredFields(r rule) {
+	set := func(s string) bool { return s != "" && s != "-" }
+	if set(r.Age) && !strings.Contains(ageTypes, r.Type) {
+		lintWarning(r, catIgnoredField, fmt.Sprintf("age %s is ignored by type %s", r.Age, r.Type))
+	}
+	if r.Argument != "" && !strings.Contains(argumentTypes, r.Type) {
+		lintWarning(r, catIgnoredField, fmt.Sprintf("argument %q is ignored by type %s", r.Argument, r.Type))
+	}
+	if set(r.Mode) && strings.Contains(noModeTypes, r.Type) {
+		lintWarning(r, catIgnoredField, fmt.Sprintf("mode %s is ignored by type %s", r.Mode, r.Type))
+	}
+}
+
+// checkLints runs the syntax lints on a rule
+func checkLints(r rule) {
+	checkSpecifiers(r, targetSystemd)
+	checkDeprecated(r)
+	checkIgnoredFields(r)
+}
+
+// volatileDirs are emptied on every boot, before or while tmpfiles runs
+var volatileDirs = []string{"/tmp", "/run", "/var/run"}
+
+// checkVolatileSources warns about symlink targets and copy sources in
+// volatile directories, which are unlikely to exist when tmpfiles runs at
+// boot; the directories themselves and paths other rules create are fine
+func checkVolatileSources(rules []rule) {
+	// Creating a path creates its missing parents as well
+	created := make(map[string]bool)
+	for _, r := range rules {
+		if _, ok := creationTypes[r.Type]; ok {
+			for p := r.Path; p != "/" && p != "."; p = filepath.Dir(p) {
+				created[p] = true
+			}
+		}
+	}
+	for _, r := range rules {
+		var source, what string
+		switch r.Type {
+		case "L":
+			source, what = resolveTargetPath(r.Path, symlinkTarget(r)), "symlink target"
+		case "C":
+			if source, what = r.Argument, "copy source"; source == "" {
+				source = factoryPath(r.Path)
+			}
+		default:
+			continue
+		}
+		if created[source] {
+			continue
+		}
+		for _, dir := range volatileDirs {
+			if source == dir || !underPrefix(source, dir) {
+				continue
+			}
+			message := fmt.Sprintf("%s %s is in volatile %s and may not exist when tmpfiles runs at boot", what, source, dir)
+			fmt.Fprintf(out, "%s⚠ %s%s in %s:%d%s\n", colorYellow, strings.ToUpper(message[:1]), message[1:], r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catVolatileTarget, Severity: severityWarning, Path: r.Path, Target: source, ConfFile: r.ConfFile, Line: r.Line,
+				Message: message})
+			break
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// machineName selects a systemd-nspawn/machined container to audit
+var machineName string
+
+// machineSearchPath lists where systemd-nspawn looks for container trees
+var machineSearchPath = []string{"/etc/machines", "/run/machines", "/var/lib/machines", "/usr/local/lib/machines", "/usr/lib/machines"}
+
+// machineRoot finds a container's root directory, asking machined first for
+// running machines and then searching the image directories like nspawn
+func machineRoot(name string) (string, error) {
+	if strings.ContainsAny(name, "/") || name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid machine name %q", name)
+	}
+	output, err := exec.Command("machinectl", "show", name, "--property=RootDirectory", "--value").Output()
+	if root := strings.TrimSpace(string(output)); err == nil && root != "" {
+		return root, nil
+	}
+	for _, dir := range machineSearchPath {
+		path := dir + "/" + name
+		fi, err := os.Stat(path)
+		if err == nil && fi.IsDir() {
+			return path, nil
+		}
+		if _, err := os.Stat(path + ".raw"); err == nil {
+			return "", fmt.Errorf("machine %s is a disk image (%s.raw), which cannot be audited directly; mount it and use --root", name, path)
+		}
+	}
+	return "", fmt.Errorf("no running machine or container tree named %s", name)
+}
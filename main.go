@@ -5,11 +5,16 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 )
 
 // lineRegex matches tmpfiles.d symlink lines (L, L?, L+)
@@ -25,6 +30,20 @@ var (
 	colorBoldRed = "\033[1;31m"
 )
 
+// Command-line options
+var (
+	// parsedRules holds every rule of the current audit run, split by parseRule
+	parsedRules []rule
+
+	verifyConf  bool
+	packageName string
+	packageFile string
+
+	// packagePayload limits completeness checks to the factory files of the
+	// audited package when --package is given; nil means no restriction
+	packagePayload map[string]bool
+)
+
 // cleanQuotes removes surrounding quotes and whitespace from a string
 func cleanQuotes(s string) string {
 	s = strings.TrimSpace(s)
@@ -45,23 +64,48 @@ func resolveTargetPath(symlinkPath, target string) string {
 	return filepath.Clean(filepath.Join(symlinkDir, target))
 }
 
+// parseSymlinkLine extracts the path and raw target of an L line using
+// lineRegex; ok is false if the line doesn't match the expected format
+func parseSymlinkLine(line string) (path, target string, ok bool) {
+	matches := lineRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return "", "", false
+	}
+
+	path = matches[1]
+
+	// Extract the actual target: last field after placeholders
+	fields := strings.Fields(matches[2])
+	if len(fields) > 0 {
+		target = cleanQuotes(fields[len(fields)-1])
+	}
+	return path, target, true
+}
+
 // factoryTarget returns the "factory default" target for a given path.
-// /etc and /var have special handling; others are under /usr/share/factory.
+// /etc and /var have special handling; others are under the factory root.
 func factoryTarget(path string) string {
+	target, _ := locateFactory(factoryRelative(path))
+	return target
+}
+
+// factoryRelative returns where below a factory root a path's default lives
+func factoryRelative(path string) string {
 	filename := filepath.Base(path)
 	if filename == "etc" || strings.HasPrefix(path, "/etc/") {
-		return "/usr/share/factory/etc/" + strings.TrimPrefix(path, "/etc/")
+		return "/etc/" + strings.TrimPrefix(path, "/etc/")
 	} else if filename == "var" || strings.HasPrefix(path, "/var/") {
-		return "/usr/share/factory/var/" + strings.TrimPrefix(path, "/var/")
+		return "/var/" + strings.TrimPrefix(path, "/var/")
 	}
-	return "/usr/share/factory" + path
+	return path
 }
 
 // processLine handles L, L?, and L+ symlinks
 // - L  : normal, errors if target missing
 // - L? : optional, warns if target missing
 // - L+ : force recreate, logs note about recreation
-func processLine(line string, linkedDirs map[string]map[string]bool) error {
+// conf and lineNo identify where the line came from in recorded findings.
+func processLine(line, conf string, lineNo int, linkedDirs map[string]map[string]bool) error {
 	if !strings.HasPrefix(line, "L") {
 		return nil // Not a symlink line; skip
 	}
@@ -82,34 +126,45 @@ func processLine(line string, linkedDirs map[string]map[string]bool) error {
 		recreate = true
 	}
 
-	// Parse line using regex
-	matches := lineRegex.FindStringSubmatch(line)
-	if matches == nil {
+	path, target, ok := parseSymlinkLine(line)
+	if !ok {
 		return nil // Line doesn't match expected L line format; skip
 	}
 
-	path := matches[1]
-
-	// Extract the actual target: last field after placeholders
-	targetField := matches[2]
-	fields := strings.Fields(targetField)
-	var target string
-	if len(fields) > 0 {
-		target = cleanQuotes(fields[len(fields)-1])
-	} else {
-		target = ""
-	}
-
 	// Handle factory default if target is empty or "-"
 	if target == "" || target == "-" {
-		ft := factoryTarget(path)
-		fmt.Printf("%s -> (factory default: %s)\n", path, ft)
-		if _, err := os.Stat(ft); err == nil {
-			fmt.Printf("  %s✓ Factory target exists: %s%s\n", colorGreen, ft, colorReset)
+		ft, root := locateFactory(factoryRelative(path))
+		fmt.Fprintf(out, "%s -> (factory default: %s)\n", path, ft)
+		if _, err := statPath(ft); err == nil {
+			fmt.Fprintf(out, "  %s✓ Factory target exists: %s%s\n", colorGreen, ft, colorReset)
+			checkBackingObject(path, ft, conf, lineNo)
+			if !primaryFactoryRoot(root) {
+				fmt.Fprintf(out, "  %s⚠ Only provided by lower-priority factory root %s%s\n", colorYellow, root, colorReset)
+				addFinding(Finding{Category: catLowerFactoryRoot, Severity: severityWarning, Path: path, Target: ft, ConfFile: conf, Line: lineNo,
+					Message: "factory target is only provided by lower-priority root " + root})
+			} else if len(factoryRoots()) > 1 {
+				fmt.Fprintf(out, "  Provided by factory root %s\n", root)
+			}
+		} else if transientStatError(err) {
+			reportUnverifiable(path, ft, conf, lineNo, err)
+			return nil
+		} else if p, ok := onFirstBootPartition(ft); ok {
+			reportDeferred(p, path, ft, conf, lineNo)
+			return nil
+		} else if reportPopulatedAtBoot(path, ft, conf, lineNo) {
+			return nil
+		} else if reportOptionalAbsent(path, ft, conf, lineNo) {
+			return nil
 		} else if targetOptional {
-			fmt.Printf("  %s⚠ Factory target missing (optional): %s%s\n", colorYellow, ft, colorReset)
+			fmt.Fprintf(out, "  %s⚠ Factory target missing (optional): %s%s\n", colorYellow, ft, colorReset)
+			addFinding(Finding{Category: catOptionalMissing, Severity: severityWarning, Path: path, Target: ft, ConfFile: conf, Line: lineNo,
+				Message: "optional factory target is missing"})
 		} else {
-			fmt.Printf("  %s✗ Factory target missing: %s%s\n", colorRed, ft, colorReset)
+			fmt.Fprintf(out, "  %s✗ Factory target missing: %s%s\n", colorRed, ft, colorReset)
+			pkg := printOwnership(ft, "    ", true)
+			explainBootPopulator(ft)
+			addFinding(Finding{Category: missingTargetCategory(ft), Severity: severityError, Path: path, Target: ft, ConfFile: conf, Line: lineNo, Package: pkg,
+				Message: "factory target is missing"})
 			return fmt.Errorf("missing factory target: %s", ft)
 		}
 		dir := filepath.Dir(ft)
@@ -122,53 +177,72 @@ func processLine(line string, linkedDirs map[string]map[string]bool) error {
 	} else {
 		// Explicit target given - resolve relative path if needed
 		resolvedTarget := resolveTargetPath(path, target)
-		fmt.Printf("%s -> %s\n", path, target)
+		fmt.Fprintf(out, "%s -> %s\n", path, target)
 		if resolvedTarget != target {
-			fmt.Printf("  %sResolved target: %s%s\n", colorYellow, resolvedTarget, colorReset)
+			fmt.Fprintf(out, "  %sResolved target: %s%s\n", colorYellow, resolvedTarget, colorReset)
 		}
 		
-		if _, err := os.Stat(resolvedTarget); err == nil {
-			fmt.Printf("  %s✓ Target exists: %s%s\n", colorGreen, resolvedTarget, colorReset)
-			dir := filepath.Dir(resolvedTarget)
-			if !isBaseDir(dir) {
+		if storePath := resolveStorePath(resolvedTarget); storePath != resolvedTarget {
+			fmt.Fprintf(out, "  %sStore path: %s%s\n", colorYellow, storePath, colorReset)
+		}
+
+		err := statTarget(resolvedTarget)
+		if err == nil {
+			fmt.Fprintf(out, "  %s✓ Target exists: %s%s\n", colorGreen, resolvedTarget, colorReset)
+			checkBackingObject(path, resolvedTarget, conf, lineNo)
+			dir := filepath.Dir(resolveStorePath(resolvedTarget))
+			if trackedDir(dir) {
 				if _, ok := linkedDirs[dir]; !ok {
 					linkedDirs[dir] = make(map[string]bool)
 				}
 				linkedDirs[dir][filepath.Base(resolvedTarget)] = true
 			}
+		} else if transientStatError(err) {
+			reportUnverifiable(path, resolvedTarget, conf, lineNo, err)
+			return nil
+		} else if p, ok := onFirstBootPartition(resolvedTarget); ok {
+			reportDeferred(p, path, resolvedTarget, conf, lineNo)
+			return nil
+		} else if reportPopulatedAtBoot(path, resolvedTarget, conf, lineNo) {
+			return nil
+		} else if reportOptionalAbsent(path, resolvedTarget, conf, lineNo) {
+			return nil
 		} else if targetOptional {
-			fmt.Printf("  %s⚠ Target missing (optional): %s%s\n", colorYellow, resolvedTarget, colorReset)
+			fmt.Fprintf(out, "  %s⚠ Target missing (optional): %s%s\n", colorYellow, resolvedTarget, colorReset)
+			addFinding(Finding{Category: catOptionalMissing, Severity: severityWarning, Path: path, Target: resolvedTarget, ConfFile: conf, Line: lineNo,
+				Message: "optional symlink target is missing"})
 		} else {
-			fmt.Printf("  %s✗ Target missing: %s%s\n", colorRed, resolvedTarget, colorReset)
+			fmt.Fprintf(out, "  %s✗ Target missing: %s%s\n", colorRed, resolvedTarget, colorReset)
+			pkg := printOwnership(resolvedTarget, "    ", true)
+			explainBootPopulator(resolvedTarget)
+			addFinding(Finding{Category: missingTargetCategory(resolvedTarget), Severity: severityError, Path: path, Target: resolvedTarget, ConfFile: conf, Line: lineNo, Package: pkg,
+				Message: "symlink target is missing"})
 			return fmt.Errorf("missing target: %s", resolvedTarget)
 		}
 	}
 
 	if recreate {
-		fmt.Printf("  %sNote: will recreate symlink if missing%s\n", colorYellow, colorReset)
+		fmt.Fprintf(out, "  %sNote: will recreate symlink if missing%s\n", colorYellow, colorReset)
 	}
 
 	return nil
 }
 
-// isBaseDir returns true if a directory is considered a base system dir
-func isBaseDir(dir string) bool {
-	baseDirs := []string{"/etc", "/var", "/usr", "/bin", "/sbin", "/lib", "/lib64", "/proc", "/run"}
-	for _, b := range baseDirs {
-		if dir == b {
-			return true
-		}
-	}
-	return false
+// reportUnverifiable records a target whose storage kept failing, which
+// says nothing about whether the target exists
+func reportUnverifiable(path, target, conf string, lineNo int, err error) {
+	fmt.Fprintf(out, "  %s⚠ Target unverifiable: %s (%v)%s\n", colorYellow, target, err, colorReset)
+	addFinding(Finding{Category: catUnverifiable, Severity: severityWarning, Path: path, Target: target, ConfFile: conf, Line: lineNo,
+		Message: fmt.Sprintf("target cannot be checked after %d retries: %v", statRetries, err)})
 }
 
 // loadIgnoreFiles reads all .ignore files under /usr/share/tmpfiles.d/
 func loadIgnoreFiles() map[string]bool {
 	ignoredFiles := make(map[string]bool)
-	files, _ := filepath.Glob("/usr/share/tmpfiles.d/*.ignore")
+	files, _ := globPath("/usr/share/tmpfiles.d/*.ignore")
 
 	for _, file := range files {
-		f, err := os.Open(file)
+		f, err := openPath(file)
 		if err != nil {
 			continue
 		}
@@ -179,44 +253,61 @@ func loadIgnoreFiles() map[string]bool {
 				continue
 			}
 			ignoredFiles[line] = true
-			fmt.Printf("   %s⤷ Ignore rule: skip %s (from %s)%s\n", colorYellow, line, file, colorReset)
+			fmt.Fprintf(out, "   %s⤷ Ignore rule: skip %s (from %s)%s\n", colorYellow, line, file, colorReset)
 		}
 		f.Close()
 	}
 	return ignoredFiles
 }
 
-// checkDirectoryCompleteness ensures all files in tracked directories are either linked or ignored
+// checkDirectoryCompleteness ensures all files in tracked directories are either linked or ignored,
+// and with --recursive-completeness those in their subdirectories too
 func checkDirectoryCompleteness(linkedDirs map[string]map[string]bool, ignoredFiles map[string]bool) error {
 	hadError := false
 	for dir, linkedFiles := range linkedDirs {
+		notifyWatchdog()
 		// Skip checking certain directories that aren't meant to be fully linked
 		if strings.Contains(dir, "/.git") || dir == "." || dir == ".." {
 			continue
 		}
+		if _, ok := directoryLink(dir, linkedDirs); ok {
+			continue
+		}
 		
-		entries, err := os.ReadDir(dir)
+		entries, err := listDir(dir)
 		if err != nil {
 			continue
 		}
 
 		missing := []string{}
 		for _, entry := range entries {
+			fullPath := filepath.Join(dir, entry.Name())
 			if entry.IsDir() {
+				if recursiveCompleteness && !ignoredFiles[fullPath] && !linkedFiles[entry.Name()] {
+					missing = append(missing, unlinkedBelow(fullPath, entry.Name(), linkedDirs, ignoredFiles)...)
+				}
 				continue
 			}
-			fullPath := filepath.Join(dir, entry.Name())
 			if ignoredFiles[fullPath] {
 				continue
 			}
+			if packagePayload != nil && !packagePayload[fullPath] {
+				continue
+			}
 			if !linkedFiles[entry.Name()] {
 				missing = append(missing, entry.Name())
 			}
 		}
 
 		if len(missing) > 0 {
-			fmt.Printf("%s✗ Error: Directory %s has symlinks in tmpfiles.d but not all files are linked.%s\n", colorRed, dir, colorReset)
-			fmt.Printf("   Missing files: %s%s%s\n", colorRed, strings.Join(missing, ", "), colorReset)
+			fmt.Fprintf(out, "%s✗ Error: Directory %s has symlinks in tmpfiles.d but not all files are linked.%s\n", colorRed, dir, colorReset)
+			fmt.Fprintf(out, "   Missing files: %s%s%s\n", colorRed, strings.Join(missing, ", "), colorReset)
+			for _, name := range missing {
+				fullPath := filepath.Join(dir, name)
+				pkg := printOwnership(fullPath, "   ", false)
+				addFinding(Finding{Category: catIncompleteDir, Severity: severityError, Path: fullPath, Package: pkg,
+					Message: fmt.Sprintf("file is not linked or ignored, but %s has symlinks in tmpfiles.d", dir)})
+			}
 			hadError = true
 		}
 	}
@@ -228,16 +319,20 @@ func checkDirectoryCompleteness(linkedDirs map[string]map[string]bool, ignoredFi
 
 // printSummary outputs a detailed human-readable report
 func printSummary(linkedDirs map[string]map[string]bool, ignoredFiles map[string]bool) {
-	fmt.Println("\n=== Summary of Linked/Ignored/Missing Files ===")
+	fmt.Fprintln(out, "\n=== Summary of Linked/Ignored/Missing Files ===")
 	for dir, linkedFiles := range linkedDirs {
 		// Skip certain directories in summary
 		if strings.Contains(dir, "/.git") || dir == "." || dir == ".." {
 			continue
 		}
+		if link, ok := directoryLink(dir, linkedDirs); ok {
+			fmt.Fprintf(out, "\nDirectory: %s\n  Linked as a whole through %s%s%s\n", dir, colorGreen, link, colorReset)
+			continue
+		}
 		
-		entries, err := os.ReadDir(dir)
+		entries, err := listDir(dir)
 		if err != nil {
-			fmt.Printf("%sDirectory: %s (cannot read: %v)%s\n", colorRed, dir, err, colorReset)
+			fmt.Fprintf(out, "%sDirectory: %s (cannot read: %v)%s\n", colorRed, dir, err, colorReset)
 			continue
 		}
 
@@ -246,10 +341,18 @@ func printSummary(linkedDirs map[string]map[string]bool, ignoredFiles map[string
 		actualLinked := []string{}
 
 		for _, entry := range entries {
+			fullPath := filepath.Join(dir, entry.Name())
 			if entry.IsDir() {
+				if linkedFiles[entry.Name()] {
+					actualLinked = append(actualLinked, entry.Name()+"/")
+				} else if recursiveCompleteness && !ignoredFiles[fullPath] {
+					missing = append(missing, unlinkedBelow(fullPath, entry.Name(), linkedDirs, ignoredFiles)...)
+				}
+				continue
+			}
+			if packagePayload != nil && !packagePayload[fullPath] {
 				continue
 			}
-			fullPath := filepath.Join(dir, entry.Name())
 			if ignoredFiles[fullPath] {
 				ignored = append(ignored, entry.Name())
 			} else if linkedFiles[entry.Name()] {
@@ -260,66 +363,597 @@ func printSummary(linkedDirs map[string]map[string]bool, ignoredFiles map[string
 		}
 
 		if len(missing) > 0 {
-			fmt.Printf("\n%sDirectory: %s%s\n", colorBoldRed, dir, colorReset)
+			fmt.Fprintf(out, "\n%sDirectory: %s%s\n", colorBoldRed, dir, colorReset)
 		} else {
-			fmt.Printf("\nDirectory: %s\n", dir)
+			fmt.Fprintf(out, "\nDirectory: %s\n", dir)
 		}
 
 		if len(actualLinked) > 0 {
-			fmt.Printf("  Linked files: %s%s%s\n", colorGreen, strings.Join(actualLinked, ", "), colorReset)
+			fmt.Fprintf(out, "  Linked files: %s%s%s\n", colorGreen, strings.Join(actualLinked, ", "), colorReset)
 		}
 		if len(ignored) > 0 {
-			fmt.Printf("  Ignored files: %s%s%s\n", colorYellow, strings.Join(ignored, ", "), colorReset)
+			fmt.Fprintf(out, "  Ignored files: %s%s%s\n", colorYellow, strings.Join(ignored, ", "), colorReset)
 		}
 		if len(missing) > 0 {
-			fmt.Printf("  Missing files: %s%s%s\n", colorRed, strings.Join(missing, ", "), colorReset)
+			fmt.Fprintf(out, "  Missing files: %s%s%s\n", colorRed, strings.Join(missing, ", "), colorReset)
 		} else {
-			fmt.Println("  All files properly linked or ignored. 🎉 No broken links, unlike my love life!")
+			fmt.Fprintln(out, "  All files properly linked or ignored. 🎉 No broken links, unlike my love life!")
 		}
 	}
 }
 
 func main() {
-	files, err := filepath.Glob("/usr/lib/tmpfiles.d/*.conf")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error finding files: %v\n", err)
-		os.Exit(1)
+	flag.BoolVar(&verifyConf, "verify-conf", false, "verify that each .conf is owned by an installed package and unmodified")
+	flag.StringVar(&packageName, "package", "", "audit only the tmpfiles.d fragments and factory payload of an installed package")
+	flag.StringVar(&packageFile, "package-file", "", "audit the tmpfiles.d fragments and factory content of an uninstalled .rpm or .deb")
+	flag.StringVar(&simulateRemove, "simulate-remove", "", "predict which tmpfiles.d symlinks would dangle if this package were removed")
+	flag.Var(&roots, "root", "audit the system installed under this directory instead of /; repeat to audit several roots or images at once")
+	flag.StringVar(&rootsFile, "roots-file", "", "file listing roots or images to audit together, one per line")
+	flag.StringVar(&machineName, "machine", "", "audit the root directory of this systemd-nspawn/machined container")
+	flag.BoolVar(&includeRuntimes, "include-runtimes", false, "treat paths managed by flatpak, snapd or container storage like any other")
+	flag.BoolVar(&contentAddressedStore, "content-addressed-store", false, "treat /nix/store and /gnu/store targets as present when their store object exists")
+	flag.BoolVar(&crossValidate, "cross-validate", false, "compare the parsed rules against systemd-tmpfiles --cat-config")
+	flag.BoolVar(&checkNeedsUpdate, "check-needs-update", false, "evaluate ConditionNeedsUpdate= for /etc and /var and report stale links that no systemd-tmpfiles pass replaces on next boot")
+	flag.BoolVar(&checkSoftReboot, "check-soft-reboot", false, "report which rule paths survive systemctl soft-reboot and the rules that would keep stale content across it")
+	flag.BoolVar(&checkSysusers, "check-sysusers", false, "report users and groups that rules rely on but that only exist on this system, not in sysusers.d or the accounts shipped in /usr")
+	flag.BoolVar(&checkUnitDirs, "check-unit-dirs", false, "report rules for directories that units already manage through StateDirectory=, RuntimeDirectory= and similar settings")
+	flag.BoolVar(&requireFactoryReset, "require-factory-reset", false, "fail on L rules under /etc that neither target the factory tree nor are waived by an ignore file or the policy's factory_reset_waivers")
+	flag.BoolVar(&checkPkgPerms, "check-pkg-perms", false, "report rules whose mode or owner disagrees with dpkg-statoverride or rpm's file attributes, which flip permissions between boots and updates")
+	flag.StringVar(&pluginDir, "plugin-dir", "", "directory of check plugins (default "+strings.Join(defaultPluginDirs, " and ")+")")
+	flag.BoolVar(&noPlugins, "no-plugins", false, "do not run check plugins")
+	flag.DurationVar(&pluginTimeout, "plugin-timeout", pluginTimeout, "time an executable check plugin may run")
+	flag.BoolVar(&verifyBoot, "verify-boot", false, "check that paths declared by rules were created this boot and are still intact")
+	flag.BoolVar(&securityScan, "security", false, "flag world-writable, setuid/setgid or non-root-owned symlink targets and factory files")
+	flag.Var(&allowedTargets, "allowed-targets", "comma-separated prefixes L rule targets must resolve into, e.g. /usr/share/factory,/usr/etc")
+	flag.BoolVar(&measuredConfig, "measured", false, "report whether factory files behind /etc symlinks are fs-verity or IMA protected")
+	flag.BoolVar(&sandbox, "sandbox", false, "confine any run to read-only file system access with Landlock before scanning, except for the outputs it was asked to write; plain audits are confined by default")
+	flag.BoolVar(&noSandbox, "no-sandbox", false, "do not confine plain audits to read-only file system access")
+	flag.StringVar(&advisoriesFile, "advisories", "", "JSON file with additional or updated risky-pattern advisories")
+	flag.StringVar(&baselineFile, "baseline", "", "only report findings not recorded in this baseline file")
+	flag.BoolVar(&updateBaseline, "update-baseline", false, "record the current findings into the --baseline file")
+	flag.BoolVar(&transactionPre, "pre", false, "package manager hook: record the state before a transaction")
+	flag.BoolVar(&transactionPost, "post", false, "package manager hook: report what the transaction changed since --pre")
+	flag.StringVar(&goldenFile, "golden", "", "fail on rules, targets or unmanaged files that differ from this golden-image report")
+	flag.Var(&failOn, "fail-on", "comma-separated finding categories or lint codes that fail the run; others only annotate")
+	flag.Var(&warnOn, "warn-on", "comma-separated finding categories or lint codes reported as warnings instead of errors")
+	flag.BoolVar(&imageBuildHook, "image-build-hook", false, "post-build hook for mkosi, kiwi or osbuild: root from $TMPFILES_AUDIT_ROOT or $BUILDROOT, JSON report to $OUTPUTDIR")
+	flag.BoolVar(&annotatePR, "annotate-pr", false, "GitHub Actions mode: JSON report, ::error annotations and a $GITHUB_STEP_SUMMARY table")
+	flag.StringVar(&resultsDir, "results-dir", "", "write an openQA test result per finding category, with per-finding logs, into this directory")
+	flag.BoolVar(&stagedMode, "staged", false, "pre-commit mode: audit only the tmpfiles.d fragments staged in git, or the files given as arguments, against --root")
+	flag.BoolVar(&failFast, "fail-fast", false, "stop and exit 1 at the first finding that fails the run")
+	flag.BoolVar(&describeAudit, "describe", false, "print the supported checks, formats, options and schema versions as JSON and exit")
+	flag.IntVar(&statRetries, "stat-retries", 3, "retries for stat calls failing with EIO, ESTALE or ENOTCONN before a target is reported unverifiable")
+	flag.DurationVar(&statBackoff, "stat-backoff", 100*time.Millisecond, "delay before the first stat retry, doubled on each further one")
+	flag.StringVar(&explainCode, "explain", "", "describe the cause and typical fixes of a lint code such as TFA001 and exit")
+	flag.IntVar(&targetSystemd, "target-systemd", 0, "systemd version to check specifiers, rule types and modifiers against (default: the version on the audited system)")
+	flag.StringVar(&siteConfigFile, "config", "", "site configuration file (default "+defaultSiteConfig+")")
+	flag.StringVar(&policyFile, "policy", "", "JSON site policy: required rules, forbidden path prefixes and mandated modes")
+	flag.BoolVar(&watchMode, "watch", false, "stay resident and re-audit when tmpfiles.d or factory directories change, emitting new and resolved findings")
+	flag.DurationVar(&watchDelay, "watch-delay", watchDelay, "how long changes must settle before --watch re-audits")
+	flag.StringVar(&listenAddr, "listen", "", "in daemon mode, serve HTTP endpoints such as /metrics on this address (default for serve: "+defaultListenAddr+")")
+	flag.StringVar(&varlinkSocket, "varlink", "", "in daemon mode, serve the "+varlinkInterface+" Varlink interface on this unix socket, e.g. "+defaultVarlinkSocket+"; tail connects to it")
+	flag.StringVar(&querySocket, "query-socket", "", "in daemon mode, answer length-prefixed JSON queries on this unix socket")
+	flag.Var(&factoryRootsFlag, "factory-root", "directory L targets and C sources default to; repeat to search several in order (default: factory_roots from the site config, else "+defaultFactoryRoot+")")
+	flag.StringVar(&layout, "layout", layout, "where /etc defaults live: factory (<factory root>/etc), usretc (/usr/etc) or auto (default: that of the --profile)")
+	flag.StringVar(&profileName, "profile", profileName, "distribution profile for factory roots, layout, base dirs and /usr merge: "+strings.Join(profileNames(), ", "))
+	flag.BoolVar(&reverseCompleteness, "reverse-completeness", false, "report factory files whose path in /etc or /var was never linked, copied or ignored")
+	flag.StringVar(&linkStyleFlag, "link-style", "", "require absolute or relative L rule targets (default: link_style of the --policy file); --fix creates links in this form")
+	flag.Var(&baseDirsFlag, "base-dir", "exempt a directory from completeness checks, or everything below it with a trailing slash; repeatable, adds to base_dirs from the site config")
+	flag.BoolVar(&verbose, "verbose", false, "also report details such as the directories exempt from completeness checks")
+	flag.BoolVar(&recursiveCompleteness, "recursive-completeness", false, "also require the files in subdirectories of tracked directories to be linked or ignored")
+	flag.BoolVar(&ostreeCompose, "rpm-ostree-postprocess", false, "rpm-ostree treefile postprocess step: audit the tree being composed, reading /etc from /usr/etc if needed, and fail on unsatisfied factory links")
+	flag.BoolVar(&preFirstBoot, "pre-firstboot", false, "treat the audited system as not booted yet even if it has a machine ID, deferring targets on repart.d partitions to first boot")
+	flag.StringVar(&auditUser, "user", "", "audit the per-user rules in user-tmpfiles.d of this account, expanding %h, %t, %S and the other specifiers like systemd-tmpfiles --user")
+	flag.StringVar(&phase, "phase", phase, "expectations to audit against: firstboot (/var content created by C and d rules may be missing), steady, or auto (firstboot if the system never booted)")
+	flag.BoolVar(&checkReadOnly, "check-readonly", false, "report rules that create or adjust paths on read-only mounts, which can never be satisfied at runtime")
+	flag.BoolVar(&fixMode, "fix", false, "create missing symlinks of L rules whose target exists")
+	flag.StringVar(&logTarget, "log-target", "auto", "where to log findings: auto, journal or console")
+	flag.BoolVar(&oneshotService, "oneshot-service", false, "run as tmpfiles-audit.service: quiet, journal logging, only report changes since the last run")
+	flag.BoolVar(&dbusService, "dbus", false, "serve audit results as "+dbusName+" on D-Bus")
+	flag.StringVar(&dbusBus, "dbus-bus", "system", "bus to register on with --dbus: system or session")
+	flag.StringVar(&outputFormat, "format", "text", "output format: "+strings.Join(outputFormats, ", "))
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] [command [options]]\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+		printCommands()
+	}
+	flag.Parse()
+	cmd, cmdArgs, haveCommand := parseCommand()
+	if describeAudit {
+		printCapabilities()
+		os.Exit(0)
+	}
+	if explainCode != "" {
+		os.Exit(explain(explainCode))
+	}
+
+	if !slices.Contains(outputFormats, outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q\n", outputFormat)
+		os.Exit(2)
+	}
+	if outputFormat != "text" {
+		out = io.Discard
+	}
+	if !slices.Contains([]string{"auto", "journal", "console"}, logTarget) {
+		fmt.Fprintf(os.Stderr, "Error: unknown log target %q\n", logTarget)
+		os.Exit(2)
+	}
+	if oneshotService {
+		out = io.Discard
+		logTarget = "console" // the delta is logged by finishOneshotService
+	}
+	if journalEnabled() && stdoutIsJournal() {
+		// Findings go to the journal as structured entries; don't log them twice
+		out = io.Discard
+	}
+
+	if ostreeCompose {
+		imageBuildHook = true
+	}
+	if imageBuildHook {
+		setupImageBuildHook()
+	}
+
+	if machineName != "" {
+		if rootDir != "" {
+			fmt.Fprintln(os.Stderr, "Error: --machine and --root are mutually exclusive")
+			os.Exit(2)
+		}
+		root, err := machineRoot(machineName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		rootDir = root
+	}
+
+	if transactionPre && transactionPost {
+		fmt.Fprintln(os.Stderr, "Error: --pre and --post are mutually exclusive")
+		os.Exit(2)
+	}
+	if updateBaseline && (transactionPre || transactionPost) {
+		fmt.Fprintln(os.Stderr, "Error: --update-baseline cannot be combined with --pre or --post")
+		os.Exit(2)
+	}
+	if updateBaseline && baselineFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: --update-baseline needs --baseline FILE")
+		os.Exit(2)
+	}
+	if failFast && (baselineFile != "" || dbusService || oneshotService) {
+		fmt.Fprintln(os.Stderr, "Error: --fail-fast cannot be combined with --baseline, --dbus or --oneshot-service")
+		os.Exit(2)
+	}
+	if fixMode && (dbusService || stagedMode) {
+		fmt.Fprintln(os.Stderr, "Error: --fix cannot be combined with --dbus or --staged")
+		os.Exit(2)
+	}
+	if watchMode && (dbusService || oneshotService || failFast || fixMode) {
+		fmt.Fprintln(os.Stderr, "Error: --watch cannot be combined with --dbus, --oneshot-service, --fail-fast or --fix")
+		os.Exit(2)
+	}
+	daemonMode := watchMode || haveCommand && flag.Arg(0) == "serve"
+	if (listenAddr != "" || querySocket != "" || varlinkSocket != "" && !(haveCommand && flag.Arg(0) == "tail")) && !daemonMode {
+		fmt.Fprintln(os.Stderr, "Error: --listen, --varlink and --query-socket need --watch or the serve command")
+		os.Exit(2)
+	}
+	for _, category := range failOn {
+		if slices.Contains(warnOn, category) {
+			fmt.Fprintf(os.Stderr, "Error: category %s is given to both --fail-on and --warn-on\n", category)
+			os.Exit(2)
+		}
+	}
+
+	if !validLinkStyle(linkStyleFlag) {
+		fmt.Fprintf(os.Stderr, "Error: unknown link style %q\n", linkStyleFlag)
+		os.Exit(2)
+	}
+	if err := loadSiteConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading site config: %v\n", err)
+		os.Exit(2)
+	}
+	if policyFile != "" {
+		if err := loadPolicy(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading policy: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if err := loadAdvisories(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading advisories: %v\n", err)
+		os.Exit(2)
+	}
+
+	cmdName := ""
+	if haveCommand {
+		cmdName = flag.Arg(0)
+	}
+	if sandbox && noSandbox {
+		fmt.Fprintln(os.Stderr, "Error: --sandbox and --no-sandbox are mutually exclusive")
+		os.Exit(2)
+	}
+	if sandbox {
+		writable, err := sandboxWrites(cmdName, cmdArgs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if err := enterSandbox(writable); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot enter sandbox: %v\n", err)
+			os.Exit(1)
+		}
+	} else if !noSandbox && plainAudit(cmdName) && landlockAvailable() {
+		if err := enterSandbox(nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot enter the default read-only sandbox (use --no-sandbox to skip it): %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if fixMode && (packageFile != "" || simulateRemove != "") {
+		fmt.Fprintln(os.Stderr, "Error: --fix cannot be combined with --package-file or --simulate-remove")
+		os.Exit(2)
+	}
+
+	if stagedMode {
+		if packageFile != "" || packageName != "" {
+			fmt.Fprintln(os.Stderr, "Error: --staged cannot be combined with --package or --package-file")
+			os.Exit(2)
+		}
+		staged, err := loadStagedFiles(flag.Args())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(staged) == 0 {
+			os.Exit(0)
+		}
+		setOverlay(staged)
+	}
+
+	if packageFile != "" {
+		unpacked, err := loadPackageFile(packageFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading package: %v\n", err)
+			os.Exit(1)
+		}
+		setOverlay(unpacked)
+	}
+
+	if phase != phaseAuto && phase != phaseFirstBoot && phase != phaseSteady {
+		fmt.Fprintf(os.Stderr, "Error: unknown phase %q\n", phase)
+		os.Exit(2)
+	}
+	if auditUser != "" {
+		if err := setupUserMode(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if ostreeCompose {
+		setupOstreeCompose()
+	}
+	layoutOption, systemdOption = layout, targetSystemd
+	if err := detectRootSettings(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
 	}
 
 	exitCode := 0
+	if verifyConf && pkgDB == nil {
+		fmt.Fprintln(os.Stderr, "Error: --verify-conf needs a package database, but none was found")
+		exitCode = 1
+	}
+
+	if dbusService {
+		os.Exit(runDBusService())
+	}
+	if watchMode {
+		os.Exit(runWatch())
+	}
+	if haveCommand {
+		os.Exit(cmd.run(cmdArgs))
+	}
+	if transactionPre || transactionPost {
+		os.Exit(runTransactionHook())
+	}
+	if list, err := matrixRoots(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	} else if list != nil {
+		os.Exit(runMatrix(list))
+	}
+
+	sdNotify("READY=1\nSTATUS=Auditing tmpfiles.d configuration")
+	if err := runAudit(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if goldenFile != "" {
+		if err := checkGolden(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if baselineFile != "" {
+		if err := applyBaseline(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if resultsDir != "" {
+		if err := writeResultsDir(resultsDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing results: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if imageBuildHook {
+		os.Exit(finishImageBuildHook())
+	}
+	if annotatePR {
+		os.Exit(finishAnnotatePR())
+	}
+
+	writeFindings(os.Stdout)
+	if journalEnabled() {
+		if err := logToJournal(findings); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if oneshotService {
+		finishOneshotService()
+	}
+	if hasErrors() {
+		exitCode = 1
+	}
+	os.Exit(exitCode)
+}
+
+// layoutOption and systemdOption are --layout and --target-systemd as
+// given, before detection on the audited root replaced their defaults
+var (
+	layoutOption  string
+	systemdOption int
+)
+
+// detectRootSettings derives what depends on the audited root from the
+// options: the distribution profile, the /etc layout, the package
+// database, runtime trees and the systemd version. The site configuration
+// belongs to the auditing host and is the same for every root
+func detectRootSettings() error {
+	if err := selectProfile(); err != nil {
+		return err
+	}
+	layout = layoutOption
+	if layout == layoutAuto && profile.Layout != "" {
+		layout = profile.Layout
+	}
+	switch layout {
+	case layoutAuto:
+		layout = detectLayout()
+	case layoutFactory, layoutUsrEtc:
+	default:
+		return fmt.Errorf("unknown layout %q", layout)
+	}
+	// Looked up with /etc unmapped, so the root's own /etc is seen
+	etcInUsr = false
+	if ostreeCompose {
+		etcInUsr = etcOnlyInUsr()
+	}
+	pkgDB = detectPackageDB()
+	runtimeTrees = detectRuntimeTrees()
+	targetSystemd = systemdOption
+	if targetSystemd == 0 {
+		targetSystemd = detectSystemdVersion()
+	}
+	return nil
+}
+
+// runAudit performs one complete audit of the configured system, replacing
+// the findings of any previous run
+func runAudit() error {
+	findings = nil
+	parsedRules = nil
+	users, groups = nil, nil
+	declaredAccounts = nil
+	removedFiles = nil
+	packagePayload = nil
+	exemptedDirs = make(map[string]bool)
+	firstBoot = unbooted()
+	bootPopulators = findBootPopulators(effectiveRules())
+	firstBootPartitions = detectFirstBootPartitions()
+	composefs = detectComposefs()
 	linkedDirs := make(map[string]map[string]bool)
+	rulesProcessed := 0
+
+	if simulateRemove != "" {
+		if pkgDB == nil {
+			return fmt.Errorf("--simulate-remove needs a package database, but none was found")
+		}
+		if err := startRemovalSimulation(simulateRemove); err != nil {
+			return err
+		}
+	}
+
+	files, err := globPath("/usr/lib/tmpfiles.d/*.conf")
+	if err != nil {
+		return fmt.Errorf("finding files: %w", err)
+	}
+	// Fragments generated at runtime are audited too, but tagged as volatile
+	runtimeConfs, err := globPath(volatileConfDir + "/*.conf")
+	if err != nil {
+		return fmt.Errorf("finding files: %w", err)
+	}
+	files = append(files, runtimeConfs...)
+
+	if auditUser != "" {
+		// Per-user rules come from the user-tmpfiles.d directories only
+		files = files[:0]
+		for _, frag := range effectiveConfFiles() {
+			files = append(files, frag.Path)
+		}
+		fmt.Fprintf(out, "=== tmpfiles.d audit of user %s (%%h=%s) on %s ===\n", auditUser, specifiers['h'], describeRoot())
+		fmt.Fprintf(out, "Conf files: %d\n\n", len(files))
+	} else if packageName != "" {
+		if pkgDB == nil {
+			return fmt.Errorf("--package needs a package database, but none was found")
+		}
+		files, packagePayload, err = packageAuditSet(packageName)
+		if err != nil {
+			return err
+		}
+		factoryFiles := 0
+		for path := range packagePayload {
+			if !inFactoryRoot(path) || slices.Contains(factoryRoots(), path) {
+				continue
+			}
+			factoryFiles++
+			// Track every factory payload directory so files without any rule still show up
+			dir := filepath.Dir(path)
+			if _, ok := linkedDirs[dir]; !ok && !isBaseDir(dir) {
+				linkedDirs[dir] = make(map[string]bool)
+			}
+		}
+		fmt.Fprintf(out, "=== tmpfiles.d audit of %s package %s ===\n", pkgDB.name(), packageName)
+		fmt.Fprintf(out, "Conf files: %d, factory payload files: %d\n\n", len(files), factoryFiles)
+	} else if stagedMode {
+		// Only the staged fragments are under audit, checked against the root
+		files = files[:0]
+		packagePayload = make(map[string]bool)
+		fmt.Fprintf(out, "=== tmpfiles.d audit of staged fragments against %s ===\n", describeRoot())
+		for path := range overlayFiles {
+			packagePayload[path] = true
+			files = append(files, path)
+		}
+		sort.Strings(files)
+		for _, path := range files {
+			fmt.Fprintf(out, "  %s (installed as %s)\n", stagedSources[path], path)
+		}
+		fmt.Fprintln(out)
+	} else if packageFile != "" {
+		// Only the package's own fragments and payload are under audit; the root
+		// merely provides what is already installed
+		files = files[:0]
+		packagePayload = make(map[string]bool)
+		for path := range overlayFiles {
+			packagePayload[path] = true
+			if filepath.Dir(path) == "/usr/lib/tmpfiles.d" && strings.HasSuffix(path, ".conf") {
+				files = append(files, path)
+			}
+			if dir := filepath.Dir(path); inFactoryRoot(dir) && !isBaseDir(dir) {
+				if _, ok := linkedDirs[dir]; !ok {
+					linkedDirs[dir] = make(map[string]bool)
+				}
+			}
+		}
+		sort.Strings(files)
+		fmt.Fprintf(out, "=== tmpfiles.d audit of package file %s against %s ===\n", packageFile, describeRoot())
+		fmt.Fprintf(out, "Conf files: %d, payload files: %d\n\n", len(files), len(packagePayload))
+	}
 
 	for _, file := range files {
-		f, err := os.Open(file)
+		if verifyConf && pkgDB != nil && confScope(file) == "persistent" {
+			verifyConfFile(file)
+		}
+		f, err := openPath(file)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error opening file %s: %v\n", file, err)
-			exitCode = 1
+			addFinding(Finding{Category: catUnreadableConf, Severity: severityError, Path: file, Message: err.Error()})
 			continue
 		}
+		if confScope(file) == "volatile" {
+			fmt.Fprintf(out, "%s# %s (volatile, generated at runtime)%s\n", colorYellow, file, colorReset)
+		}
 		scanner := bufio.NewScanner(f)
+		lineNo := 0
 		for scanner.Scan() {
+			lineNo++
 			line := scanner.Text()
 			// Skip comments and empty lines
 			line = strings.TrimSpace(line)
 			if line == "" || strings.HasPrefix(line, "#") {
 				continue
 			}
+			line = expandSpecifiers(line)
+			rulesProcessed++
+			notifyProgress(file, rulesProcessed)
+			if r, err := parseRuleCached(line); err == nil {
+				r.ConfFile, r.Line = file, lineNo
+				parsedRules = append(parsedRules, r)
+				checkRule(r)
+			}
 			// Only handle symlink lines (L, L?, L+)
 			if strings.HasPrefix(line, "L") {
-				if err := processLine(line, linkedDirs); err != nil {
-					exitCode = 1
-				}
+				processLine(line, file, lineNo, linkedDirs)
 			}
 		}
 		f.Close()
 	}
 
+	checkVolatileSources(parsedRules)
+	if policyFile != "" {
+		checkPolicy(parsedRules)
+	}
+	if linkStyle() != "" {
+		checkLinkStyle(parsedRules)
+	}
+	if checkReadOnly {
+		checkReadOnlyMounts(parsedRules)
+	}
+	checkSchema(targetSystemd)
+	merged := effectiveRules()
+	checkConflicts(merged)
+	checkExclusions(merged)
+	checkLinkFlapping(merged)
+	checkDuplicates(merged)
+	checkOrdering(merged)
+	if crossValidate {
+		runCrossValidation(files)
+	}
+	if verifyBoot {
+		runBootVerification(parsedRules)
+	}
+	if checkNeedsUpdate {
+		runNeedsUpdateCheck(merged)
+	}
+	if checkSoftReboot {
+		runSoftRebootCheck(merged)
+	}
+	if checkUnitDirs {
+		runUnitDirCheck(merged)
+	}
+	if checkPkgPerms {
+		checkPackagePermissions(merged)
+	}
+	if securityScan {
+		runSecurityScan(parsedRules)
+	}
+	if len(allowedTargets) > 0 {
+		checkAllowedTargets(parsedRules)
+	}
+	if measuredConfig {
+		checkMeasuredConfig(parsedRules)
+	}
+	runPlugins(merged)
+	if fixMode {
+		runFixes(parsedRules)
+	}
+
 	ignoredFiles := loadIgnoreFiles()
 
-	if err := checkDirectoryCompleteness(linkedDirs, ignoredFiles); err != nil {
-		exitCode = 1
+	checkDirectoryCompleteness(linkedDirs, ignoredFiles)
+	if requireFactoryReset {
+		checkFactoryResetStory(merged, ignoredFiles)
+	}
+	if reverseCompleteness {
+		checkMaterialized(ignoredFiles)
+	}
+	if verbose {
+		printExemptedDirs()
 	}
 
 	printSummary(linkedDirs, ignoredFiles)
-	os.Exit(exitCode)
+	printRuntimeSummary()
+	printScopeSummary()
+	printRemovalSummary()
+	sdNotify(fmt.Sprintf("STATUS=Audited %d rules in %d files: %d finding(s)", rulesProcessed, len(files), len(findings)))
+	return nil
 }
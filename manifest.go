@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// factoryFilesInUse returns the regular files below the factory root that
+// L and C rules point at, descending into referenced directories
+func factoryFilesInUse(list []rule) []string {
+	seen := make(map[string]bool)
+	for _, r := range list {
+		if r.Type != "L" && r.Type != "C" {
+			continue
+		}
+		target := canonicalPath(resolveTargetPath(r.Path, symlinkTarget(r)))
+		if !inFactoryRoot(target) {
+			continue
+		}
+		fi, err := statPath(target)
+		if err != nil {
+			continue
+		}
+		if !fi.IsDir() {
+			seen[target] = true
+			continue
+		}
+		filepath.WalkDir(rootPath(target), func(host string, d fs.DirEntry, err error) error {
+			if err == nil && d.Type().IsRegular() {
+				seen[filepath.Join(target, strings.TrimPrefix(host, rootPath(target)))] = true
+			}
+			return nil
+		})
+	}
+	files := make([]string, 0, len(seen))
+	for path := range seen {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// quietAudit runs an audit without printing its report
+func quietAudit() error {
+	report := out
+	out = io.Discard
+	defer func() { out = report }()
+	return runAudit()
+}
+
+// runManifest records the SHA-256 digest of every factory file in use, in
+// sha256sum format, to the file named by the first argument or stdout
+func runManifest(args []string) int {
+	if err := quietAudit(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	w := io.Writer(os.Stdout)
+	if len(args) > 0 {
+		f, err := os.Create(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	for _, path := range factoryFilesInUse(parsedRules) {
+		fmt.Fprintf(w, "%s  %s\n", fileDigest(path, sha256.New()), path)
+	}
+	return 0
+}
+
+// readManifest parses a sha256sum-style manifest into digests by path
+func readManifest(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	digests := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		digest, file, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("%s: malformed line %q", path, scanner.Text())
+		}
+		digests[file] = digest
+	}
+	return digests, scanner.Err()
+}
+
+// runVerifyManifest compares the factory files in use with a recorded manifest
+func runVerifyManifest(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: verify-manifest MANIFEST")
+		return 2
+	}
+	recorded, err := readManifest(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := quietAudit(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	findings = nil
+
+	fmt.Fprintf(out, "=== Factory tree integrity against %s ===\n", args[0])
+	for _, path := range factoryFilesInUse(parsedRules) {
+		if _, ok := recorded[path]; !ok {
+			fmt.Fprintf(out, "%s⚠ Not in manifest: %s%s\n", colorYellow, path, colorReset)
+			addFinding(Finding{Category: catUnrecordedFactory, Severity: severityWarning, Path: path, Message: "factory file is not recorded in the manifest"})
+		}
+	}
+	paths := make([]string, 0, len(recorded))
+	for path := range recorded {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		switch digest := fileDigest(path, sha256.New()); {
+		case digest == "":
+			fmt.Fprintf(out, "%s✗ Missing: %s%s\n", colorRed, path, colorReset)
+			addFinding(Finding{Category: catManifestMissing, Severity: severityError, Path: path, Message: "recorded factory file is missing"})
+		case digest != recorded[path]:
+			fmt.Fprintf(out, "%s✗ Modified: %s%s\n", colorRed, path, colorReset)
+			addFinding(Finding{Category: catManifestMismatch, Severity: severityError, Path: path, Message: "SHA-256 digest differs from the manifest"})
+		default:
+			fmt.Fprintf(out, "%s✓ %s%s\n", colorGreen, path, colorReset)
+		}
+	}
+	writeFindings(os.Stdout)
+	if hasErrors() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// rootList collects repeated --root options; the first one is the root of
+// a normal audit, more than one select matrix mode
+type rootList []string
+
+func (r *rootList) String() string { return strings.Join(*r, ",") }
+
+func (r *rootList) Set(value string) error {
+	*r = append(*r, value)
+	if len(*r) == 1 {
+		rootDir = value
+	}
+	return nil
+}
+
+var (
+	// roots holds every --root given
+	roots rootList
+	// rootsFile lists further roots or images to audit, one per line
+	rootsFile string
+)
+
+// matrixReport combines the reports of all audited roots, keyed by root
+type matrixReport struct {
+	Tool    string            `json:"tool"`
+	Schema  int               `json:"schema"`
+	Created time.Time         `json:"created"`
+	Reports map[string]Report `json:"reports"`
+}
+
+// matrixRoots returns the roots of a matrix run, or nil for a normal audit
+func matrixRoots() ([]string, error) {
+	list := append([]string(nil), roots...)
+	if rootsFile != "" {
+		data, err := os.ReadFile(rootsFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				list = append(list, line)
+			}
+		}
+	}
+	if len(list) < 2 && rootsFile == "" {
+		return nil, nil
+	}
+	return list, nil
+}
+
+// runMatrix audits every root or image in turn and prints one combined
+// report; the parse cache is shared, so fragments common to several image
+// flavors are only parsed once
+func runMatrix(list []string) int {
+	combined := matrixReport{Tool: "tmpfiles-audit", Schema: reportSchema,
+		Created: time.Now().UTC().Truncate(time.Second), Reports: make(map[string]Report)}
+	failed := false
+	for _, root := range list {
+		r, cleanup, err := loadOperand(root)
+		cleanup()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error auditing %s: %v\n", root, err)
+			return 1
+		}
+		combined.Reports[root] = r
+
+		errors, warnings := 0, 0
+		for _, f := range r.Findings {
+			switch {
+			case blocksRun(f):
+				errors++
+			case f.Severity == severityWarning:
+				warnings++
+			}
+		}
+		switch {
+		case errors > 0:
+			failed = true
+			fmt.Fprintf(out, "%s✗ %s: %d rules, %d errors, %d warnings%s\n", colorRed, root, len(r.Rules), errors, warnings, colorReset)
+		case warnings > 0:
+			fmt.Fprintf(out, "%s⚠ %s: %d rules, %d warnings%s\n", colorYellow, root, len(r.Rules), warnings, colorReset)
+		default:
+			fmt.Fprintf(out, "%s✓ %s: %d rules, no findings%s\n", colorGreen, root, len(r.Rules), colorReset)
+		}
+	}
+	if outputFormat == "json" {
+		data, _ := json.MarshalIndent(combined, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// measuredConfig reports fs-verity and IMA protection of factory files behind /etc symlinks
+var measuredConfig bool
+
+// security.ima attribute types, from the kernel's enum evm_ima_xattr_type
+const (
+	imaDigestNG     = 0x04
+	imaDigSig       = 0x03
+	imaVerityDigSig = 0x06
+)
+
+// measurement describes how a file is protected against tampering
+type measurement struct {
+	verity bool
+	ima    string // "", "signature", "verity signature" or "digest"
+}
+
+// measureFile reports the fs-verity and IMA state of a file on the audited system
+func measureFile(path string) (measurement, error) {
+	var m measurement
+	resolved, err := resolveInRoot(path)
+	if err != nil {
+		return m, err
+	}
+	host := rootPath(resolved)
+	var stx unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, host, unix.AT_SYMLINK_NOFOLLOW, 0, &stx); err != nil {
+		return m, err
+	}
+	m.verity = stx.Attributes_mask&unix.STATX_ATTR_VERITY != 0 && stx.Attributes&unix.STATX_ATTR_VERITY != 0
+
+	buf := make([]byte, 4096)
+	n, err := unix.Lgetxattr(host, "security.ima", buf)
+	if err != nil && !errors.Is(err, unix.ENODATA) && !errors.Is(err, unix.ENOTSUP) {
+		return m, err
+	}
+	if err == nil && n > 0 {
+		switch buf[0] {
+		case imaDigSig:
+			m.ima = "signature"
+		case imaVerityDigSig:
+			m.ima = "verity signature"
+		case imaDigestNG:
+			m.ima = "digest"
+		}
+	}
+	return m, nil
+}
+
+// checkMeasuredConfig lists the fs-verity/IMA state of every factory file
+// that backs an L rule under /etc, flagging files that carry neither
+func checkMeasuredConfig(list []rule) {
+	fmt.Fprintln(out, "\n=== fs-verity/IMA status of factory files behind /etc ===")
+	origin := make(map[string]rule)
+	for _, r := range list {
+		if r.Type != "L" || !underPrefix(r.Path, "/etc") {
+			continue
+		}
+		target := canonicalPath(resolveTargetPath(r.Path, symlinkTarget(r)))
+		if !inFactoryRoot(target) {
+			continue
+		}
+		if _, ok := origin[target]; !ok {
+			origin[target] = r
+		}
+	}
+	targets := make([]string, 0, len(origin))
+	for target := range origin {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		r := origin[target]
+		fi, err := statPath(target)
+		if err != nil || fi.IsDir() {
+			continue
+		}
+		m, err := measureFile(target)
+		if err != nil {
+			fmt.Fprintf(out, "%s⚠ %s: cannot read measurement state: %v%s\n", colorYellow, target, err, colorReset)
+			continue
+		}
+		var state []string
+		if m.verity {
+			state = append(state, "fs-verity")
+		}
+		if m.ima != "" {
+			state = append(state, "IMA "+m.ima)
+		}
+		if len(state) > 0 {
+			fmt.Fprintf(out, "%s✓ %s: %s%s\n", colorGreen, target, strings.Join(state, ", "), colorReset)
+			continue
+		}
+		fmt.Fprintf(out, "%s⚠ %s: neither fs-verity nor IMA protected (backs %s)%s\n", colorYellow, target, r.Path, colorReset)
+		addFinding(Finding{Category: catUnmeasuredFactory, Severity: severityWarning, Path: r.Path, Target: target, ConfFile: r.ConfFile, Line: r.Line,
+			Message: "factory file has no fs-verity or IMA signature"})
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(out, "No /etc symlinks point into %s\n", strings.Join(factoryRoots(), " or "))
+	}
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// labelEscaper escapes Prometheus label values
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeMetric writes one metric family in the Prometheus text format
+func writeMetric(w io.Writer, name, kind, help string, samples ...string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s\n", name, s)
+	}
+}
+
+// writeMetrics renders the latest daemon run as Prometheus metrics
+func writeMetrics(w io.Writer, run daemonRun) {
+	bySeverity := make(map[string]int)
+	byCategory := make(map[string]int)
+	for _, f := range run.Report.Findings {
+		bySeverity[f.Severity]++
+		byCategory[f.Category]++
+	}
+	var samples []string
+	for _, severity := range []string{severityError, severityWarning, severityInfo} {
+		samples = append(samples, fmt.Sprintf(`{severity="%s"} %d`, severity, bySeverity[severity]))
+	}
+	writeMetric(w, "tmpfiles_audit_findings", "gauge", "Findings of the last audit by severity.", samples...)
+
+	// Every known category is exported so alerts can match on a zero value
+	samples = nil
+	for _, c := range checks {
+		samples = append(samples, fmt.Sprintf(`{category="%s",code="%s"} %d`, labelEscaper.Replace(c.Category), c.Code, byCategory[c.Category]))
+	}
+	writeMetric(w, "tmpfiles_audit_findings_by_category", "gauge", "Findings of the last audit by category.", samples...)
+
+	success := 1
+	if run.Err != nil {
+		success = 0
+	}
+	writeMetric(w, "tmpfiles_audit_last_run_success", "gauge", "Whether the last audit completed.", fmt.Sprintf(" %d", success))
+	writeMetric(w, "tmpfiles_audit_last_run_timestamp_seconds", "gauge", "Start time of the last audit.",
+		fmt.Sprintf(" %.3f", float64(run.Started.UnixMilli())/1000))
+	writeMetric(w, "tmpfiles_audit_last_run_duration_seconds", "gauge", "Duration of the last audit.",
+		fmt.Sprintf(" %.6f", run.Duration.Seconds()))
+	writeMetric(w, "tmpfiles_audit_rules_audited", "gauge", "Rules parsed by the last audit.", fmt.Sprintf(" %d", len(run.Report.Rules)))
+	writeMetric(w, "tmpfiles_audit_runs_total", "counter", "Audits attempted since the daemon started.", fmt.Sprintf(" %d", run.Audits))
+}
+
+// serveMetrics answers Prometheus scrapes
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetrics(w, daemon.snapshot())
+}
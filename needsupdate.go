@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// checkNeedsUpdate models ConditionNeedsUpdate= to tell whether update passes run on next boot
+var checkNeedsUpdate bool
+
+// unitDirs are the system unit directories, highest precedence first
+var unitDirs = []string{"/etc/systemd/system", "/run/systemd/system", "/usr/local/lib/systemd/system", "/usr/lib/systemd/system"}
+
+// updateState is the outcome of ConditionNeedsUpdate= for one directory
+type updateState struct {
+	Dir    string
+	Needed bool
+	Reason string
+}
+
+// needsUpdate evaluates ConditionNeedsUpdate=dir like systemd's
+// condition_test_needs_update(): the condition holds when /usr was modified
+// after dir/.updated was written by systemd-update-done
+func needsUpdate(dir string) updateState {
+	st := updateState{Dir: dir}
+	usr, err := statPath("/usr")
+	if err != nil {
+		st.Reason = "/usr does not exist"
+		return st
+	}
+	stamp := filepath.Join(dir, ".updated")
+	fi, err := statPath(stamp)
+	if err != nil {
+		st.Needed, st.Reason = true, stamp+" does not exist"
+		return st
+	}
+	updated := fi.ModTime()
+	// systemd-update-done records the exact /usr timestamp in the file,
+	// since the file's own mtime may be truncated by the file system
+	for _, line := range readLines(stamp) {
+		if v, ok := strings.CutPrefix(line, "TIMESTAMP_NSEC="); ok {
+			if nsec, err := strconv.ParseInt(v, 10, 64); err == nil {
+				updated = time.Unix(0, nsec)
+			}
+		}
+	}
+	if usr.ModTime().Sub(updated) > 0 {
+		st.Needed = true
+		st.Reason = fmt.Sprintf("/usr (%s) is newer than %s (%s)", usr.ModTime().Format(time.RFC3339), stamp, updated.Format(time.RFC3339))
+		return st
+	}
+	st.Reason = fmt.Sprintf("%s is up to date with /usr (%s)", stamp, usr.ModTime().Format(time.RFC3339))
+	return st
+}
+
+// gatedUnit is a service whose start depends on ConditionNeedsUpdate=
+type gatedUnit struct {
+	Name       string
+	Conditions []string
+	Tmpfiles   bool // its ExecStart= runs systemd-tmpfiles
+}
+
+// readUnitSettings returns the values of key in a unit file, in order
+func readUnitSettings(path, key string) []string {
+	var values []string
+	for _, line := range readLines(path) {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && strings.TrimSpace(k) == key {
+			values = append(values, strings.TrimSpace(v))
+		}
+	}
+	return values
+}
+
+// serviceUnits returns the service unit files of the audited system; a
+// unit name in a higher-precedence directory shadows the same name below
+func serviceUnits() []string {
+	seen := make(map[string]bool)
+	var units []string
+	for _, dir := range unitDirs {
+		matches, _ := globPath(dir + "/*.service")
+		for _, path := range matches {
+			if name := filepath.Base(path); !seen[name] {
+				seen[name] = true
+				units = append(units, path)
+			}
+		}
+	}
+	sort.Slice(units, func(i, j int) bool { return filepath.Base(units[i]) < filepath.Base(units[j]) })
+	return units
+}
+
+// tmpfilesUnits finds the services that run systemd-tmpfiles, telling the
+// ones gated on ConditionNeedsUpdate= apart from those that run every boot
+func tmpfilesUnits() (gated []gatedUnit, unconditional []string) {
+	for _, path := range serviceUnits() {
+		name := filepath.Base(path)
+		tmpfiles := false
+		for _, exec := range readUnitSettings(path, "ExecStart") {
+			if strings.Contains(exec, "systemd-tmpfiles") {
+				tmpfiles = true
+			}
+		}
+		conditions := readUnitSettings(path, "ConditionNeedsUpdate")
+		switch {
+		case len(conditions) > 0:
+			gated = append(gated, gatedUnit{Name: name, Conditions: conditions, Tmpfiles: tmpfiles})
+		case tmpfiles:
+			unconditional = append(unconditional, name)
+		}
+	}
+	return gated, unconditional
+}
+
+// unitRuns reports whether all ConditionNeedsUpdate= settings of a unit
+// hold; a "!" prefix negates the condition
+func unitRuns(u gatedUnit, states map[string]updateState) bool {
+	for _, c := range u.Conditions {
+		dir, negate := strings.CutPrefix(c, "!")
+		st, ok := states[dir]
+		if !ok {
+			st = needsUpdate(dir)
+			states[dir] = st
+		}
+		if st.Needed == negate {
+			return false
+		}
+	}
+	return true
+}
+
+// runNeedsUpdateCheck reports whether the update passes gated on
+// ConditionNeedsUpdate= run on next boot, and which stale symlinks no
+// systemd-tmpfiles invocation would replace
+func runNeedsUpdateCheck(list []rule) {
+	fmt.Fprintf(out, "\n=== ConditionNeedsUpdate ===\n")
+	states := make(map[string]updateState)
+	for _, dir := range []string{"/etc", "/var"} {
+		st := needsUpdate(dir)
+		states[dir] = st
+		if st.Needed {
+			fmt.Fprintf(out, "%s⚠ ConditionNeedsUpdate=%s holds: %s%s\n", colorYellow, dir, st.Reason, colorReset)
+		} else {
+			fmt.Fprintf(out, "%s✓ ConditionNeedsUpdate=%s does not hold: %s%s\n", colorGreen, dir, st.Reason, colorReset)
+		}
+	}
+
+	gated, unconditional := tmpfilesUnits()
+	updatePass := false
+	for _, u := range gated {
+		runs := unitRuns(u, states)
+		verdict := "skipped"
+		if runs {
+			verdict = "runs"
+		}
+		kind := ""
+		if u.Tmpfiles {
+			kind = " (systemd-tmpfiles update pass)"
+			updatePass = updatePass || runs
+		}
+		fmt.Fprintf(out, "   ⤷ %s%s %s on next boot (ConditionNeedsUpdate=%s)\n", u.Name, kind, verdict, strings.Join(u.Conditions, " "))
+	}
+	for _, name := range unconditional {
+		fmt.Fprintf(out, "   ⤷ %s runs systemd-tmpfiles on every boot\n", name)
+	}
+	// L+ replaces an existing path whenever any systemd-tmpfiles pass runs
+	replaced := updatePass || len(unconditional) > 0
+
+	stale := 0
+	for _, r := range list {
+		if r.Type != "L" || strings.ContainsAny(r.Path, "*?[%") {
+			continue
+		}
+		link, err := readlinkPath(r.Path)
+		target := symlinkTarget(r)
+		if err != nil || link == target {
+			continue
+		}
+		var why string
+		switch {
+		case !r.hasModifier('+'):
+			why = "L without + never replaces an existing path"
+		case !replaced:
+			why = "no systemd-tmpfiles pass runs on next boot"
+		default:
+			continue
+		}
+		stale++
+		fmt.Fprintf(out, "%s⚠ Stale link persists: %s -> %s instead of %s (%s:%d)%s\n", colorYellow, r.Path, link, target, r.ConfFile, r.Line, colorReset)
+		fmt.Fprintf(out, "   ⤷ %s\n", why)
+		addFinding(Finding{Category: catStaleLink, Severity: severityWarning, Path: r.Path, Target: target, ConfFile: r.ConfFile, Line: r.Line,
+			Message: fmt.Sprintf("link points to %s and is not replaced on next boot: %s", link, why)})
+	}
+	if stale == 0 {
+		fmt.Fprintf(out, "%s✓ No stale links would persist past next boot%s\n", colorGreen, colorReset)
+	}
+}
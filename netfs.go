@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+var (
+	// statRetries is how often a stat failing with a transient error is retried
+	statRetries = 3
+	// statBackoff is the delay before the first retry; it doubles on each one
+	statBackoff = 100 * time.Millisecond
+)
+
+// transientStatError reports whether err comes from flaky storage rather
+// than a missing file: I/O errors, stale NFS handles and dead FUSE daemons
+func transientStatError(err error) bool {
+	return errors.Is(err, syscall.EIO) || errors.Is(err, syscall.ESTALE) || errors.Is(err, syscall.ENOTCONN)
+}
+
+// retryStat runs stat, retrying with exponential backoff while it fails
+// with a transient error
+func retryStat(stat func() (os.FileInfo, error)) (os.FileInfo, error) {
+	fi, err := stat()
+	delay := statBackoff
+	for i := 0; i < statRetries && transientStatError(err); i++ {
+		time.Sleep(delay)
+		delay *= 2
+		fi, err = stat()
+	}
+	return fi, err
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// lastWatchdog and lastStatus rate-limit notifications during scans;
+	// audits and the daemon's watch loop notify from different goroutines
+	notifyMu     sync.Mutex
+	lastWatchdog time.Time
+	lastStatus   time.Time
+)
+
+// sdNotify sends a state string such as "READY=1" to the service manager;
+// it does nothing when not started by systemd with NOTIFY_SOCKET set
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		// Abstract namespace socket
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// watchdogInterval returns how often systemd expects a WATCHDOG=1 ping, or 0
+// if the watchdog is not enabled for this process
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// notifyWatchdog pings the watchdog at half its interval; safe to call often
+func notifyWatchdog() {
+	notifyMu.Lock()
+	defer notifyMu.Unlock()
+	now := time.Now()
+	if interval := watchdogInterval(); interval > 0 && now.Sub(lastWatchdog) >= interval/2 {
+		sdNotify("WATCHDOG=1")
+		lastWatchdog = now
+	}
+}
+
+// notifyProgress reports scan progress as STATUS= and keeps the watchdog fed;
+// called for every rule, so both are rate limited
+func notifyProgress(conf string, rules int) {
+	notifyWatchdog()
+	notifyMu.Lock()
+	defer notifyMu.Unlock()
+	now := time.Now()
+	if now.Sub(lastStatus) >= time.Second {
+		sdNotify(fmt.Sprintf("STATUS=Auditing %s (%d rules processed)", conf, rules))
+		lastStatus = now
+	}
+}
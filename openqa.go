@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// resultsDir receives one openQA test result per finding category
+var resultsDir string
+
+// openQADetail is one step of an openQA test result; Text names a file in
+// the results directory holding the step's log
+type openQADetail struct {
+	Result string `json:"result"`
+	Title  string `json:"title"`
+	Text   string `json:"text"`
+}
+
+// openQAResult is an openQA test module result as found in testresults/
+type openQAResult struct {
+	Result  string         `json:"result"`
+	Details []openQADetail `json:"details"`
+}
+
+// openQAStepResult maps a finding to an openQA step result: blocking
+// findings fail, warnings become soft-fails and informational ones pass
+func openQAStepResult(f Finding) string {
+	switch {
+	case blocksRun(f):
+		return "fail"
+	case f.Severity == severityWarning:
+		return "softfail"
+	}
+	return "ok"
+}
+
+// openQAModuleResult is the worst result of a module's steps
+func openQAModuleResult(details []openQADetail) string {
+	result := "ok"
+	for _, d := range details {
+		switch {
+		case d.Result == "fail":
+			return "fail"
+		case d.Result == "softfail":
+			result = "softfail"
+		}
+	}
+	return result
+}
+
+// writeResultsDir writes one test module per finding category as
+// result-tmpfiles_<category>.json, each finding being a step whose log is in
+// tmpfiles_<category>-<n>.txt, plus tmpfiles-audit.json with the full report;
+// os-autoinst tests can upload the directory with parse_extra_log or copy it
+// into testresults/
+func writeResultsDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	byCategory := make(map[string][]Finding)
+	for _, f := range findings {
+		byCategory[f.Category] = append(byCategory[f.Category], f)
+	}
+	categories := make([]string, 0, len(byCategory))
+	for c := range byCategory {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	for _, c := range categories {
+		module := "tmpfiles_" + strings.ReplaceAll(c, "-", "_")
+		var result openQAResult
+		for i, f := range byCategory[c] {
+			textFile := fmt.Sprintf("%s-%d.txt", module, i+1)
+			var text strings.Builder
+			fmt.Fprintf(&text, "%s: %s\n", f.Path, f.Message)
+			fmt.Fprintf(&text, "code: %s (see tmpfiles-audit --explain %s)\n", f.Code, f.Code)
+			if f.Target != "" {
+				fmt.Fprintf(&text, "target: %s\n", f.Target)
+			}
+			if f.ConfFile != "" {
+				fmt.Fprintf(&text, "rule: %s:%d\n", f.ConfFile, f.Line)
+			}
+			if f.Package != "" {
+				fmt.Fprintf(&text, "package: %s\n", f.Package)
+			}
+			if err := os.WriteFile(filepath.Join(dir, textFile), []byte(text.String()), 0644); err != nil {
+				return err
+			}
+			result.Details = append(result.Details, openQADetail{Result: openQAStepResult(f), Title: f.Path, Text: textFile})
+		}
+		result.Result = openQAModuleResult(result.Details)
+		data, _ := json.MarshalIndent(result, "", "  ")
+		if err := os.WriteFile(filepath.Join(dir, "result-"+module+".json"), append(data, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	return saveReport(filepath.Join(dir, imageBuildReportName), currentReport())
+}
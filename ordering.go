@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// removalTypes remove their path when run with --remove
+const removalTypes = "rR"
+
+// createsPath reports whether a rule creates its path, and whether as a directory
+func createsPath(r rule) (creates, dir bool) {
+	mode, ok := creationTypes[r.Type]
+	return ok, ok && mode == os.ModeDir
+}
+
+// implicitParent reports whether a directory rule declares what systemd
+// gives parent directories it creates on the fly anyway (0755 root:root),
+// so processing order cannot change the outcome
+func implicitParent(r rule) bool {
+	orDash := func(s string, defaults ...string) bool {
+		return s == "" || s == "-" || slices.Contains(defaults, s)
+	}
+	return orDash(r.Mode, "0755", "755") && orDash(r.User, "root", "0") && orDash(r.Group, "root", "0")
+}
+
+// checkOrdering warns about rules whose effect depends on the order they
+// are processed in: a parent directory declared after rules creating
+// entries below it, and removals preceding creations in the same subtree
+func checkOrdering(rules []rule) {
+	fmt.Fprintln(out, "\n=== Ordering-sensitive rules ===")
+	found := 0
+	report := func(later, earlier rule, message string) {
+		if !inAuditScope(later) && !inAuditScope(earlier) {
+			return
+		}
+		found++
+		fmt.Fprintf(out, "%s⚠ %s (%s:%d) %s%s\n   earlier: %s (%s:%d)\n", colorYellow, formatRule(later), later.ConfFile, later.Line, message, colorReset,
+			formatRule(earlier), earlier.ConfFile, earlier.Line)
+		addFinding(Finding{Category: catOrderSensitive, Severity: severityWarning, Path: later.Path, ConfFile: later.ConfFile, Line: later.Line,
+			Message: fmt.Sprintf("%s: %q at %s:%d", message, formatRule(earlier), earlier.ConfFile, earlier.Line)})
+	}
+
+	for j, r := range rules {
+		creates, dir := createsPath(r)
+		if dir && !implicitParent(r) {
+			for _, earlier := range rules[:j] {
+				if c, _ := createsPath(earlier); c && earlier.Path != r.Path && underPrefix(earlier.Path, r.Path) {
+					report(r, earlier, "declares a parent directory after a rule creating an entry below it")
+					break
+				}
+			}
+		}
+		if creates {
+			for _, earlier := range rules[:j] {
+				if strings.Contains(removalTypes, earlier.Type) && underPrefix(r.Path, earlier.Path) {
+					report(r, earlier, "creates an entry in a subtree an earlier rule removes")
+					break
+				}
+			}
+		}
+	}
+	if found == 0 {
+		fmt.Fprintf(out, "%s✓ No ordering-sensitive rules%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+// ostreeCompose runs the audit as an rpm-ostree treefile postprocess step
+var ostreeCompose bool
+
+// etcInUsr maps /etc to /usr/etc, where ostree trees keep the /etc that
+// is merged into every deployment
+var etcInUsr bool
+
+// setupOstreeCompose prepares auditing a tree under construction. The
+// compose only fails on unsatisfied factory links unless --fail-on says
+// otherwise, and since systemd-tmpfiles knows nothing of ostree, L and C
+// defaults stay in the factory root even though the tree has /usr/etc
+func setupOstreeCompose() {
+	if len(failOn) == 0 {
+		failOn = categoryList{catMissingTarget}
+	}
+	if layout == layoutAuto {
+		layout = layoutFactory
+	}
+}
+
+// etcOnlyInUsr reports whether the audited tree keeps /etc in /usr/etc
+// alone; scripts run chrooted see /usr/etc bound at /etc, while a committed
+// or checked out tree only has /usr/etc
+func etcOnlyInUsr() bool {
+	if _, err := statPath("/etc"); err == nil {
+		return false
+	}
+	fi, err := statPath(usrEtcDir)
+	return err == nil && fi.IsDir()
+}
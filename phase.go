@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Phases select which expectations an audit holds the system to
+const (
+	phaseAuto      = "auto"      // firstboot for systems that never booted
+	phaseFirstBoot = "firstboot" // /var content created at boot may be missing
+	phaseSteady    = "steady"    // everything rules create must be there
+)
+
+var (
+	// phase is the --phase option
+	phase = phaseAuto
+	// firstBoot is set while auditing in the firstboot phase
+	firstBoot bool
+	// bootPopulators are the C and d rules that populate /var at boot
+	bootPopulators []rule
+)
+
+// findBootPopulators returns the rules systemd-tmpfiles --boot uses to
+// populate /var: C rules copying in a path or a tree, and d, D, v, q and
+// Q rules creating a directory
+func findBootPopulators(list []rule) []rule {
+	var found []rule
+	for _, r := range list {
+		if strings.Contains("CdDvqQ", r.Type) && underPrefix(r.Path, "/var") && !strings.ContainsAny(r.Path, "*?[%") {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// populatedAtBoot returns the rule that creates a /var path at boot; a C
+// rule covers everything below it that its source has
+func populatedAtBoot(path string) (rule, bool) {
+	for _, r := range bootPopulators {
+		if r.Path == path {
+			return r, true
+		}
+		if r.Type == "C" && underPrefix(path, r.Path) {
+			if _, err := statPath(symlinkTarget(r) + strings.TrimPrefix(path, r.Path)); err == nil {
+				return r, true
+			}
+		}
+	}
+	return rule{}, false
+}
+
+// reportPopulatedAtBoot records a missing target that is expected to
+// appear at first boot, reporting whether it did
+func reportPopulatedAtBoot(path, target, conf string, lineNo int) bool {
+	r, ok := populatedAtBoot(target)
+	if !ok || !firstBoot {
+		return false
+	}
+	fmt.Fprintf(out, "  %s⤷ Target created at first boot by %s %s (%s:%d)%s\n", colorYellow, r.Type, r.Path, r.ConfFile, r.Line, colorReset)
+	addFinding(Finding{Category: catFirstBootPopulated, Severity: severityInfo, Path: path, Target: target, ConfFile: conf, Line: lineNo,
+		Message: fmt.Sprintf("target is created at first boot by %s %s (%s:%d)", r.Type, r.Path, r.ConfFile, r.Line)})
+	return true
+}
+
+// explainBootPopulator points out the rule that should have created a
+// target missing in the steady phase
+func explainBootPopulator(target string) {
+	if r, ok := populatedAtBoot(target); ok {
+		fmt.Fprintf(out, "    %s⤷ %s %s (%s:%d) should have created it at boot%s\n", colorYellow, r.Type, r.Path, r.ConfFile, r.Line, colorReset)
+	}
+}
@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// packageDB resolves file ownership against the installed package database
+type packageDB interface {
+	// name returns the package manager name for display
+	name() string
+	// owner returns the package owning path, or "" if no package owns it
+	owner(path string) string
+	// verify compares path against the checksum pkg recorded for it;
+	// known is false when the database holds no checksum for the file
+	verify(pkg, path string) (ok, known bool)
+	// files returns every path shipped by an installed package
+	files(pkg string) []string
+}
+
+// pkgDB is the package database detected at startup, nil if none was found
+var pkgDB packageDB
+
+// detectPackageDB picks the package manager whose database is present on this system
+func detectPackageDB() packageDB {
+	if _, err := os.Stat(rootPath("/var/lib/dpkg/status")); err == nil {
+		return &dpkgDB{infoDir: rootPath("/var/lib/dpkg/info")}
+	}
+	if fi, err := os.Stat(rootPath("/var/lib/pacman/local")); err == nil && fi.IsDir() {
+		return &pacmanDB{localDir: rootPath("/var/lib/pacman/local")}
+	}
+	return nil
+}
+
+// usrMergeAlias maps a path to its counterpart across the /usr merge
+// (/bin <-> /usr/bin etc.), since package file lists may use either form;
+// systems without the merge have no aliases
+func usrMergeAlias(path string) string {
+	if !profile.UsrMerged {
+		return ""
+	}
+	for _, d := range []string{"/bin/", "/sbin/", "/lib/", "/lib64/"} {
+		if strings.HasPrefix(path, d) {
+			return "/usr" + path
+		}
+		if strings.HasPrefix(path, "/usr"+d) {
+			return strings.TrimPrefix(path, "/usr")
+		}
+	}
+	return ""
+}
+
+// fileDigest returns the hex digest of a file's contents, or "" if it cannot be read
+func fileDigest(path string, h hash.Hash) string {
+	f, err := openPath(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// dpkgDB reads ownership from the per-package file lists in /var/lib/dpkg/info
+type dpkgDB struct {
+	infoDir string
+	owners  map[string]string
+}
+
+func (d *dpkgDB) name() string { return "dpkg" }
+
+// load builds the path -> package map from all *.list files on first use
+func (d *dpkgDB) load() {
+	if d.owners != nil {
+		return
+	}
+	d.owners = make(map[string]string)
+	lists, _ := filepath.Glob(filepath.Join(d.infoDir, "*.list"))
+	for _, list := range lists {
+		f, err := os.Open(list)
+		if err != nil {
+			continue
+		}
+		pkg := strings.TrimSuffix(filepath.Base(list), ".list")
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			path := strings.TrimSpace(scanner.Text())
+			if path == "" || path == "/." {
+				continue
+			}
+			d.owners[path] = pkg
+		}
+		f.Close()
+	}
+}
+
+func (d *dpkgDB) owner(path string) string {
+	d.load()
+	if pkg, ok := d.owners[path]; ok {
+		return pkg
+	}
+	if alias := usrMergeAlias(path); alias != "" {
+		return d.owners[alias]
+	}
+	return ""
+}
+
+func (d *dpkgDB) files(pkg string) []string {
+	lists, _ := filepath.Glob(filepath.Join(d.infoDir, pkg+".list"))
+	if len(lists) == 0 {
+		// Multi-arch packages name their lists pkg:arch.list
+		lists, _ = filepath.Glob(filepath.Join(d.infoDir, pkg+":*.list"))
+	}
+	var paths []string
+	for _, list := range lists {
+		f, err := os.Open(list)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			path := strings.TrimSpace(scanner.Text())
+			if path != "" && path != "/." {
+				paths = append(paths, path)
+			}
+		}
+		f.Close()
+	}
+	return paths
+}
+
+// verify looks path up in the package's md5sums file, which lists paths relative to /
+func (d *dpkgDB) verify(pkg, path string) (ok, known bool) {
+	f, err := os.Open(filepath.Join(d.infoDir, pkg+".md5sums"))
+	if err != nil {
+		return false, false
+	}
+	defer f.Close()
+
+	rel := strings.TrimPrefix(path, "/")
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[1] != rel {
+			continue
+		}
+		return fileDigest(path, md5.New()) == fields[0], true
+	}
+	return false, false
+}
+
+// pacmanDB reads ownership from the local pacman database, where each
+// installed package has a directory holding "desc" and "files" entries
+type pacmanDB struct {
+	localDir string
+	owners   map[string]string
+	pkgDirs  map[string]string
+}
+
+func (p *pacmanDB) name() string { return "pacman" }
+
+// readPacmanSection returns the lines of a %SECTION% block in a pacman db entry
+func readPacmanSection(file, section string) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	inSection := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "%") && strings.HasSuffix(line, "%") {
+			inSection = line == "%"+section+"%"
+			continue
+		}
+		if inSection && line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// load builds the path -> package map from every package's files entry on first use
+func (p *pacmanDB) load() {
+	if p.owners != nil {
+		return
+	}
+	p.owners = make(map[string]string)
+	p.pkgDirs = make(map[string]string)
+	entries, _ := os.ReadDir(p.localDir)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(p.localDir, entry.Name())
+		names := readPacmanSection(filepath.Join(dir, "desc"), "NAME")
+		if len(names) == 0 {
+			continue
+		}
+		p.pkgDirs[names[0]] = dir
+		for _, file := range readPacmanSection(filepath.Join(dir, "files"), "FILES") {
+			// pacman stores paths relative to / and marks directories with a trailing slash
+			p.owners["/"+strings.TrimSuffix(file, "/")] = names[0]
+		}
+	}
+}
+
+func (p *pacmanDB) owner(path string) string {
+	p.load()
+	if pkg, ok := p.owners[path]; ok {
+		return pkg
+	}
+	if alias := usrMergeAlias(path); alias != "" {
+		return p.owners[alias]
+	}
+	return ""
+}
+
+func (p *pacmanDB) files(pkg string) []string {
+	p.load()
+	dir, ok := p.pkgDirs[pkg]
+	if !ok {
+		return nil
+	}
+	var paths []string
+	for _, file := range readPacmanSection(filepath.Join(dir, "files"), "FILES") {
+		paths = append(paths, "/"+strings.TrimSuffix(file, "/"))
+	}
+	return paths
+}
+
+// verify looks path up in the package's gzip-compressed mtree, which records
+// a sha256digest keyword for every regular file
+func (p *pacmanDB) verify(pkg, path string) (ok, known bool) {
+	p.load()
+	f, err := os.Open(filepath.Join(p.pkgDirs[pkg], "mtree"))
+	if err != nil {
+		return false, false
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return false, false
+	}
+	defer zr.Close()
+
+	want := "." + path
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != want {
+			continue
+		}
+		for _, kw := range fields[1:] {
+			if digest, found := strings.CutPrefix(kw, "sha256digest="); found {
+				return fileDigest(path, sha256.New()) == digest, true
+			}
+		}
+		return false, false
+	}
+	return false, false
+}
+
+// verifyConfFile checks that a tmpfiles.d fragment is shipped by an installed
+// package and still matches the checksum the package recorded for it
+func verifyConfFile(file string) error {
+	pkg := pkgDB.owner(file)
+	if pkg == "" {
+		fmt.Fprintf(out, "%s✗ Conf file is not owned by any %s package: %s%s\n", colorRed, pkgDB.name(), file, colorReset)
+		addFinding(Finding{Category: catStrayConf, Severity: severityError, Path: file, Message: "conf file is not owned by any package"})
+		return fmt.Errorf("stray conf file: %s", file)
+	}
+	ok, known := pkgDB.verify(pkg, file)
+	switch {
+	case !known:
+		fmt.Fprintf(out, "%s⚠ Conf file %s (package %s) has no recorded checksum%s\n", colorYellow, file, pkg, colorReset)
+		addFinding(Finding{Category: catUnverifiedConf, Severity: severityWarning, Path: file, Package: pkg, Message: "package database has no checksum for conf file"})
+	case !ok:
+		fmt.Fprintf(out, "%s✗ Conf file %s differs from the version shipped by package %s%s\n", colorRed, file, pkg, colorReset)
+		addFinding(Finding{Category: catModifiedConf, Severity: severityError, Path: file, Package: pkg, Message: "conf file differs from the packaged version"})
+		return fmt.Errorf("locally modified conf file: %s", file)
+	default:
+		fmt.Fprintf(out, "%s✓ Conf file %s is owned by package %s and unmodified%s\n", colorGreen, file, pkg, colorReset)
+	}
+	return nil
+}
+
+// packageAuditSet extracts the tmpfiles.d fragments and regular files shipped
+// by a package, so an audit can be restricted to what that package installs
+func packageAuditSet(pkg string) (confs []string, payload map[string]bool, err error) {
+	paths := pkgDB.files(pkg)
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("package %s is not installed or ships no files", pkg)
+	}
+	payload = make(map[string]bool)
+	for _, path := range paths {
+		canonical := path
+		if !strings.HasPrefix(path, "/usr/") {
+			if alias := usrMergeAlias(path); alias != "" {
+				canonical = alias
+			}
+		}
+		if filepath.Dir(canonical) == "/usr/lib/tmpfiles.d" && strings.HasSuffix(canonical, ".conf") {
+			confs = append(confs, canonical)
+		}
+		if fi, err := statPath(canonical); err == nil && !fi.IsDir() {
+			payload[canonical] = true
+		}
+	}
+	return confs, payload, nil
+}
+
+// printOwnership annotates a path with its owning package and returns it;
+// missing marks paths that no longer exist, where an owner means the file was deleted
+func printOwnership(path, indent string, missing bool) string {
+	if pkgDB == nil {
+		return ""
+	}
+	pkg := pkgDB.owner(path)
+	switch {
+	case missing && removedFiles[path]:
+		fmt.Fprintf(out, "%s%s⤷ %s would be removed along with %s package %s%s\n", indent, colorYellow, path, pkgDB.name(), pkg, colorReset)
+	case pkg == "":
+		fmt.Fprintf(out, "%s%s⤷ %s is not owned by any %s package%s\n", indent, colorYellow, path, pkgDB.name(), colorReset)
+	case missing:
+		fmt.Fprintf(out, "%s%s⤷ %s belongs to %s package %s, which is installed but the file was deleted%s\n", indent, colorYellow, path, pkgDB.name(), pkg, colorReset)
+	default:
+		fmt.Fprintf(out, "%s%s⤷ %s is owned by %s package %s%s\n", indent, colorYellow, path, pkgDB.name(), pkg, colorReset)
+	}
+	return pkg
+}
@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// loadPackageFile unpacks a .deb or .rpm into memory, returning its regular
+// files keyed by absolute path with pre-usrmerge paths folded into /usr
+func loadPackageFile(path string) (map[string][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(8)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	switch {
+	case string(magic) == "!<arch>\n":
+		return readDeb(br)
+	case bytes.Equal(magic[:4], []byte{0xed, 0xab, 0xee, 0xdb}):
+		return readRPM(br)
+	}
+	return nil, fmt.Errorf("%s: not a .deb or .rpm package", path)
+}
+
+// packagePath normalizes an archive member name to an absolute, usr-merged path
+func packagePath(name string) string {
+	path := filepath.Clean("/" + strings.TrimPrefix(name, "./"))
+	if alias := usrMergeAlias(path); alias != "" && !strings.HasPrefix(path, "/usr/") {
+		return alias
+	}
+	return path
+}
+
+// decompress wraps r according to the compression named by an archive suffix
+// or rpm payload compressor; xz and zstd are delegated to the external tools
+func decompress(r io.Reader, kind string) (io.Reader, error) {
+	switch kind {
+	case "", "none", "tar":
+		return r, nil
+	case "gz", "gzip":
+		return gzip.NewReader(r)
+	case "bz2", "bzip2":
+		return bzip2.NewReader(r), nil
+	case "xz", "lzma", "zst", "zstd":
+		tool := map[string]string{"xz": "xz", "lzma": "xz", "zst": "zstd", "zstd": "zstd"}[kind]
+		cmd := exec.Command(tool, "-dc")
+		cmd.Stdin = r
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("%s -dc: %w", tool, err)
+		}
+		return bytes.NewReader(out), nil
+	}
+	return nil, fmt.Errorf("unsupported compression %q", kind)
+}
+
+// readDeb walks the ar members of a .deb and unpacks its data.tar
+func readDeb(r io.Reader) (map[string][]byte, error) {
+	if _, err := io.CopyN(io.Discard, r, 8); err != nil {
+		return nil, err
+	}
+	hdr := make([]byte, 60)
+	for {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return nil, errors.New("deb: no data.tar member found")
+		}
+		name := strings.TrimSuffix(strings.TrimSpace(string(hdr[0:16])), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("deb: bad member size for %s", name)
+		}
+		member := io.LimitReader(r, size)
+		if strings.HasPrefix(name, "data.tar") {
+			payload, err := decompress(member, strings.TrimPrefix(strings.TrimPrefix(name, "data.tar"), "."))
+			if err != nil {
+				return nil, fmt.Errorf("deb: %w", err)
+			}
+			return readTar(payload)
+		}
+		// ar members are padded to an even size
+		if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readTar collects the regular files and symlinks of a tar stream; like
+// readCpio it keeps a symlink's target as its content
+func readTar(r io.Reader) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tar: %w", err)
+		}
+		if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeSymlink {
+			continue
+		}
+		if h.Typeflag == tar.TypeSymlink {
+			files[packagePath(h.Name)] = []byte(h.Linkname)
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("tar: %w", err)
+		}
+		files[packagePath(h.Name)] = data
+	}
+}
+
+// rpmTagPayloadCompressor is the header tag naming the payload compression
+const rpmTagPayloadCompressor = 1125
+
+// Limits on untrusted sizes in package files; the rpm ones match rpm's own
+// HEADER_TAGS_MAX and HEADER_DATA_MAX
+const (
+	rpmMaxTags    = 0xffff
+	rpmMaxData    = 256 << 20
+	cpioMaxName   = 4096
+	cpioMaxMember = 1 << 30
+)
+
+// readExactly reads n bytes, growing the buffer as data arrives so that a
+// size claimed by a truncated file is never allocated up front
+func readExactly(r io.Reader, n int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, n))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
+
+// readRPMHeader parses one rpm header structure and returns its string tags
+func readRPMHeader(r io.Reader) (map[int]string, int64, error) {
+	var intro struct {
+		Magic    [3]byte
+		Version  byte
+		Reserved [4]byte
+		Count    uint32
+		Size     uint32
+	}
+	if err := binary.Read(r, binary.BigEndian, &intro); err != nil {
+		return nil, 0, err
+	}
+	if intro.Magic != [3]byte{0x8e, 0xad, 0xe8} {
+		return nil, 0, errors.New("rpm: bad header magic")
+	}
+	if intro.Count > rpmMaxTags || intro.Size > rpmMaxData {
+		return nil, 0, fmt.Errorf("rpm: header too large (%d tags, %d bytes)", intro.Count, intro.Size)
+	}
+	raw, err := readExactly(r, 16*int64(intro.Count))
+	if err != nil {
+		return nil, 0, err
+	}
+	index := make([]struct{ Tag, Type, Offset, Count uint32 }, intro.Count)
+	if err := binary.Read(bytes.NewReader(raw), binary.BigEndian, index); err != nil {
+		return nil, 0, err
+	}
+	store, err := readExactly(r, int64(intro.Size))
+	if err != nil {
+		return nil, 0, err
+	}
+	tags := make(map[int]string)
+	for _, e := range index {
+		// Only RPM_STRING_TYPE (6) entries are needed
+		if e.Type != 6 || int(e.Offset) >= len(store) {
+			continue
+		}
+		s := store[e.Offset:]
+		if i := bytes.IndexByte(s, 0); i >= 0 {
+			s = s[:i]
+		}
+		tags[int(e.Tag)] = string(s)
+	}
+	return tags, 16 + 16*int64(intro.Count) + int64(intro.Size), nil
+}
+
+// readRPM skips the lead and signature header, then unpacks the cpio payload
+func readRPM(r io.Reader) (map[string][]byte, error) {
+	if _, err := io.CopyN(io.Discard, r, 96); err != nil {
+		return nil, fmt.Errorf("rpm: %w", err)
+	}
+	_, sigLen, err := readRPMHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("rpm signature: %w", err)
+	}
+	// The signature header is padded to a multiple of 8 bytes
+	if pad := (8 - sigLen%8) % 8; pad > 0 {
+		if _, err := io.CopyN(io.Discard, r, pad); err != nil {
+			return nil, err
+		}
+	}
+	tags, _, err := readRPMHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("rpm header: %w", err)
+	}
+	compressor := tags[rpmTagPayloadCompressor]
+	if compressor == "" {
+		compressor = "gzip"
+	}
+	payload, err := decompress(r, compressor)
+	if err != nil {
+		return nil, fmt.Errorf("rpm: %w", err)
+	}
+	return readCpio(payload)
+}
+
+// readCpio collects the regular files and symlinks of a "newc" cpio archive
+func readCpio(r io.Reader) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	hdr := make([]byte, 110)
+	for {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return nil, fmt.Errorf("cpio: %w", err)
+		}
+		if string(hdr[:6]) != "070701" && string(hdr[:6]) != "070702" {
+			return nil, errors.New("cpio: unsupported archive format")
+		}
+		var fields [13]int64
+		for i := range fields {
+			v, err := strconv.ParseInt(string(hdr[6+8*i:14+8*i]), 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cpio: bad header field: %w", err)
+			}
+			fields[i] = v
+		}
+		mode, size, nameSize := fields[1], fields[6], fields[11]
+		if nameSize < 1 || nameSize > cpioMaxName || size > cpioMaxMember {
+			return nil, fmt.Errorf("cpio: implausible sizes (name %d, data %d)", nameSize, size)
+		}
+		name, err := readExactly(r, nameSize+(4-(110+nameSize)%4)%4)
+		if err != nil {
+			return nil, fmt.Errorf("cpio: %w", err)
+		}
+		member := strings.TrimRight(string(name[:nameSize]), "\x00")
+		if member == "TRAILER!!!" {
+			return files, nil
+		}
+		data, err := readExactly(r, size+(4-size%4)%4)
+		if err != nil {
+			return nil, fmt.Errorf("cpio: %w", err)
+		}
+		if kind := mode & 0170000; kind == 0100000 || kind == 0120000 {
+			files[packagePath(member)] = data[:size]
+		}
+	}
+}
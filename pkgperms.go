@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// checkPkgPerms compares rule modes and owners with those the package manager enforces
+var checkPkgPerms bool
+
+// rpmDBDirs hold the rpm database on the usual layouts
+var rpmDBDirs = []string{"/usr/lib/sysimage/rpm", "/var/lib/rpm"}
+
+// permOverride is the mode and ownership a package manager gives a path
+// whenever the owning package is installed or updated
+type permOverride struct {
+	User   string
+	Group  string
+	Mode   uint32
+	Source string // "dpkg-statoverride" or "rpm package NAME"
+}
+
+// loadStatOverrides parses dpkg's statoverride database, whose lines read
+// "user group mode path"
+func loadStatOverrides() map[string]permOverride {
+	overrides := make(map[string]permOverride)
+	for _, line := range readLines("/var/lib/dpkg/statoverride") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+		mode, err := strconv.ParseUint(fields[2], 8, 32)
+		if err != nil {
+			continue
+		}
+		overrides[fields[3]] = permOverride{User: fields[0], Group: fields[1], Mode: uint32(mode), Source: "dpkg-statoverride"}
+	}
+	return overrides
+}
+
+// rpmAvailable reports whether the audited system has an rpm database the
+// rpm binary can query
+func rpmAvailable() bool {
+	if _, err := exec.LookPath("rpm"); err != nil {
+		return false
+	}
+	for _, dir := range rpmDBDirs {
+		if fi, err := statPath(dir); err == nil && fi.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// rpmBatch is how many arguments one rpm query takes
+const rpmBatch = 256
+
+// rpmQuery runs an rpm query over items in batches and returns the lines
+// printed; rpm fails when some item is unknown but still prints the rest
+func rpmQuery(args, items []string) []string {
+	if rootDir != "" {
+		args = append([]string{"--root=" + rootDir}, args...)
+	}
+	var lines []string
+	for start := 0; start < len(items); start += rpmBatch {
+		batch := items[start:min(start+rpmBatch, len(items))]
+		output, _ := exec.Command("rpm", slices.Concat(args, batch)...).Output()
+		scanner := bufio.NewScanner(bytes.NewReader(output))
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return lines
+}
+
+// rpmAttrs returns the mode and ownership rpm recorded for those of paths
+// that packages ship, which %attr sets and which rpm restores on every
+// update of the package. rpm is asked once for the owning packages and
+// once for their file lists, however many paths there are
+func rpmAttrs(paths []string) map[string]permOverride {
+	attrs := make(map[string]permOverride)
+	if len(paths) == 0 {
+		return attrs
+	}
+	var packages []string
+	seen := make(map[string]bool)
+	for _, line := range rpmQuery([]string{"-qf", "--qf", `pkg\t%{NAME}\n`}, paths) {
+		// Unowned paths print a message instead of the format
+		name, ok := strings.CutPrefix(line, "pkg\t")
+		if ok && !seen[name] {
+			seen[name] = true
+			packages = append(packages, name)
+		}
+	}
+	wanted := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		wanted[path] = true
+	}
+	for _, line := range rpmQuery([]string{"-q", "--qf", `[%{FILENAMES}\t%{FILEMODES:octal}\t%{FILEUSERNAME}\t%{FILEGROUPNAME}\t%{NAME}\n]`}, packages) {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 || !wanted[fields[0]] {
+			continue
+		}
+		mode, err := strconv.ParseUint(fields[1], 8, 32)
+		if err != nil {
+			continue
+		}
+		attrs[fields[0]] = permOverride{User: fields[2], Group: fields[3], Mode: uint32(mode) & 07777, Source: "rpm package " + fields[4]}
+	}
+	return attrs
+}
+
+// permDisagreements lists how a rule's declared mode and owner differ from
+// a package manager's; fields the rule leaves unset or applies on creation
+// only never touch an existing path and cannot disagree
+func permDisagreements(r rule, o permOverride) []string {
+	var diffs []string
+	if r.Mode != "" && r.Mode != "-" && !strings.ContainsAny(r.Mode, "~:") {
+		if m, err := strconv.ParseUint(r.Mode, 8, 32); err == nil && uint32(m) != o.Mode {
+			diffs = append(diffs, fmt.Sprintf("mode %04o vs %04o", m, o.Mode))
+		}
+	}
+	if r.User != "" && r.User != "-" && !strings.HasPrefix(r.User, ":") && !sameAccount(r.User, o.User, lookupUser) {
+		diffs = append(diffs, fmt.Sprintf("user %s vs %s", r.User, o.User))
+	}
+	if r.Group != "" && r.Group != "-" && !strings.HasPrefix(r.Group, ":") && !sameAccount(r.Group, o.Group, lookupGroup) {
+		diffs = append(diffs, fmt.Sprintf("group %s vs %s", r.Group, o.Group))
+	}
+	return diffs
+}
+
+// checkPackagePermissions reports rules whose mode or owner disagrees with
+// dpkg-statoverride or rpm's recorded file attributes: systemd-tmpfiles
+// applies the rule at boot, the package manager its own on the next update,
+// so the permissions flip back and forth
+func checkPackagePermissions(list []rule) {
+	fmt.Fprintln(out, "\n=== Package manager permissions ===")
+	statOverrides := loadStatOverrides()
+	useRPM := rpmAvailable()
+	if len(statOverrides) == 0 && !useRPM {
+		fmt.Fprintf(out, "%s✓ No dpkg-statoverride entries or rpm database to compare with%s\n", colorGreen, colorReset)
+		return
+	}
+	type ruleCandidates struct {
+		r     rule
+		paths []string
+	}
+	var checked []ruleCandidates
+	var rpmPaths []string
+	for _, r := range list {
+		if !strings.Contains("dDevqQfFpcbzZ", r.Type) || strings.Contains(r.Path, "%") || !inAuditScope(r) {
+			continue
+		}
+		var candidates []string
+		if strings.ContainsAny(r.Path, "*?[") {
+			for path := range statOverrides {
+				if ok, _ := filepath.Match(r.Path, path); ok {
+					candidates = append(candidates, path)
+				}
+			}
+			if useRPM {
+				candidates = append(candidates, rulePaths(r)...)
+			}
+		} else {
+			candidates = []string{r.Path}
+		}
+		checked = append(checked, ruleCandidates{r, candidates})
+		if useRPM {
+			rpmPaths = append(rpmPaths, candidates...)
+		}
+	}
+	var rpmOverrides map[string]permOverride
+	if useRPM {
+		slices.Sort(rpmPaths)
+		rpmOverrides = rpmAttrs(slices.Compact(rpmPaths))
+	}
+
+	flips := 0
+	for _, c := range checked {
+		r := c.r
+		seen := make(map[string]bool)
+		for _, path := range c.paths {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			o, ok := statOverrides[path]
+			if !ok {
+				o, ok = rpmOverrides[path]
+			}
+			if !ok {
+				continue
+			}
+			diffs := permDisagreements(r, o)
+			if len(diffs) == 0 {
+				continue
+			}
+			flips++
+			fmt.Fprintf(out, "%s⚠ %s flips between %s and %s (%s:%d)%s\n", colorYellow, path, r.Type+" rule", o.Source, r.ConfFile, r.Line, colorReset)
+			fmt.Fprintf(out, "   ⤷ rule vs package manager: %s\n", strings.Join(diffs, ", "))
+			addFinding(Finding{Category: catPermissionFlip, Severity: severityWarning, Path: path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: fmt.Sprintf("rule disagrees with %s (%s), so permissions change between boots and package updates", o.Source, strings.Join(diffs, ", "))})
+		}
+	}
+	if flips == 0 {
+		fmt.Fprintf(out, "%s✓ Rules agree with the package manager's permissions%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultPluginDirs are searched for check plugins; a plugin in an earlier
+// directory masks one of the same name in a later one
+var defaultPluginDirs = []string{"/etc/tmpfiles-audit/plugins.d", "/usr/lib/tmpfiles-audit/plugins.d"}
+
+var (
+	pluginDir     string // --plugin-dir, replaces defaultPluginDirs
+	noPlugins     bool   // --no-plugins
+	pluginTimeout = 60 * time.Second
+)
+
+// pluginInputVersion is the version of the plugin contract
+const pluginInputVersion = 1
+
+// pluginInput is written to a plugin as JSON: executables read it on
+// stdin, Go plugins get it as the argument of their Check function
+type pluginInput struct {
+	Version int    `json:"version"`
+	Root    string `json:"root"` // audited root, "/" for the running system
+	Rules   []rule `json:"rules"`
+}
+
+// pluginOutput is what a plugin returns as JSON: executables on stdout,
+// Go plugins from Check. Findings take the fields of the report's findings;
+// code, runtime and scope are filled in by the audit
+type pluginOutput struct {
+	Findings []Finding `json:"findings"`
+}
+
+// checkPlugin is an executable or Go plugin found in a plugin directory
+type checkPlugin struct {
+	Name string
+	Path string
+}
+
+// discoverPlugins lists the plugins of the plugin directories by name.
+// Files that others can write to are refused, as the audit would run
+// whatever they were replaced with
+func discoverPlugins() ([]checkPlugin, []string) {
+	dirs := defaultPluginDirs
+	if pluginDir != "" {
+		dirs = []string{pluginDir}
+	}
+	var plugins []checkPlugin
+	var refused []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if seen[name] || strings.HasPrefix(name, ".") {
+				continue
+			}
+			seen[name] = true
+			path := filepath.Join(dir, name)
+			fi, err := os.Stat(path)
+			if err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+			if fi.Mode().Perm()&0o022 != 0 {
+				refused = append(refused, path)
+				continue
+			}
+			if filepath.Ext(name) != ".so" && fi.Mode().Perm()&0o111 == 0 {
+				continue
+			}
+			plugins = append(plugins, checkPlugin{Name: strings.TrimSuffix(name, ".so"), Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, refused
+}
+
+// runExecPlugin runs an executable plugin with the input on stdin and
+// returns its stdout
+func runExecPlugin(path string, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("timed out after %s", pluginTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// pluginFindings decodes and validates a plugin's output; findings without
+// a category are filed under the plugin's name
+func pluginFindings(name string, output []byte) ([]Finding, error) {
+	var result pluginOutput
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	for i := range result.Findings {
+		f := &result.Findings[i]
+		switch f.Severity {
+		case severityError, severityWarning, severityInfo:
+		default:
+			return nil, fmt.Errorf("finding %d: invalid severity %q", i+1, f.Severity)
+		}
+		if f.Message == "" {
+			return nil, fmt.Errorf("finding %d: no message", i+1)
+		}
+		if f.Category == "" {
+			f.Category = name
+		}
+		f.Code, f.Runtime, f.Scope = "", "", ""
+	}
+	return result.Findings, nil
+}
+
+// runPlugins feeds the effective rules to every check plugin and records
+// the findings they return; a plugin that fails is a finding itself.
+// Plugins inherit the audit's sandbox, so in plain audits they cannot
+// write either
+func runPlugins(rules []rule) {
+	if noPlugins {
+		return
+	}
+	plugins, refused := discoverPlugins()
+	if len(plugins) == 0 && len(refused) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n=== Plugin checks ===")
+	for _, path := range refused {
+		fmt.Fprintf(out, "%s✗ Refusing plugin %s: writable by group or others%s\n", colorRed, path, colorReset)
+		addFinding(Finding{Category: catPluginFailed, Severity: severityError, Path: path, Message: "plugin is writable by group or others and was not run"})
+	}
+	root := rootDir
+	if root == "" {
+		root = "/"
+	}
+	input, _ := json.Marshal(pluginInput{Version: pluginInputVersion, Root: root, Rules: rules})
+	for _, p := range plugins {
+		var output []byte
+		var err error
+		if filepath.Ext(p.Path) == ".so" {
+			output, err = runGoPlugin(p.Path, input)
+		} else {
+			output, err = runExecPlugin(p.Path, input)
+		}
+		var found []Finding
+		if err == nil {
+			found, err = pluginFindings(p.Name, output)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "%s✗ Plugin %s failed: %v%s\n", colorRed, p.Name, err, colorReset)
+			addFinding(Finding{Category: catPluginFailed, Severity: severityError, Path: p.Path, Message: fmt.Sprintf("plugin %s failed: %v", p.Name, err)})
+			continue
+		}
+		if len(found) == 0 {
+			fmt.Fprintf(out, "%s✓ %s: no findings%s\n", colorGreen, p.Name, colorReset)
+			continue
+		}
+		fmt.Fprintf(out, "%s⚠ %s: %d finding(s)%s\n", colorYellow, p.Name, len(found), colorReset)
+		for _, f := range found {
+			fmt.Fprintf(out, "   ⤷ [%s] %s: %s\n", f.Severity, f.Path, f.Message)
+			addFinding(f)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// policyFile names the site policy evaluated during the audit
+var policyFile string
+
+// requiredRule is a rule that must be part of the audited config; an empty
+// type or argument matches any
+type requiredRule struct {
+	Type     string `json:"type,omitempty"`
+	Path     string `json:"path"`
+	Argument string `json:"argument,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// forbiddenPrefix is a subtree no rule may touch; types restricts it to
+// some rule types
+type forbiddenPrefix struct {
+	Prefix string `json:"prefix"`
+	Types  string `json:"types,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// mandatedMode is the mode rules for paths matching a glob must declare
+type mandatedMode struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+}
+
+// policy is a declarative set of site constraints on the tmpfiles.d config
+type policy struct {
+	RequiredRules     []requiredRule    `json:"required_rules,omitempty"`
+	ForbiddenPrefixes []forbiddenPrefix `json:"forbidden_prefixes,omitempty"`
+	Modes             []mandatedMode    `json:"modes,omitempty"`
+	LinkStyle         string            `json:"link_style,omitempty"` // "absolute" or "relative" L rule targets
+	ResetWaivers      []resetWaiver     `json:"factory_reset_waivers,omitempty"`
+}
+
+// sitePolicy holds the loaded policy
+var sitePolicy policy
+
+// loadPolicy reads and validates the --policy file
+func loadPolicy() error {
+	data, err := os.ReadFile(policyFile)
+	if err != nil {
+		return err
+	}
+	var p policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("%s: %w", policyFile, err)
+	}
+	for _, m := range p.Modes {
+		if _, err := filepath.Match(m.Path, "/"); err != nil {
+			return fmt.Errorf("%s: mode for %s: %w", policyFile, m.Path, err)
+		}
+	}
+	for _, w := range p.ResetWaivers {
+		if _, err := filepath.Match(w.Path, "/"); err != nil {
+			return fmt.Errorf("%s: factory reset waiver for %s: %w", policyFile, w.Path, err)
+		}
+		if w.Reason == "" {
+			return fmt.Errorf("%s: factory reset waiver for %s has no reason", policyFile, w.Path)
+		}
+	}
+	if !validLinkStyle(p.LinkStyle) {
+		return fmt.Errorf("%s: unknown link style %q", policyFile, p.LinkStyle)
+	}
+	sitePolicy = p
+	return nil
+}
+
+// withReason appends a policy entry's reason to a message
+func withReason(message, reason string) string {
+	if reason == "" {
+		return message
+	}
+	return message + " (" + reason + ")"
+}
+
+// modeTypes are the rule types whose mode field systemd-tmpfiles applies
+const modeTypes = "fFdDevqQpcbCzZ"
+
+// checkPolicy evaluates the site policy against the rules that were read
+func checkPolicy(rules []rule) {
+	fmt.Fprintf(out, "\n=== Site policy %s ===\n", policyFile)
+	violations := 0
+	violation := func(f Finding) {
+		violations++
+		fmt.Fprintf(out, "%s✗ %s: %s%s\n", colorRed, f.Path, f.Message, colorReset)
+		addFinding(f)
+	}
+
+	for _, req := range sitePolicy.RequiredRules {
+		found := false
+		for _, r := range rules {
+			if r.Path == req.Path && (req.Type == "" || r.Type == req.Type) && (req.Argument == "" || r.Argument == req.Argument) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			want := strings.TrimSpace(strings.Join([]string{req.Type, req.Path, req.Argument}, " "))
+			violation(Finding{Category: catPolicyMissingRule, Severity: severityError, Path: req.Path, Target: req.Argument,
+				Message: withReason("required rule "+want+" is missing", req.Reason)})
+		}
+	}
+
+	for _, r := range rules {
+		for _, fp := range sitePolicy.ForbiddenPrefixes {
+			if underPrefix(r.Path, filepath.Clean(fp.Prefix)) && (fp.Types == "" || strings.Contains(fp.Types, r.Type)) {
+				violation(Finding{Category: catPolicyForbiddenPath, Severity: severityError, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+					Message: withReason(fmt.Sprintf("%s rule below forbidden prefix %s", r.Type, fp.Prefix), fp.Reason)})
+				break
+			}
+		}
+		if !strings.Contains(modeTypes, r.Type) {
+			continue
+		}
+		for _, m := range sitePolicy.Modes {
+			if ok, _ := filepath.Match(m.Path, r.Path); !ok {
+				continue
+			}
+			if strings.TrimLeft(r.Mode, "0") != strings.TrimLeft(m.Mode, "0") {
+				declared := r.Mode
+				if declared == "" || declared == "-" {
+					declared = "no mode"
+				}
+				violation(Finding{Category: catPolicyMode, Severity: severityError, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+					Message: fmt.Sprintf("rule declares %s, policy mandates %s for %s", declared, m.Mode, m.Path)})
+			}
+			break
+		}
+	}
+	if violations == 0 {
+		fmt.Fprintf(out, "%s✓ The config complies with the site policy%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// prefixDepth is the number of path components prefix-map groups rules by
+var prefixDepth = 3
+
+// prefixMapFlags registers the options of prefix-map
+func prefixMapFlags(fs *flag.FlagSet) {
+	fs.IntVar(&prefixDepth, "depth", prefixDepth, "number of leading path components that make up a prefix")
+}
+
+// prefixClaimant is a package, or an unowned fragment, with rules below a prefix
+type prefixClaimant struct {
+	Owner     string   `json:"owner"`
+	ConfFiles []string `json:"conf_files"`
+	Rules     int      `json:"rules"`
+}
+
+// prefixClaim lists who manages a path prefix
+type prefixClaim struct {
+	Prefix    string           `json:"prefix"`
+	Claimants []prefixClaimant `json:"claimants"`
+}
+
+// prefixMap is the result of prefix-map
+type prefixMap struct {
+	Depth    int                       `json:"depth"`
+	Prefixes []prefixClaim             `json:"prefixes"`
+	Overlaps []string                  `json:"overlaps"`         // prefixes with several claimants
+	Matrix   map[string]map[string]int `json:"matrix,omitempty"` // shared prefixes per pair of claimants
+}
+
+// pathPrefix cuts a path down to its first depth components
+func pathPrefix(path string, depth int) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// ruleOwner names what a rule belongs to: the package shipping its fragment,
+// or the fragment itself when no package database knows it
+func ruleOwner(conf string, owners map[string]string) string {
+	if owner, ok := owners[conf]; ok {
+		return owner
+	}
+	owner := ""
+	if pkgDB != nil {
+		owner = pkgDB.owner(conf)
+	}
+	if owner == "" {
+		owner = conf
+	}
+	owners[conf] = owner
+	return owner
+}
+
+// buildPrefixMap groups the effective rules by path prefix and owner
+func buildPrefixMap(rules []rule, depth int) prefixMap {
+	owners := make(map[string]string)
+	claims := make(map[string]map[string]*prefixClaimant)
+	for _, r := range rules {
+		path := globFreeParent(r.Path)
+		if strings.Contains(path, "%") {
+			continue
+		}
+		prefix := pathPrefix(path, depth)
+		if claims[prefix] == nil {
+			claims[prefix] = make(map[string]*prefixClaimant)
+		}
+		owner := ruleOwner(r.ConfFile, owners)
+		c := claims[prefix][owner]
+		if c == nil {
+			c = &prefixClaimant{Owner: owner}
+			claims[prefix][owner] = c
+		}
+		c.Rules++
+		if len(c.ConfFiles) == 0 || c.ConfFiles[len(c.ConfFiles)-1] != r.ConfFile {
+			c.ConfFiles = append(c.ConfFiles, r.ConfFile)
+		}
+	}
+
+	pm := prefixMap{Depth: depth, Overlaps: []string{}, Matrix: make(map[string]map[string]int)}
+	for prefix, byOwner := range claims {
+		claim := prefixClaim{Prefix: prefix}
+		for _, c := range byOwner {
+			claim.Claimants = append(claim.Claimants, *c)
+		}
+		sort.Slice(claim.Claimants, func(i, j int) bool { return claim.Claimants[i].Owner < claim.Claimants[j].Owner })
+		pm.Prefixes = append(pm.Prefixes, claim)
+	}
+	sort.Slice(pm.Prefixes, func(i, j int) bool { return pm.Prefixes[i].Prefix < pm.Prefixes[j].Prefix })
+	for _, claim := range pm.Prefixes {
+		if len(claim.Claimants) < 2 {
+			continue
+		}
+		pm.Overlaps = append(pm.Overlaps, claim.Prefix)
+		for _, a := range claim.Claimants {
+			for _, b := range claim.Claimants {
+				if a.Owner == b.Owner {
+					continue
+				}
+				if pm.Matrix[a.Owner] == nil {
+					pm.Matrix[a.Owner] = make(map[string]int)
+				}
+				pm.Matrix[a.Owner][b.Owner]++
+			}
+		}
+	}
+	return pm
+}
+
+// runPrefixMap prints which packages claim which path prefixes, and how
+// many prefixes each pair of packages shares
+func runPrefixMap(args []string) int {
+	if prefixDepth < 1 {
+		fmt.Fprintln(os.Stderr, "Error: --depth must be at least 1")
+		return 2
+	}
+	pm := buildPrefixMap(effectiveRules(), prefixDepth)
+	if outputFormat == "json" {
+		data, _ := json.MarshalIndent(pm, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+		return 0
+	}
+	printPrefixMap(pm)
+	return 0
+}
+
+// printPrefixMap renders a prefix map and its conflict matrix for humans
+func printPrefixMap(pm prefixMap) {
+	fmt.Fprintf(out, "=== Path prefixes claimed on %s (depth %d) ===\n", describeRoot(), pm.Depth)
+	if pkgDB != nil {
+		fmt.Fprintf(out, "Owners are %s packages, or the fragment itself if no package ships it\n", pkgDB.name())
+	}
+	for _, claim := range pm.Prefixes {
+		if len(claim.Claimants) > 1 {
+			fmt.Fprintf(out, "%s%s%s\n", colorYellow, claim.Prefix, colorReset)
+		} else {
+			fmt.Fprintln(out, claim.Prefix)
+		}
+		for _, c := range claim.Claimants {
+			fmt.Fprintf(out, "   %s: %d rule(s) in %s\n", c.Owner, c.Rules, strings.Join(c.ConfFiles, ", "))
+		}
+	}
+
+	fmt.Fprintln(out, "\n=== Overlapping prefixes ===")
+	if len(pm.Overlaps) == 0 {
+		fmt.Fprintf(out, "%s✓ Every prefix has a single owner%s\n", colorGreen, colorReset)
+		return
+	}
+	fmt.Fprintf(out, "%s⚠ %d prefix(es) are managed by several owners: %s%s\n", colorYellow, len(pm.Overlaps), strings.Join(pm.Overlaps, ", "), colorReset)
+
+	names := make([]string, 0, len(pm.Matrix))
+	width := 0
+	for name := range pm.Matrix {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+	sort.Strings(names)
+	fmt.Fprintln(out, "\nShared prefixes:")
+	fmt.Fprintf(out, "%*s", width, "")
+	for i := range names {
+		fmt.Fprintf(out, " %4d", i+1)
+	}
+	fmt.Fprintln(out)
+	for i, a := range names {
+		fmt.Fprintf(out, "%-*s", width, a)
+		for _, b := range names {
+			if n := pm.Matrix[a][b]; n > 0 {
+				fmt.Fprintf(out, " %4d", n)
+			} else {
+				fmt.Fprintf(out, " %4s", ".")
+			}
+		}
+		fmt.Fprintf(out, "  (%d)\n", i+1)
+	}
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// systemdProfile is the tmpfiles.d syntax a systemd release added
+type systemdProfile struct {
+	Version   int
+	Types     string
+	Modifiers string
+}
+
+// systemdProfiles lists the rule types and modifiers by the release that
+// introduced them; version 0 covers what every supported release knows
+var systemdProfiles = []systemdProfile{
+	{Version: 0, Types: "fFwdDevqQpLcbCxXrRzZtThHaAm", Modifiers: "+!-"},
+	{Version: 246, Modifiers: "="},
+	{Version: 247, Modifiers: "~"},
+	{Version: 251, Modifiers: "^"},
+	{Version: 256, Modifiers: "$?"},
+}
+
+// syntaxSince returns the release that introduced a rule type or modifier,
+// or -1 if no release knows it
+func syntaxSince(c byte, modifier bool) int {
+	for _, p := range systemdProfiles {
+		set := p.Types
+		if modifier {
+			set = p.Modifiers
+		}
+		if strings.IndexByte(set, c) >= 0 {
+			return p.Version
+		}
+	}
+	return -1
+}
+
+// checkSchema validates every line of the merged config against the syntax
+// of the target systemd version; lines it cannot parse are rejected by
+// systemd-tmpfiles as a whole
+func checkSchema(version int) {
+	target := "any systemd version"
+	if version > 0 {
+		target = fmt.Sprintf("systemd %d", version)
+	}
+	fmt.Fprintf(out, "\n=== Config schema for %s ===\n", target)
+	reject := func(conf string, line int, path, message string) {
+		fmt.Fprintf(out, "%s✗ %s:%d: %s%s\n", colorRed, conf, line, message, colorReset)
+		addFinding(Finding{Category: catUnsupportedSyntax, Severity: severityError, Path: path, ConfFile: conf, Line: line, Message: message})
+	}
+	before := len(findings)
+	for _, frag := range effectiveConfFiles() {
+		if packagePayload != nil && !packagePayload[frag.Path] {
+			continue
+		}
+		for i, raw := range readLines(frag.Path) {
+			line := strings.TrimSpace(raw)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			r, err := parseRuleCached(line)
+			if err != nil {
+				reject(frag.Path, i+1, "", err.Error())
+				continue
+			}
+			switch since := syntaxSince(r.Type[0], false); {
+			case since < 0:
+				reject(frag.Path, i+1, r.Path, fmt.Sprintf("unknown rule type %s", r.Type))
+			case version > 0 && since > version:
+				reject(frag.Path, i+1, r.Path, fmt.Sprintf("rule type %s needs systemd %d", r.Type, since))
+			}
+			for j := 0; j < len(r.Modifiers); j++ {
+				if since := syntaxSince(r.Modifiers[j], true); version > 0 && since > version {
+					reject(frag.Path, i+1, r.Path, fmt.Sprintf("modifier %c needs systemd %d", r.Modifiers[j], since))
+				}
+			}
+		}
+	}
+	if len(findings) == before {
+		fmt.Fprintf(out, "%s✓ Every line is understood by %s%s\n", colorGreen, target, colorReset)
+	}
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// provideMapping declares the paths an optional package provides; a path
+// without glob characters covers everything below it
+type provideMapping struct {
+	Package string   `json:"package"`
+	Paths   []string `json:"paths"`
+}
+
+// validate checks a provides mapping from the site configuration
+func (m provideMapping) validate() error {
+	if m.Package == "" {
+		return fmt.Errorf("no package")
+	}
+	for _, p := range m.Paths {
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("%s: %q is not an absolute path", m.Package, p)
+		}
+		if _, err := filepath.Match(p, "/"); err != nil {
+			return fmt.Errorf("%s: %w", m.Package, err)
+		}
+	}
+	return nil
+}
+
+// providerOf returns the optional package declared to provide a path
+func providerOf(path string) (string, bool) {
+	for _, m := range site.Provides {
+		for _, p := range m.Paths {
+			if !strings.ContainsAny(p, "*?[") && underPrefix(path, filepath.Clean(p)) {
+				return m.Package, true
+			}
+			if ok, _ := filepath.Match(p, path); ok {
+				return m.Package, true
+			}
+		}
+	}
+	return "", false
+}
+
+// reportOptionalAbsent records a missing target that an optional package
+// provides when that package is not installed, reporting whether it did;
+// without a package database the package is assumed to be left out
+func reportOptionalAbsent(path, target, conf string, lineNo int) bool {
+	pkg, ok := providerOf(target)
+	if !ok || pkgDB != nil && len(pkgDB.files(pkg)) > 0 {
+		return false
+	}
+	fmt.Fprintf(out, "  %s⤷ Target provided by optional package %s, which is not installed%s\n", colorYellow, pkg, colorReset)
+	addFinding(Finding{Category: catOptionalAbsent, Severity: severityInfo, Path: path, Target: target, ConfFile: conf, Line: lineNo, Package: pkg,
+		Message: fmt.Sprintf("target is provided by optional package %s, which is not installed", pkg)})
+	return true
+}
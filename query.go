@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// querySocket is where the daemon answers single-shot queries; "" disables it
+var querySocket string
+
+// maxQuerySize bounds a query so a stray client cannot make the daemon
+// allocate arbitrary amounts of memory
+const maxQuerySize = 64 * 1024
+
+// query is a request on the query socket:
+//
+//	{"query": "status"}
+//	{"query": "factory-linked", "path": "/etc/fstab"}
+//	{"query": "findings", "path": "/etc"}
+type query struct {
+	Query string `json:"query"`
+	Path  string `json:"path,omitempty"`
+}
+
+// statusAnswer answers {"query": "status"}
+type statusAnswer struct {
+	LastRun  time.Time `json:"last_run"`
+	Duration float64   `json:"duration_seconds"`
+	Audits   int       `json:"audits"`
+	Rules    int       `json:"rules"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+	Failure  string    `json:"failure,omitempty"` // why the last audit failed
+}
+
+// linkAnswer answers {"query": "factory-linked"}
+type linkAnswer struct {
+	Path     string    `json:"path"`
+	Linked   bool      `json:"linked"`
+	Target   string    `json:"target,omitempty"`
+	ConfFile string    `json:"conf_file,omitempty"`
+	Line     int       `json:"line,omitempty"`
+	Findings []Finding `json:"findings"`
+}
+
+// readFrame reads one message framed as its decimal length, a newline and
+// the payload, which is easy to produce from a shell:
+//
+//	printf '18\n{"query":"status"}' | socat - UNIX-CONNECT:/run/tmpfiles-audit.sock
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || n < 0 || n > maxQuerySize {
+		return nil, fmt.Errorf("invalid frame length %q", strings.TrimSpace(header))
+	}
+	data := make([]byte, n)
+	_, err = io.ReadFull(r, data)
+	return data, err
+}
+
+// writeFrame writes one message in readFrame's framing
+func writeFrame(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%d\n%s\n", len(data)+1, data)
+	return err
+}
+
+// answerQuery evaluates a query against the latest daemon run
+func answerQuery(q query) (any, error) {
+	run := daemon.snapshot()
+	if run.Report.Tool == "" && q.Query != "status" {
+		return nil, errors.New("no audit has completed yet")
+	}
+	switch q.Query {
+	case "status":
+		a := statusAnswer{LastRun: run.Started, Duration: run.Duration.Seconds(), Audits: run.Audits, Rules: len(run.Report.Rules)}
+		for _, f := range run.Report.Findings {
+			switch f.Severity {
+			case severityError:
+				a.Errors++
+			case severityWarning:
+				a.Warnings++
+			}
+		}
+		if run.Err != nil {
+			a.Failure = run.Err.Error()
+		}
+		return a, nil
+	case "factory-linked":
+		if !filepath.IsAbs(q.Path) {
+			return nil, errors.New("factory-linked needs an absolute path")
+		}
+		path := filepath.Clean(q.Path)
+		a := linkAnswer{Path: path, Findings: []Finding{}}
+		for _, r := range run.Report.Rules {
+			if r.Type != "L" || r.Path != path {
+				continue
+			}
+			target := r.Argument
+			if target == "" {
+				target = factoryTarget(path)
+			}
+			if inFactoryRoot(target) {
+				a.Linked, a.Target, a.ConfFile, a.Line = true, target, r.ConfFile, r.Line
+				break
+			}
+		}
+		for _, f := range run.Report.Findings {
+			if f.Path == path {
+				a.Findings = append(a.Findings, f)
+			}
+		}
+		return a, nil
+	case "findings":
+		list := []Finding{}
+		for _, f := range run.Report.Findings {
+			if q.Path == "" || underPrefix(f.Path, filepath.Clean(q.Path)) {
+				list = append(list, f)
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unknown query %q", q.Query)
+}
+
+// serveQuery answers the single query of one connection
+func serveQuery(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	data, err := readFrame(bufio.NewReader(conn))
+	if err != nil {
+		writeFrame(conn, map[string]string{"error": err.Error()})
+		return
+	}
+	var q query
+	if err := json.Unmarshal(data, &q); err != nil {
+		writeFrame(conn, map[string]string{"error": err.Error()})
+		return
+	}
+	answer, err := answerQuery(q)
+	if err != nil {
+		writeFrame(conn, map[string]string{"error": err.Error()})
+		return
+	}
+	writeFrame(conn, answer)
+}
+
+// startQuerySocket answers queries on querySocket in the background
+func startQuerySocket() error {
+	if querySocket == "" {
+		return nil
+	}
+	if err := os.Remove(querySocket); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	listener, err := net.Listen("unix", querySocket)
+	if err != nil {
+		return err
+	}
+	// Queries only read the latest results, so anyone may ask
+	if err := os.Chmod(querySocket, 0666); err != nil {
+		return err
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: query socket: %v\n", err)
+				os.Exit(1)
+			}
+			go serveQuery(conn)
+		}
+	}()
+	return nil
+}
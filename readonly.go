@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// mountInfoFile lists the mounts of the auditor's mount namespace
+const mountInfoFile = "/proc/self/mountinfo"
+
+// checkReadOnly enables reporting rules whose path lies on a read-only mount
+var checkReadOnly bool
+
+// mount is one line of mountinfo
+type mount struct {
+	Point    string // where the mount is, on the audited system
+	FSType   string
+	Source   string
+	Options  string // superblock options
+	ReadOnly bool   // mounted or its superblock is read-only
+}
+
+// unescapeMountPath decodes the octal escapes mountinfo uses for spaces and the like
+func unescapeMountPath(s string) string {
+	if unescaped, err := cUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+// readMounts parses mountinfo, keeping the mounts inside the audited root
+// with their paths relative to it; the mount holding the root itself
+// becomes the one at /
+func readMounts() ([]mount, error) {
+	f, err := os.Open(mountInfoFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	top := "/"
+	if rootDir != "" {
+		top = canonicalHostPath(rootDir)
+	}
+	var mounts []mount
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		pre, post, ok := strings.Cut(scanner.Text(), " - ")
+		fields, superFields := strings.Fields(pre), strings.Fields(post)
+		if !ok || len(fields) < 6 || len(superFields) < 3 {
+			continue
+		}
+		point := unescapeMountPath(fields[4])
+		switch {
+		case underPrefix(point, top):
+			point = "/" + strings.TrimPrefix(strings.TrimPrefix(point, top), "/")
+		case underPrefix(top, point):
+			point = "/"
+		default:
+			continue
+		}
+		mounts = append(mounts, mount{
+			Point:    point,
+			FSType:   superFields[0],
+			Source:   unescapeMountPath(superFields[1]),
+			Options:  superFields[2],
+			ReadOnly: slices.Contains(strings.Split(fields[5], ","), "ro") || slices.Contains(strings.Split(superFields[2], ","), "ro"),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// canonicalHostPath resolves symlinks in a path on the host
+func canonicalHostPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// mountOf returns the mount a path of the audited system lives on: the
+// last mounted of those with the deepest mount point above it
+func mountOf(mounts []mount, path string) (mount, bool) {
+	path = canonicalPath(path)
+	var best mount
+	found := false
+	for _, m := range mounts {
+		if underPrefix(path, m.Point) && (!found || len(m.Point) >= len(best.Point)) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// adjustingTypes are rule types that change existing paths
+const adjustingTypes = "wWzZtThHaAemrR"
+
+// globFreeParent returns the deepest directory of a glob without glob characters
+func globFreeParent(path string) string {
+	for strings.ContainsAny(path, "*?[") {
+		path = filepath.Dir(path)
+	}
+	return path
+}
+
+// checkReadOnlyMounts reports rules systemd-tmpfiles can never apply because
+// their path is on a read-only mount: missing paths that should be created,
+// symlinks --fix would create, and paths that should be adjusted
+func checkReadOnlyMounts(list []rule) {
+	fmt.Fprintln(out, "\n=== Rules on read-only mounts ===")
+	mounts, err := readMounts()
+	if err != nil {
+		fmt.Fprintf(out, "%s⚠ Cannot read %s: %v%s\n", colorYellow, mountInfoFile, err, colorReset)
+		return
+	}
+	blocked := 0
+	for _, r := range list {
+		if strings.Contains(r.Path, "%") || !inAuditScope(r) {
+			continue
+		}
+		creating, adjusting := strings.Contains(creatingTypes, r.Type), strings.Contains(adjustingTypes, r.Type)
+		if !creating && !adjusting {
+			continue
+		}
+		m, ok := mountOf(mounts, globFreeParent(r.Path))
+		if !ok || !m.ReadOnly {
+			continue
+		}
+		where := fmt.Sprintf("%s (%s) is read-only", m.Point, m.FSType)
+		switch {
+		case creating && !strings.ContainsAny(r.Path, "*?["):
+			if _, err := lstatPath(r.Path); err == nil {
+				continue
+			}
+			creator := "systemd-tmpfiles"
+			if _, fixable := fixableLink(r); fixable {
+				creator += " or --fix"
+			}
+			blocked++
+			fmt.Fprintf(out, "%s✗ %s %s can never be created by %s: %s (%s:%d)%s\n", colorRed, r.Type, r.Path, creator, where, r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catReadOnlyMount, Severity: severityError, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: fmt.Sprintf("path is missing and cannot be created by %s: %s", creator, where)})
+		case adjusting:
+			blocked++
+			fmt.Fprintf(out, "%s⚠ %s %s cannot be adjusted at runtime: %s (%s:%d)%s\n", colorYellow, r.Type, r.Path, where, r.ConfFile, r.Line, colorReset)
+			addFinding(Finding{Category: catReadOnlyMount, Severity: severityWarning, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: "systemd-tmpfiles cannot adjust the path: " + where})
+		}
+	}
+	if blocked == 0 {
+		fmt.Fprintf(out, "%s✓ No rule needs to modify a read-only mount%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// recursiveCompleteness extends completeness checks to the subdirectories
+// of tracked directories
+var recursiveCompleteness bool
+
+// unlinkedBelow returns the files below subdir, relative to its tracked
+// parent, that are neither linked nor ignored. An ignored directory or one
+// linked as a whole covers everything below it, and subdirectories that are
+// tracked themselves are left to their own check
+func unlinkedBelow(subdir, rel string, linkedDirs map[string]map[string]bool, ignoredFiles map[string]bool) []string {
+	if _, tracked := linkedDirs[subdir]; tracked || strings.Contains(subdir, "/.git") {
+		return nil
+	}
+	if _, ok := directoryLink(subdir, linkedDirs); ok {
+		return nil
+	}
+	notifyWatchdog()
+	entries, err := listDir(subdir)
+	if err != nil {
+		return nil
+	}
+	var missing []string
+	for _, entry := range entries {
+		fullPath := filepath.Join(subdir, entry.Name())
+		name := filepath.Join(rel, entry.Name())
+		switch {
+		case ignoredFiles[fullPath]:
+		case entry.IsDir():
+			missing = append(missing, unlinkedBelow(fullPath, name, linkedDirs, ignoredFiles)...)
+		case packagePayload != nil && !packagePayload[fullPath]:
+		default:
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// reload re-reads the site config, the policy and the advisories between
+// audits; a file that fails to load keeps its previous contents. Ignore
+// files and fragments are read by every audit anyway, and the listeners
+// and the latest results are left alone
+func (d *daemonState) reload() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	previous := site.Webhooks
+	var errs []error
+	if err := loadSiteConfig(); err != nil {
+		errs = append(errs, fmt.Errorf("site config: %w", err))
+	}
+	if policyFile != "" {
+		if err := loadPolicy(); err != nil {
+			errs = append(errs, fmt.Errorf("policy: %w", err))
+		}
+	}
+	if err := loadAdvisories(); err != nil {
+		errs = append(errs, fmt.Errorf("advisories: %w", err))
+	}
+
+	// Rate limits, dedup and the pending batch carry over to webhooks with
+	// an unchanged URL; the old webhooks are stopped so that nothing is
+	// posted under the previous configuration
+	for j := range previous {
+		old := &previous[j]
+		old.mu.Lock()
+		var pending []Finding
+		if old.flushTimer != nil && old.flushTimer.Stop() {
+			pending = old.pending
+		}
+		old.pending, old.flushTimer = nil, nil
+		for i := range site.Webhooks {
+			h := &site.Webhooks[i]
+			if old.URL != h.URL {
+				continue
+			}
+			h.lastSent = old.lastSent
+			for key, at := range old.posted {
+				h.posted[key] = at
+			}
+			h.pending = append(h.pending, pending...)
+			if len(h.pending) > 0 && h.flushTimer == nil {
+				h.flushTimer = time.AfterFunc(max(h.interval-time.Since(h.lastSent), 0), h.flush)
+			}
+			break
+		}
+		old.mu.Unlock()
+	}
+	select {
+	case scheduleChanged <- struct{}{}:
+	default:
+	}
+	return errors.Join(errs...)
+}
+
+// handleReloads reloads the daemon's configuration on SIGHUP and re-audits
+// with it, following systemd's Type=notify-reload protocol
+func handleReloads() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			var now unix.Timespec
+			unix.ClockGettime(unix.CLOCK_MONOTONIC, &now)
+			sdNotify(fmt.Sprintf("RELOADING=1\nMONOTONIC_USEC=%d", now.Nano()/1000))
+			if err := daemon.reload(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: reload: %v\n", err)
+			}
+			sdNotify("READY=1")
+			daemonAudit()
+		}
+	}()
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Report is a complete audit result: the rules that were read and the
+// findings about them, as written by --format=json and stored in snapshots
+type Report struct {
+	Tool     string    `json:"tool"`
+	Schema   int       `json:"schema"`
+	Created  time.Time `json:"created"`
+	Host     string    `json:"host,omitempty"`
+	Root     string    `json:"root"`
+	Rules    []rule    `json:"rules"`
+	Findings []Finding `json:"findings"`
+}
+
+// currentReport captures the results of the last audit run
+func currentReport() Report {
+	root := rootDir
+	if root == "" {
+		root = "/"
+	}
+	return Report{
+		Tool:     "tmpfiles-audit",
+		Schema:   reportSchema,
+		Created:  time.Now().UTC().Truncate(time.Second),
+		Host:     auditedHostname(),
+		Root:     root,
+		Rules:    append([]rule{}, parsedRules...),
+		Findings: append([]Finding{}, findings...),
+	}
+}
+
+// auditedHostname returns the static hostname of the audited system
+func auditedHostname() string {
+	if lines := readLines("/etc/hostname"); len(lines) > 0 && strings.TrimSpace(lines[0]) != "" {
+		return strings.TrimSpace(lines[0])
+	}
+	if rootDir == "" {
+		name, _ := os.Hostname()
+		return name
+	}
+	return ""
+}
+
+// writeReport prints the current report as indented JSON
+func writeReport(w io.Writer) {
+	data, err := json.MarshalIndent(currentReport(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding report: %v\n", err)
+		return
+	}
+	w.Write(append(data, '\n'))
+}
+
+// loadReport reads a report written by writeReport or saveReport
+func loadReport(path string) (Report, error) {
+	var r Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("%s: %w", path, err)
+	}
+	if r.Tool != "tmpfiles-audit" {
+		return r, fmt.Errorf("%s is not a tmpfiles-audit report", path)
+	}
+	return r, nil
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory that is synced before the rename, so readers and crashes
+// never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// saveReport atomically writes a report to path
+func saveReport(path string, r Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
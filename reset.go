@@ -0,0 +1,256 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// resetDirs are emptied by a factory reset unless other directories are given
+var resetDirs = []string{"/etc", "/var"}
+
+// Outcomes of a path in a simulated factory reset
+const (
+	resetLink          = "link"
+	resetCopy          = "copy"
+	resetCreate        = "create"
+	resetDangling      = "dangling"
+	resetMissingSource = "missing-source"
+	resetLost          = "lost"
+)
+
+// resetOutcome is what a factory reset leaves at one path
+type resetOutcome struct {
+	Path     string `json:"path"`
+	Outcome  string `json:"outcome"`
+	Type     string `json:"type,omitempty"`   // rule type producing the path
+	Target   string `json:"target,omitempty"` // link target or copy source
+	Files    int    `json:"files,omitempty"`  // files copied, or lost below a lost directory
+	ConfFile string `json:"conf_file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// resetSimulation is the result of simulate-reset
+type resetSimulation struct {
+	Dirs        []string       `json:"dirs"`
+	Outcomes    []resetOutcome `json:"outcomes"`
+	Unsimulated int            `json:"unsimulated_rules,omitempty"` // rules with globs or specifiers
+}
+
+// creatingTypes are the rule types that bring a missing path into existence
+const creatingTypes = "fFdDvqQpLcbC"
+
+// resetState tracks what the simulated systemd-tmpfiles run produced
+type resetState struct {
+	dirs     []string
+	produced map[string]*resetOutcome
+}
+
+// inReset reports whether a path lies in a directory the reset empties
+func (s *resetState) inReset(path string) bool {
+	for _, d := range s.dirs {
+		if underPrefix(path, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// covered reports whether a path exists after the reset: it was produced
+// itself, lies below a produced link, or was copied from a directory
+func (s *resetState) covered(path string) bool {
+	if !s.inReset(path) {
+		_, err := statPath(path)
+		return err == nil
+	}
+	for p := path; ; p = filepath.Dir(p) {
+		if o, ok := s.produced[p]; ok {
+			switch {
+			case p == path:
+				return o.Outcome != resetMissingSource
+			case o.Outcome == resetLink:
+				return true
+			case o.Outcome == resetCopy:
+				_, err := statPath(o.Target + strings.TrimPrefix(path, p))
+				return err == nil
+			}
+			return false
+		}
+		if p == "/" {
+			return false
+		}
+	}
+}
+
+// countFiles returns the number of non-directories below a path
+func countFiles(path string) int {
+	entries, err := listDir(path)
+	if err != nil {
+		return 1
+	}
+	n := 0
+	for _, e := range entries {
+		if e.IsDir() {
+			n += countFiles(filepath.Join(path, e.Name()))
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// apply records what the first rule for each path produces, as
+// systemd-tmpfiles applies the first of several rules for a path
+func (s *resetState) apply(list []rule) (unsimulated int) {
+	for _, r := range list {
+		if !strings.Contains(creatingTypes, r.Type) || !s.inReset(r.Path) {
+			continue
+		}
+		if strings.ContainsAny(r.Path, "*?[%") || r.Type == "L" && strings.Contains(r.Argument, "%") {
+			unsimulated++
+			continue
+		}
+		if _, ok := s.produced[r.Path]; ok {
+			continue
+		}
+		o := &resetOutcome{Path: r.Path, Type: r.Type + r.Modifiers, ConfFile: r.ConfFile, Line: r.Line, Outcome: resetCreate}
+		switch r.Type {
+		case "L":
+			o.Outcome, o.Target = resetLink, symlinkTarget(r)
+		case "C":
+			o.Outcome, o.Target = resetCopy, symlinkTarget(r)
+			if fi, err := statPath(o.Target); err != nil {
+				o.Outcome = resetMissingSource
+			} else if fi.IsDir() {
+				o.Files = countFiles(o.Target)
+			} else {
+				o.Files = 1
+			}
+		}
+		s.produced[r.Path] = o
+	}
+	// Links can only be judged once everything they may point into is known
+	for _, o := range s.produced {
+		if o.Outcome == resetLink && !s.covered(resolveTargetPath(o.Path, o.Target)) {
+			o.Outcome = resetDangling
+		}
+	}
+	return unsimulated
+}
+
+// lostBelow returns the live paths below dir that the reset does not bring
+// back; a directory none of whose content survives is returned as a whole
+func (s *resetState) lostBelow(dir string) (lost []resetOutcome, whole bool) {
+	notifyWatchdog()
+	entries, err := listDir(dir)
+	if err != nil {
+		return nil, false
+	}
+	whole = true
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if s.covered(path) {
+			whole = false
+			// Created and copied directories only bring back some of their content
+			if o := s.produced[path]; e.IsDir() && (o == nil || o.Outcome != resetLink) {
+				below, _ := s.lostBelow(path)
+				lost = append(lost, below...)
+			}
+			continue
+		}
+		if !e.IsDir() {
+			lost = append(lost, resetOutcome{Path: path, Outcome: resetLost})
+			continue
+		}
+		below, all := s.lostBelow(path)
+		if all {
+			lost = append(lost, resetOutcome{Path: path + "/", Outcome: resetLost, Files: countFiles(path)})
+		} else {
+			lost = append(lost, below...)
+			whole = false
+		}
+	}
+	return lost, whole
+}
+
+// simulateReset predicts the outcome of running systemd-tmpfiles on emptied
+// reset directories with the effective configuration
+func simulateReset(dirs []string) resetSimulation {
+	s := &resetState{dirs: dirs, produced: make(map[string]*resetOutcome)}
+	sim := resetSimulation{Dirs: dirs, Unsimulated: s.apply(effectiveRules())}
+	for _, o := range s.produced {
+		sim.Outcomes = append(sim.Outcomes, *o)
+	}
+	for _, d := range dirs {
+		lost, _ := s.lostBelow(d)
+		sim.Outcomes = append(sim.Outcomes, lost...)
+	}
+	sort.Slice(sim.Outcomes, func(i, j int) bool { return sim.Outcomes[i].Path < sim.Outcomes[j].Path })
+	return sim
+}
+
+// runSimulateReset implements `simulate-reset [DIR...]`
+func runSimulateReset(args []string) int {
+	dirs := resetDirs
+	if len(args) > 0 {
+		dirs = nil
+		for _, a := range args {
+			if !filepath.IsAbs(a) {
+				fmt.Fprintf(os.Stderr, "Error: %q is not an absolute path\n", a)
+				return 2
+			}
+			dirs = append(dirs, filepath.Clean(a))
+		}
+	}
+	sim := simulateReset(dirs)
+
+	if outputFormat == "json" {
+		data, _ := json.MarshalIndent(sim, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+		return 0
+	}
+	printResetSimulation(sim)
+	return 0
+}
+
+// printResetSimulation renders a reset simulation for humans
+func printResetSimulation(sim resetSimulation) {
+	fmt.Fprintf(out, "=== Factory reset of %s on %s ===\n", strings.Join(sim.Dirs, ", "), describeRoot())
+	counts := make(map[string]int)
+	for _, o := range sim.Outcomes {
+		counts[o.Outcome]++
+		source := ""
+		if o.ConfFile != "" {
+			source = fmt.Sprintf(" (%s:%d)", o.ConfFile, o.Line)
+		}
+		switch o.Outcome {
+		case resetLink:
+			fmt.Fprintf(out, "%s+ %s -> %s%s%s\n", colorGreen, o.Path, o.Target, source, colorReset)
+		case resetCopy:
+			fmt.Fprintf(out, "%s+ %s copied from %s, %d file(s)%s%s\n", colorGreen, o.Path, o.Target, o.Files, source, colorReset)
+		case resetCreate:
+			fmt.Fprintf(out, "%s+ %s created by %s%s%s\n", colorGreen, o.Path, o.Type, source, colorReset)
+		case resetDangling:
+			fmt.Fprintf(out, "%s✗ %s -> %s would dangle%s%s\n", colorRed, o.Path, o.Target, source, colorReset)
+		case resetMissingSource:
+			fmt.Fprintf(out, "%s✗ %s would not be copied, %s is missing%s%s\n", colorRed, o.Path, o.Target, source, colorReset)
+		case resetLost:
+			if o.Files > 0 {
+				fmt.Fprintf(out, "%s- %s lost, %d file(s)%s\n", colorYellow, o.Path, o.Files, colorReset)
+			} else {
+				fmt.Fprintf(out, "%s- %s lost%s\n", colorYellow, o.Path, colorReset)
+			}
+		}
+	}
+	fmt.Fprintf(out, "\n%d linked, %d copied, %d created, %d broken, %d lost\n", counts[resetLink], counts[resetCopy], counts[resetCreate],
+		counts[resetDangling]+counts[resetMissingSource], counts[resetLost])
+	if sim.Unsimulated > 0 {
+		fmt.Fprintf(out, "%s⚠ %d rule(s) with globs or specifiers were not simulated%s\n", colorYellow, sim.Unsimulated, colorReset)
+	}
+}
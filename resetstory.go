@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// requireFactoryReset enforces that every L rule under /etc survives a factory reset
+var requireFactoryReset bool
+
+// resetWaiver exempts /etc links matching a glob from targeting the factory
+// tree; the reason documents what restores them after a reset
+type resetWaiver struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// matchResetWaiver returns the policy waiver covering an /etc path
+func matchResetWaiver(path string) (resetWaiver, bool) {
+	for _, w := range sitePolicy.ResetWaivers {
+		if ok, _ := filepath.Match(w.Path, path); ok {
+			return w, true
+		}
+	}
+	return resetWaiver{}, false
+}
+
+// checkFactoryResetStory asserts that each /etc path an L rule manages
+// either points into the factory tree, so a reset restores it from /usr,
+// or is waived by a .ignore entry or a documented policy waiver
+func checkFactoryResetStory(list []rule, ignoredFiles map[string]bool) {
+	fmt.Fprintln(out, "\n=== Factory-reset coverage of /etc ===")
+	links, covered, waived := 0, 0, 0
+	for _, r := range list {
+		if r.Type != "L" || !underPrefix(r.Path, "/etc") || strings.ContainsAny(r.Path, "*?[%") || !inAuditScope(r) {
+			continue
+		}
+		links++
+		target := resolveTargetPath(r.Path, symlinkTarget(r))
+		if inFactoryRoot(target) {
+			covered++
+			continue
+		}
+		if ignoredFiles[r.Path] {
+			waived++
+			fmt.Fprintf(out, "%s✓ %s -> %s is waived by an ignore file%s\n", colorGreen, r.Path, target, colorReset)
+			continue
+		}
+		if w, ok := matchResetWaiver(r.Path); ok {
+			waived++
+			fmt.Fprintf(out, "%s✓ %s -> %s is waived: %s%s\n", colorGreen, r.Path, target, w.Reason, colorReset)
+			continue
+		}
+		fmt.Fprintf(out, "%s✗ %s -> %s has no factory-reset story (%s:%d)%s\n", colorRed, r.Path, target, r.ConfFile, r.Line, colorReset)
+		fmt.Fprintf(out, "   ⤷ Point it into %s, or document a waiver in the policy's factory_reset_waivers\n", factoryRoots()[0])
+		addFinding(Finding{Category: catNoResetStory, Severity: severityError, Path: r.Path, Target: target, ConfFile: r.ConfFile, Line: r.Line,
+			Message: "link under /etc neither targets the factory tree nor is waived"})
+	}
+	fmt.Fprintf(out, "%d of %d /etc link(s) target the factory tree, %d waived\n", covered, links, waived)
+	if covered+waived == links {
+		fmt.Fprintf(out, "%s✓ Every /etc link survives a factory reset%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// reverseCompleteness enables checking that factory content is materialized
+var reverseCompleteness bool
+
+// checkMaterialized walks the factory roots and reports files whose live
+// path does not exist on the audited system; a .ignore entry naming the
+// factory file or the live path exempts it. When a whole live directory is
+// missing it is reported once instead of every file below it
+func checkMaterialized(ignoredFiles map[string]bool) {
+	fmt.Fprintln(out, "\n=== Factory content materialized on the system ===")
+	missing := 0
+	var walk func(root, dir string)
+	walk = func(root, dir string) {
+		notifyWatchdog()
+		entries, err := listDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			factory := filepath.Join(dir, e.Name())
+			live := strings.TrimPrefix(factory, root)
+			if ignoredFiles[factory] || ignoredFiles[live] {
+				continue
+			}
+			if e.IsDir() {
+				if _, err := lstatPath(live); errors.Is(err, fs.ErrNotExist) && inScope(factory) {
+					missing++
+					reportUnmaterialized(factory, live, "directory")
+					continue
+				}
+				walk(root, factory)
+				continue
+			}
+			if !inScope(factory) {
+				continue
+			}
+			if _, err := lstatPath(live); errors.Is(err, fs.ErrNotExist) {
+				if _, ok := populatedAtBoot(live); ok && firstBoot {
+					continue
+				}
+				missing++
+				reportUnmaterialized(factory, live, "file")
+			}
+		}
+	}
+	for _, root := range factoryRoots() {
+		walk(root, root)
+	}
+	if missing == 0 {
+		fmt.Fprintf(out, "%s✓ Every factory file has a live counterpart%s\n", colorGreen, colorReset)
+	}
+}
+
+// inScope reports whether a factory path belongs to what is being audited
+func inScope(path string) bool {
+	if packagePayload == nil {
+		return true
+	}
+	for p := range packagePayload {
+		if underPrefix(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportUnmaterialized records factory content without a live counterpart
+func reportUnmaterialized(factory, live, kind string) {
+	fmt.Fprintf(out, "%s⚠ %s is never materialized: %s does not exist%s\n", colorYellow, factory, live, colorReset)
+	pkg := printOwnership(factory, "   ", false)
+	addFinding(Finding{Category: catUnmaterialized, Severity: severityWarning, Path: live, Target: factory, Package: pkg,
+		Message: fmt.Sprintf("factory %s is installed but not linked, copied or ignored", kind)})
+}
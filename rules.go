@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// rule is a tmpfiles.d line split into fields the way systemd-tmpfiles does it
+type rule struct {
+	Type      string `json:"type"`                // rule type letter, e.g. "L"
+	Modifiers string `json:"modifiers,omitempty"` // modifier characters following the type, e.g. "+!"
+	Path      string `json:"path"`
+	Mode      string `json:"mode,omitempty"`
+	User      string `json:"user,omitempty"`
+	Group     string `json:"group,omitempty"`
+	Age       string `json:"age,omitempty"`
+	Argument  string `json:"argument,omitempty"`
+
+	ConfFile string `json:"conf_file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// ruleModifiers are the characters systemd accepts after the type letter
+const ruleModifiers = "+!-=~^?$"
+
+// hasModifier reports whether the rule carries the given modifier character
+func (r rule) hasModifier(m byte) bool {
+	return strings.IndexByte(r.Modifiers, m) >= 0
+}
+
+// parseRule splits a non-comment tmpfiles.d line like systemd's parse_line():
+// the first six fields are unquoted and C-unescaped words, the argument is
+// the rest of the line with leading whitespace removed, taken literally
+func parseRule(line string) (rule, error) {
+	var fields [6]string
+	rest := line
+	for i := range fields {
+		word, remainder, err := extractWord(rest)
+		if err != nil {
+			return rule{}, err
+		}
+		fields[i], rest = word, remainder
+	}
+	if fields[0] == "" || fields[1] == "" {
+		return rule{}, errors.New("syntax error: type and path are required")
+	}
+
+	r := rule{
+		Type:      fields[0][:1],
+		Modifiers: fields[0][1:],
+		Path:      fields[1],
+		Mode:      fields[2],
+		User:      fields[3],
+		Group:     fields[4],
+		Age:       fields[5],
+	}
+	if strings.Trim(r.Modifiers, ruleModifiers) != "" {
+		return rule{}, errors.New("unknown modifiers in type " + strconv.Quote(fields[0]))
+	}
+	if arg := strings.TrimLeft(rest, " \t"); arg != "" && arg != "-" {
+		r.Argument = arg
+		// Only the file-writing types treat their argument as a C-escaped string
+		if strings.Contains("fFwW", r.Type) {
+			unescaped, err := cUnescape(arg)
+			if err != nil {
+				return rule{}, err
+			}
+			r.Argument = unescaped
+		}
+	}
+	return r, nil
+}
+
+// parsedLine is a cached parseRule result
+type parsedLine struct {
+	rule rule
+	err  error
+}
+
+// parseCache remembers parsed lines across audits of several roots, which
+// mostly share the fragments of their base image
+var parseCache = make(map[string]parsedLine)
+
+// parseRuleCached is parseRule with results shared between audits
+func parseRuleCached(line string) (rule, error) {
+	if p, ok := parseCache[line]; ok {
+		return p.rule, p.err
+	}
+	r, err := parseRule(line)
+	parseCache[line] = parsedLine{r, err}
+	return r, err
+}
+
+// extractWord returns the first whitespace-separated word of s with quotes
+// removed and C escapes resolved, like systemd's extract_first_word() with
+// EXTRACT_UNQUOTE|EXTRACT_CUNESCAPE
+func extractWord(s string) (word, rest string, err error) {
+	s = strings.TrimLeft(s, " \t")
+	var b strings.Builder
+	var quote byte
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && (c == ' ' || c == '\t'):
+			return b.String(), s[i:], nil
+		case c == '\\':
+			n, decoded, err := unescapeAt(s[i:])
+			if err != nil {
+				return "", "", err
+			}
+			b.WriteString(decoded)
+			i += n - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if quote != 0 {
+		return "", "", errors.New("unbalanced quotes")
+	}
+	return b.String(), "", nil
+}
+
+// cUnescape resolves C escapes in s
+func cUnescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		n, decoded, err := unescapeAt(s[i:])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(decoded)
+		i += n - 1
+	}
+	return b.String(), nil
+}
+
+// unescapeAt decodes the escape sequence at the start of s, returning the
+// number of bytes consumed
+func unescapeAt(s string) (int, string, error) {
+	if len(s) < 2 {
+		return 0, "", errors.New("trailing backslash")
+	}
+	simple := map[byte]string{'a': "\a", 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v",
+		'\\': "\\", '"': "\"", '\'': "'", ' ': " ", 's': " "}
+	if v, ok := simple[s[1]]; ok {
+		return 2, v, nil
+	}
+	switch {
+	case s[1] == 'x' && len(s) >= 4:
+		v, err := strconv.ParseUint(s[2:4], 16, 8)
+		if err != nil {
+			return 0, "", errors.New("invalid \\x escape")
+		}
+		return 4, string([]byte{byte(v)}), nil
+	case s[1] >= '0' && s[1] <= '7' && len(s) >= 4:
+		v, err := strconv.ParseUint(s[1:4], 8, 8)
+		if err != nil {
+			return 0, "", errors.New("invalid octal escape")
+		}
+		return 4, string([]byte{byte(v)}), nil
+	}
+	return 0, "", errors.New("invalid escape sequence \\" + string(s[1]))
+}
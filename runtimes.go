@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// runtimeTree is a directory tree managed by flatpak, snapd or container storage
+type runtimeTree struct {
+	runtime string
+	prefix  string
+}
+
+var (
+	// runtimeTrees lists the runtime-managed trees found on the audited system
+	runtimeTrees []runtimeTree
+
+	// includeRuntimes disables the separate classification of runtime-managed paths
+	includeRuntimes bool
+)
+
+// readKeyFile returns key=value pairs from an ini-style file, ignoring sections
+func readKeyFile(path string) map[string]string {
+	values := make(map[string]string)
+	f, err := openPath(path)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' || line[0] == '[' {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.TrimSpace(key)] = cleanQuotes(value)
+		}
+	}
+	return values
+}
+
+// detectRuntimeTrees discovers the trees managed by flatpak, snapd and
+// podman/containers storage from their own databases and configuration
+func detectRuntimeTrees() []runtimeTree {
+	var trees []runtimeTree
+	exists := func(path string) bool {
+		_, err := statPath(path)
+		return err == nil
+	}
+
+	// flatpak: the default system installation plus any extra installations
+	if exists("/var/lib/flatpak/repo") {
+		trees = append(trees, runtimeTree{"flatpak", "/var/lib/flatpak"})
+	}
+	extra, _ := globPath("/etc/flatpak/installations.d/*.conf")
+	for _, conf := range extra {
+		if path := readKeyFile(conf)["Path"]; path != "" {
+			trees = append(trees, runtimeTree{"flatpak", filepath.Clean(path)})
+		}
+	}
+
+	// snapd keeps its state database under /var/lib/snapd
+	if exists("/var/lib/snapd/state.json") {
+		for _, prefix := range []string{"/snap", "/var/lib/snapd", "/var/snap"} {
+			trees = append(trees, runtimeTree{"snapd", prefix})
+		}
+	}
+
+	// podman and friends share containers/storage, whose location is configurable
+	storage := readKeyFile("/etc/containers/storage.conf")
+	graphRoot, runRoot := storage["graphroot"], storage["runroot"]
+	if graphRoot == "" {
+		graphRoot = "/var/lib/containers/storage"
+	}
+	if runRoot == "" {
+		runRoot = "/run/containers/storage"
+	}
+	if exists(graphRoot) {
+		trees = append(trees, runtimeTree{"containers", filepath.Clean(graphRoot)})
+	}
+	if exists(runRoot) {
+		trees = append(trees, runtimeTree{"containers", filepath.Clean(runRoot)})
+	}
+	return trees
+}
+
+// runtimeFor returns the runtime managing path, or "" for host-managed paths
+func runtimeFor(path string) string {
+	for _, t := range runtimeTrees {
+		if path == t.prefix || strings.HasPrefix(path, t.prefix+"/") {
+			return t.runtime
+		}
+	}
+	return ""
+}
+
+// classifyRuntime tags a finding located in a runtime-managed tree and
+// downgrades it to info, since those runtimes maintain their files themselves
+func classifyRuntime(f *Finding) {
+	if includeRuntimes {
+		return
+	}
+	managed := f.Path
+	runtime := runtimeFor(managed)
+	if runtime == "" && f.Target != "" {
+		managed = f.Target
+		runtime = runtimeFor(managed)
+	}
+	if runtime == "" {
+		return
+	}
+	f.Runtime = runtime
+	f.Severity = severityInfo
+	fmt.Fprintf(out, "    %s⤷ %s is managed by %s; reported separately%s\n", colorYellow, managed, runtime, colorReset)
+}
+
+// printRuntimeSummary lists findings that were set aside as runtime-managed
+func printRuntimeSummary() {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		if f.Runtime != "" {
+			counts[f.Runtime]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n=== Findings in runtime-managed trees (not counted as failures) ===")
+	for _, t := range []string{"flatpak", "snapd", "containers"} {
+		if counts[t] > 0 {
+			fmt.Fprintf(out, "  %s: %d finding(s)\n", t, counts[t])
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandbox confines the audit to read-only file system access with Landlock.
+// Plain audits, which produce nothing but their output, are confined this
+// way by default whenever the kernel supports Landlock; seccomp is not used
+// on top, as it sees the flags of an open but not the path, and helpers
+// such as systemd-tmpfiles legitimately open /dev/null for writing
+var sandbox bool
+
+// noSandbox turns off the default confinement of plain audits
+var noSandbox bool
+
+// sandboxedEnv marks the re-executed process that already runs confined
+const sandboxedEnv = "TMPFILES_AUDIT_SANDBOXED"
+
+// landlockReadAccess is what the audit needs: reading files and directories,
+// and executing helpers such as systemd-tmpfiles or xz
+const landlockReadAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR
+
+// landlockHandledAccess returns every file system right the kernel's
+// Landlock ABI version can restrict
+func landlockHandledAccess(abi int) uint64 {
+	handled := uint64(unix.LANDLOCK_ACCESS_FS_MAKE_SYM<<1 - 1)
+	if abi >= 2 {
+		handled |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		handled |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 5 {
+		handled |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+	return handled
+}
+
+// landlockAvailable reports whether the kernel supports Landlock
+func landlockAvailable() bool {
+	_, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	return errno == 0
+}
+
+// sandboxWrites returns the directories a run has to write to given its
+// options and command, creating them so Landlock can grant them; runs that
+// cannot work confined are an error
+func sandboxWrites(cmdName string, args []string) ([]string, error) {
+	if fixMode {
+		return nil, errors.New("--fix cannot run inside the read-only --sandbox")
+	}
+	var dirs []string
+	if oneshotService || transactionPre || transactionPost {
+		dirs = append(dirs, stateDir())
+	}
+	if resultsDir != "" {
+		dirs = append(dirs, resultsDir)
+	}
+	if updateBaseline {
+		dirs = append(dirs, filepath.Dir(baselineFile))
+	}
+	if imageBuildHook {
+		if path := imageBuildReportPath(); path != "" {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+	}
+	if annotatePR {
+		path := os.Getenv("TMPFILES_AUDIT_REPORT")
+		if path == "" {
+			path = imageBuildReportName
+		}
+		dirs = append(dirs, filepath.Dir(path))
+		if summary := os.Getenv("GITHUB_STEP_SUMMARY"); summary != "" {
+			dirs = append(dirs, filepath.Dir(summary))
+		}
+	}
+	// Unix sockets are created in their directory
+	for _, socket := range []string{varlinkSocket, querySocket} {
+		if socket != "" && cmdName != "tail" {
+			dirs = append(dirs, filepath.Dir(socket))
+		}
+	}
+	switch cmdName {
+	case "doctor":
+		dirs = append(dirs, stateDir())
+	case "snapshot":
+		if len(args) > 0 && args[0] == "save" {
+			dirs = append(dirs, snapshotDir())
+		}
+	case "manifest", "keygen", "sign":
+		if len(args) > 0 {
+			dirs = append(dirs, filepath.Dir(args[len(args)-1]))
+		}
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("preparing %s for the sandbox: %w", dir, err)
+		}
+	}
+	return dirs, nil
+}
+
+// plainAudit reports whether a run only audits and prints its results, and
+// so gets the read-only sandbox by default; matrix runs are not plain, as
+// the disk images among their roots are mounted first
+func plainAudit(cmdName string) bool {
+	if cmdName != "" || fixMode || watchMode || dbusService || oneshotService || transactionPre || transactionPost {
+		return false
+	}
+	if len(roots) > 1 || rootsFile != "" {
+		return false
+	}
+	return resultsDir == "" && !updateBaseline && !imageBuildHook && !annotatePR
+}
+
+// landlockAllow adds a rule granting access beneath path
+func landlockAllow(ruleset int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	attr := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&attr)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("adding Landlock rule for %s: %w", path, errno)
+	}
+	return nil
+}
+
+// enterSandbox applies a read-only Landlock ruleset and re-executes the
+// program, so that every thread of the new process is confined; writable
+// lists the only paths that may still be modified
+func enterSandbox(writable []string) error {
+	if os.Getenv(sandboxedEnv) != "" {
+		return nil
+	}
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return fmt.Errorf("landlock is not available: %w", errno)
+	}
+	handled := landlockHandledAccess(int(abi))
+	// Only the file system part of the attribute is passed, which every ABI understands
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	ruleset, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), 8, 0)
+	if errno != 0 {
+		return fmt.Errorf("creating Landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(ruleset))
+
+	if err := landlockAllow(int(ruleset), "/", landlockReadAccess); err != nil {
+		return err
+	}
+	// os/exec connects the output of helpers it does not capture to /dev/null
+	if err := landlockAllow(int(ruleset), os.DevNull, unix.LANDLOCK_ACCESS_FS_READ_FILE|unix.LANDLOCK_ACCESS_FS_WRITE_FILE); err != nil {
+		return err
+	}
+	for _, path := range writable {
+		if err := landlockAllow(int(ruleset), path, handled); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	// Landlock confines the calling thread, which the exec below turns into
+	// the only thread of the new process image
+	runtime.LockOSThread()
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("setting no_new_privs: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0); errno != 0 {
+		return fmt.Errorf("enforcing Landlock ruleset: %w", errno)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return unix.Exec(exe, os.Args, append(os.Environ(), sandboxedEnv+"=1"))
+}
@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// auditSchedule configures the daemon's periodic re-audits
+type auditSchedule struct {
+	Cron          string  `json:"cron"`                      // minute hour day-of-month month day-of-week
+	Jitter        string  `json:"jitter,omitempty"`          // random delay added to every slot, e.g. "15m"
+	MaxLoad       float64 `json:"max_load,omitempty"`        // postpone while the 1-minute load average is higher
+	MaxIOPressure float64 `json:"max_io_pressure,omitempty"` // postpone while "some" IO pressure (avg10, %) is higher
+
+	jitter time.Duration
+	fields [5]cronField
+}
+
+// cronField is the set of values one cron field matches
+type cronField map[int]bool
+
+// cronRanges are the value ranges of the five cron fields
+var cronRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+// parseCronField parses a comma-separated list of *, N, N-M, each
+// optionally followed by /STEP
+func parseCronField(spec string, lo, hi int) (cronField, error) {
+	field := make(cronField)
+	for _, part := range strings.Split(spec, ",") {
+		rng, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepSpec); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return nil, fmt.Errorf("invalid value in %q", part)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return nil, fmt.Errorf("invalid range in %q", part)
+				}
+			} else if hasStep {
+				to = hi
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return nil, fmt.Errorf("%q is outside %d-%d", part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			field[v] = true
+		}
+	}
+	return field, nil
+}
+
+// validate parses the schedule from the site config
+func (s *auditSchedule) validate() error {
+	specs := strings.Fields(s.Cron)
+	if len(specs) != 5 {
+		return fmt.Errorf("cron spec %q needs 5 fields", s.Cron)
+	}
+	for i, spec := range specs {
+		field, err := parseCronField(spec, cronRanges[i][0], cronRanges[i][1])
+		if err != nil {
+			return fmt.Errorf("cron spec %q: %w", s.Cron, err)
+		}
+		s.fields[i] = field
+	}
+	if s.Jitter != "" {
+		var err error
+		if s.jitter, err = time.ParseDuration(s.Jitter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matches reports whether the schedule has a slot at t's minute; like
+// cron, restricted day-of-month and day-of-week fields match either
+func (s *auditSchedule) matches(t time.Time) bool {
+	if !s.fields[0][t.Minute()] || !s.fields[1][t.Hour()] || !s.fields[3][int(t.Month())] {
+		return false
+	}
+	dom, dow := s.fields[2][t.Day()], s.fields[4][int(t.Weekday())]
+	if len(s.fields[2]) < 31 && len(s.fields[4]) < 7 {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// next returns the first slot after t, or the zero time if there is none
+// within a year (e.g. February 31st)
+func (s *auditSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(1, 0, 0); t.Before(limit); t = t.Add(time.Minute) {
+		if s.matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// readLoad returns the 1-minute load average
+func readLoad() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("malformed /proc/loadavg")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// readIOPressure returns the share of the last 10 seconds in which some
+// task waited for IO, in percent
+func readIOPressure() (float64, error) {
+	data, err := os.ReadFile("/proc/pressure/io")
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "some "); ok {
+			for _, kv := range strings.Fields(rest) {
+				if v, ok := strings.CutPrefix(kv, "avg10="); ok {
+					return strconv.ParseFloat(v, 64)
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("malformed /proc/pressure/io")
+}
+
+// busy reports why the host is too loaded for an audit, or "" if it isn't;
+// unreadable values don't hold audits back
+func (s *auditSchedule) busy() string {
+	if s.MaxLoad > 0 {
+		if load, err := readLoad(); err == nil && load > s.MaxLoad {
+			return fmt.Sprintf("load average %.2f exceeds %.2f", load, s.MaxLoad)
+		}
+	}
+	if s.MaxIOPressure > 0 {
+		if pressure, err := readIOPressure(); err == nil && pressure > s.MaxIOPressure {
+			return fmt.Sprintf("IO pressure %.2f%% exceeds %.2f%%", pressure, s.MaxIOPressure)
+		}
+	}
+	return ""
+}
+
+// busyRetry is how long a scheduled audit waits before checking the load again
+const busyRetry = time.Minute
+
+// scheduleChanged wakes the scheduler after the site config was reloaded
+var scheduleChanged = make(chan struct{}, 1)
+
+// currentSchedule returns the configured schedule, which a reload may replace
+func currentSchedule() *auditSchedule {
+	daemon.mu.Lock()
+	defer daemon.mu.Unlock()
+	return site.Schedule
+}
+
+// sleepUntil waits until t, returning false if the schedule changed meanwhile
+func sleepUntil(t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-scheduleChanged:
+		return false
+	}
+}
+
+// startScheduler runs the configured periodic audits in the background;
+// each slot is delayed by a random jitter so a fleet sharing storage doesn't
+// audit at the same moment, and postponed while the host is busy, at most
+// until the next slot
+func startScheduler() {
+	go func() {
+		for {
+			s := currentSchedule()
+			if s == nil {
+				<-scheduleChanged
+				continue
+			}
+			slot := s.next(time.Now())
+			if slot.IsZero() {
+				fmt.Fprintf(os.Stderr, "Warning: schedule %q never fires\n", s.Cron)
+				<-scheduleChanged
+				continue
+			}
+			at := slot
+			if s.jitter > 0 {
+				at = at.Add(rand.N(s.jitter))
+			}
+			if !sleepUntil(at) {
+				continue
+			}
+			following := s.next(slot)
+			for {
+				reason := s.busy()
+				if reason == "" {
+					daemonAudit()
+					break
+				}
+				if !following.IsZero() && time.Now().Add(busyRetry).After(following) {
+					fmt.Fprintf(os.Stderr, "Warning: skipping scheduled audit of %s: %s\n", slot.Format(time.DateTime), reason)
+					break
+				}
+				if !sleepUntil(time.Now().Add(busyRetry)) {
+					break
+				}
+			}
+		}
+	}()
+}
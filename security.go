@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// securityScan enables the --security check class
+var securityScan bool
+
+// runSecurityScan inspects symlink targets and the factory tree for
+// world-writable, setuid/setgid or unexpectedly owned files
+func runSecurityScan(list []rule) {
+	fmt.Fprintln(out, "\n=== Security scan of symlink targets and factory files ===")
+
+	// Each path is reported once, attributed to the first rule pointing at it
+	origin := make(map[string]rule)
+	for _, r := range list {
+		if r.Type != "L" {
+			continue
+		}
+		target := resolveStorePath(resolveTargetPath(r.Path, symlinkTarget(r)))
+		if _, ok := origin[target]; !ok {
+			origin[target] = r
+		}
+	}
+	for _, root := range factoryRoots() {
+		factory := rootPath(root)
+		filepath.WalkDir(factory, func(host string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			notifyWatchdog()
+			path := root + strings.TrimPrefix(host, factory)
+			if _, ok := origin[path]; !ok {
+				origin[path] = rule{}
+			}
+			return nil
+		})
+	}
+
+	paths := make([]string, 0, len(origin))
+	for path := range origin {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	issues := 0
+	for _, path := range paths {
+		issues += checkPathSecurity(path, origin[path])
+	}
+	if issues == 0 {
+		fmt.Fprintf(out, "%s✓ No insecure targets or factory files%s\n", colorGreen, colorReset)
+	}
+}
+
+// checkPathSecurity reports the security problems of one path, returning how many it found
+func checkPathSecurity(path string, r rule) int {
+	fi, err := statPath(path)
+	if err != nil {
+		return 0
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	// Device nodes like /dev/null are world-writable by design
+	if !ok || !fi.Mode().IsRegular() && !fi.IsDir() {
+		return 0
+	}
+	report := func(category, severity, message string) {
+		color := colorYellow
+		if severity == severityError {
+			color = colorBoldRed
+		}
+		fmt.Fprintf(out, "%s%s %s: %s%s\n", color, map[string]string{severityError: "✗", severityWarning: "⚠"}[severity], path, message, colorReset)
+		f := Finding{Category: category, Severity: severity, Path: path, Message: message}
+		// Factory files no rule refers to are reported under their own path
+		if r.Path != "" {
+			f.Path, f.Target, f.ConfFile, f.Line = r.Path, path, r.ConfFile, r.Line
+		}
+		addFinding(f)
+	}
+
+	issues := 0
+	mode := st.Mode & 07777
+	// Sticky world-writable directories like /tmp are the one safe exception
+	if mode&0002 != 0 && !(fi.IsDir() && mode&01000 != 0) {
+		report(catWorldWritable, severityError, fmt.Sprintf("world-writable (%04o)", mode))
+		issues++
+	}
+	if !fi.IsDir() && mode&(syscall.S_ISUID|syscall.S_ISGID) != 0 {
+		report(catSetuidTarget, severityError, fmt.Sprintf("setuid/setgid (%04o)", mode))
+		issues++
+	}
+	if st.Uid != 0 {
+		if parent, err := statPath(filepath.Dir(path)); err == nil {
+			if pst, ok := parent.Sys().(*syscall.Stat_t); ok && pst.Uid == 0 {
+				report(catNonRootOwner, severityWarning, fmt.Sprintf("owned by %s inside root-owned %s", userName(st.Uid), filepath.Dir(path)))
+				issues++
+			}
+		}
+	}
+	return issues
+}
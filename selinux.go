@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+//go:build selinux
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileContext is one file_contexts specification
+type fileContext struct {
+	pattern  *regexp.Regexp
+	fileType string // "" matches any type, otherwise e.g. "--" or "-d"
+	context  string
+}
+
+var (
+	fileContexts       []fileContext
+	fileContextsLoaded bool
+)
+
+// selinuxPolicyType reads SELINUXTYPE from /etc/selinux/config
+func selinuxPolicyType() string {
+	if t := readKeyFile("/etc/selinux/config")["SELINUXTYPE"]; t != "" {
+		return t
+	}
+	return "targeted"
+}
+
+// loadFileContexts parses the policy's file_contexts and its local additions
+func loadFileContexts() []fileContext {
+	if fileContextsLoaded {
+		return fileContexts
+	}
+	fileContextsLoaded = true
+	base := "/etc/selinux/" + selinuxPolicyType() + "/contexts/files/file_contexts"
+	for _, path := range []string{base, base + ".homedirs", base + ".local"} {
+		for _, line := range readLines(path) {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			re, err := regexp.Compile("^(" + fields[0] + ")$")
+			if err != nil {
+				continue
+			}
+			fc := fileContext{pattern: re, context: fields[len(fields)-1]}
+			if len(fields) == 3 {
+				fc.fileType = fields[1]
+			}
+			fileContexts = append(fileContexts, fc)
+		}
+	}
+	return fileContexts
+}
+
+// fileContextType maps a file mode to its file_contexts type specifier
+func fileContextType(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return "-l"
+	case mode.IsDir():
+		return "-d"
+	case mode&fs.ModeNamedPipe != 0:
+		return "-p"
+	case mode&fs.ModeSocket != 0:
+		return "-s"
+	case mode&fs.ModeCharDevice != 0:
+		return "-c"
+	case mode&fs.ModeDevice != 0:
+		return "-b"
+	}
+	return "--"
+}
+
+// defaultContext looks up the label file_contexts assigns to a path; like
+// libselinux, the last matching specification wins
+func defaultContext(path string, mode fs.FileMode) (string, bool) {
+	specs := loadFileContexts()
+	kind := fileContextType(mode)
+	for i := len(specs) - 1; i >= 0; i-- {
+		if (specs[i].fileType == "" || specs[i].fileType == kind) && specs[i].pattern.MatchString(path) {
+			return specs[i].context, specs[i].context != "<<none>>"
+		}
+	}
+	return "", false
+}
+
+// currentContext reads a path's security.selinux attribute without following symlinks
+func currentContext(path string) (string, error) {
+	parent, err := resolveInRoot(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	host := rootPath(filepath.Join(parent, filepath.Base(path)))
+	buf := make([]byte, 256)
+	n, err := unix.Lgetxattr(host, "security.selinux", buf)
+	if err == unix.ERANGE {
+		if n, err = unix.Lgetxattr(host, "security.selinux", nil); err == nil {
+			buf = make([]byte, n)
+			n, err = unix.Lgetxattr(host, "security.selinux", buf)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(buf[:n]), "\x00"), nil
+}
+
+// checkSELinux compares the labels of paths a z/Z rule restores with the
+// file_contexts default, which is what systemd-tmpfiles relabels to; an
+// argument is ignored by z and Z and reported by checkIgnoredFields
+func checkSELinux(r rule) {
+	for _, path := range rulePaths(r) {
+		if r.Type == "Z" {
+			filepath.WalkDir(rootPath(path), func(host string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				notifyWatchdog()
+				checkContext(r, filepath.Join(path, strings.TrimPrefix(host, rootPath(path))))
+				return nil
+			})
+			continue
+		}
+		checkContext(r, path)
+	}
+}
+
+// checkContext reports a single path whose label differs from what r expects
+func checkContext(r rule, path string) {
+	fi, err := lstatPath(path)
+	if err != nil {
+		return
+	}
+	want, ok := defaultContext(path, fi.Mode())
+	if !ok {
+		return
+	}
+	got, err := currentContext(path)
+	if err != nil {
+		if err == unix.ENODATA || err == unix.ENOTSUP {
+			got = "(unlabeled)"
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: cannot read SELinux label of %s: %v\n", path, err)
+			return
+		}
+	}
+	if got == want {
+		return
+	}
+	fmt.Fprintf(out, "%s⚠ SELinux label mismatch: %s is %s, expected %s (%s:%d)%s\n", colorYellow, path, got, want, r.ConfFile, r.Line, colorReset)
+	addFinding(Finding{Category: catSELinuxLabel, Severity: severityWarning, Path: path, Target: want, ConfFile: r.ConfFile, Line: r.Line,
+		Message: "SELinux context is " + got})
+}
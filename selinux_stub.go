@@ -0,0 +1,9 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+//go:build !selinux
+
+package main
+
+// checkSELinux is a no-op unless built with the selinux tag
+func checkSELinux(r rule) {}
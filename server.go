@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+)
+
+// defaultListenAddr is where serve listens without --listen
+const defaultListenAddr = ":8090"
+
+// writeJSON answers a request with a JSON document
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeJSONError answers a request with {"error": message}
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// auditResult is the answer to POST /audit
+type auditResult struct {
+	Audit    int       `json:"audit"`
+	Findings int       `json:"findings"`
+	Added    []Finding `json:"added"`
+	Resolved []Finding `json:"resolved"`
+}
+
+// serveAudit runs an audit and returns what changed since the previous one
+func serveAudit(w http.ResponseWriter, r *http.Request) {
+	added, resolved, err := daemonAudit()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	run := daemon.snapshot()
+	writeJSON(w, http.StatusOK, auditResult{Audit: run.Audits, Findings: len(run.Report.Findings),
+		Added: append([]Finding{}, added...), Resolved: append([]Finding{}, resolved...)})
+}
+
+// serveReport returns the report of the latest successful audit
+func serveReport(w http.ResponseWriter, r *http.Request) {
+	run := daemon.snapshot()
+	if run.Report.Tool == "" {
+		writeJSONError(w, http.StatusServiceUnavailable, "no audit has completed yet")
+		return
+	}
+	writeJSON(w, http.StatusOK, run.Report)
+}
+
+// snapshotSummary describes a saved snapshot in GET /snapshots
+type snapshotSummary struct {
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	Rules    int       `json:"rules"`
+	Findings int       `json:"findings"`
+}
+
+// serveSnapshotList lists the saved snapshots, oldest first
+func serveSnapshotList(w http.ResponseWriter, r *http.Request) {
+	list, err := listSnapshots()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	summaries := make([]snapshotSummary, 0, len(list))
+	for _, s := range list {
+		summaries = append(summaries, snapshotSummary{s.name, s.report.Created, len(s.report.Rules), len(s.report.Findings)})
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// serveSnapshot returns one saved snapshot
+func serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	path, err := snapshotPath(r.PathValue("name"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	report, err := loadReport(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		writeJSONError(w, http.StatusNotFound, "no snapshot named "+r.PathValue("name"))
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// serveEvents streams the findings of future audits as server-sent events
+func serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	events, cancel := daemon.subscribe()
+	defer cancel()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-events:
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Event, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// runServe audits on start and then on request over HTTP; with --watch,
+// changes to the watched directories trigger audits as well
+func runServe(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: serve [--listen ADDR] [--watch]")
+		return 2
+	}
+	if listenAddr == "" {
+		listenAddr = defaultListenAddr
+	}
+	if watchMode {
+		return runWatch()
+	}
+	// Results are only exposed over HTTP
+	out = io.Discard
+	if err := startListeners(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	daemonAudit()
+	startScheduler()
+	handleReloads()
+	sdNotify("READY=1")
+	select {}
+}
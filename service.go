@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// oneshotService runs the audit as tmpfiles-audit.service: no terminal
+// output, findings go to the journal, and only changes since the previous
+// run are logged
+var oneshotService bool
+
+// finishOneshotService compares this run's findings with the previous run,
+// logs the delta to the journal and saves the new state
+func finishOneshotService() {
+	statePath := filepath.Join(stateDir(), "last-findings.json")
+	previous, err := loadFindings(statePath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring previous state: %v\n", err)
+	}
+	added, resolved := diffFindings(previous, findings)
+
+	if err := logToJournal(added); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	errors := 0
+	for _, f := range findings {
+		if f.Severity == severityError {
+			errors++
+		}
+	}
+	summary := fmt.Sprintf("tmpfiles audit finished: %d finding(s), %d error(s); %d new, %d resolved since the last run",
+		len(findings), errors, len(added), len(resolved))
+	priority := 6
+	if errors > 0 {
+		priority = 3
+	}
+	if err := logMessageToJournal(priority, summary); err != nil {
+		// Without journald, stderr still ends up in the service's log
+		fmt.Fprintln(os.Stderr, summary)
+	}
+
+	if err := saveFindings(statePath, findings); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: saving state: %v\n", err)
+	}
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// signingKey is the PEM key file used by sign (private) and verify-signature (public)
+var signingKey string
+
+func signingFlags(fs *flag.FlagSet) {
+	fs.StringVar(&signingKey, "key", "", "PEM Ed25519 key: private for sign, public for verify-signature")
+}
+
+// readPEM reads the single PEM block of a key file
+func readPEM(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+	return block.Bytes, nil
+}
+
+// readPrivateKey loads a PKCS#8 Ed25519 private key
+func readPrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+	}
+	return priv, nil
+}
+
+// readPublicKey loads a PKIX Ed25519 public key
+func readPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+	}
+	return pub, nil
+}
+
+// runKeygen implements `keygen PREFIX`, writing PREFIX and PREFIX.pub
+func runKeygen(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: keygen PREFIX")
+		return 2
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	if err := os.WriteFile(args[0], pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(args[0]+".pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(out, "%s✓ Wrote %s and %s.pub%s\n", colorGreen, args[0], args[0], colorReset)
+	return 0
+}
+
+// runSign implements `sign --key KEY REPORT`; the detached signature covers
+// the report bytes, including the host and creation time recorded in it
+func runSign(args []string) int {
+	if len(args) != 1 || signingKey == "" {
+		fmt.Fprintln(os.Stderr, "Usage: sign --key PRIVATE-KEY REPORT")
+		return 2
+	}
+	priv, err := readPrivateKey(signingKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	// Only sign what verification can later interpret as a report
+	if _, err := loadReport(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data))
+	if err := os.WriteFile(args[0]+".sig", []byte(sig+"\n"), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(out, "%s✓ Signed %s, signature in %s.sig%s\n", colorGreen, args[0], args[0], colorReset)
+	return 0
+}
+
+// verifyReportSignature checks a report against its detached signature
+func verifyReportSignature(pub ed25519.PublicKey, reportFile, sigFile string) error {
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		return err
+	}
+	encoded, err := os.ReadFile(sigFile)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return fmt.Errorf("%s: %w", sigFile, err)
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// runVerifySignature implements `verify-signature --key PUBLIC-KEY REPORT [SIGNATURE]`
+func runVerifySignature(args []string) int {
+	if len(args) < 1 || len(args) > 2 || signingKey == "" {
+		fmt.Fprintln(os.Stderr, "Usage: verify-signature --key PUBLIC-KEY REPORT [SIGNATURE]")
+		return 2
+	}
+	pub, err := readPublicKey(signingKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	sigFile := args[0] + ".sig"
+	if len(args) == 2 {
+		sigFile = args[1]
+	}
+	if err := verifyReportSignature(pub, args[0], sigFile); err != nil {
+		fmt.Fprintf(out, "%s✗ %s: %v%s\n", colorRed, args[0], err, colorReset)
+		return 1
+	}
+	r, err := loadReport(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	host := r.Host
+	if host == "" {
+		host = r.Root
+	}
+	fmt.Fprintf(out, "%s✓ Good signature: report of %s created %s%s\n", colorGreen, host, r.Created.Format("2006-01-02 15:04:05 MST"), colorReset)
+	return 0
+}
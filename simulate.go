@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// simulateRemove names a package whose removal is simulated
+	simulateRemove string
+
+	// removedFiles hides the files of simulateRemove from the audited system;
+	// nil when no removal is simulated
+	removedFiles map[string]bool
+)
+
+// startRemovalSimulation hides every file of pkg so the audit sees the system
+// as it would look after the package is removed
+func startRemovalSimulation(pkg string) error {
+	paths := pkgDB.files(pkg)
+	if len(paths) == 0 {
+		return fmt.Errorf("package %s is not installed or ships no files", pkg)
+	}
+	removedFiles = make(map[string]bool)
+	var confs []string
+	for _, path := range paths {
+		if fi, err := statPath(path); err != nil || fi.IsDir() {
+			// Directories usually stay behind while other packages still use them
+			continue
+		}
+		removedFiles[path] = true
+		if alias := usrMergeAlias(path); alias != "" {
+			removedFiles[alias] = true
+		}
+		if strings.Contains(path, "/tmpfiles.d/") && strings.HasSuffix(path, ".conf") {
+			confs = append(confs, path)
+		}
+	}
+	fmt.Fprintf(out, "=== Simulating removal of %s package %s (%d files) ===\n", pkgDB.name(), pkg, len(removedFiles))
+	for _, conf := range confs {
+		fmt.Fprintf(out, "   %s⤷ Rules from %s would no longer apply%s\n", colorYellow, conf, colorReset)
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+// printRemovalSummary lists the rule targets that would dangle once the
+// simulated package is gone
+func printRemovalSummary() {
+	if removedFiles == nil {
+		return
+	}
+	var dangling []string
+	for _, f := range findings {
+		if f.Category == catDanglingAfterRemoval {
+			dangling = append(dangling, fmt.Sprintf("%s -> %s (%s:%d)", f.Path, f.Target, f.ConfFile, f.Line))
+		}
+	}
+	sort.Strings(dangling)
+	fmt.Fprintf(out, "\n=== Removing %s ===\n", simulateRemove)
+	if len(dangling) == 0 {
+		fmt.Fprintf(out, "  %s✓ No tmpfiles.d symlink would dangle%s\n", colorGreen, colorReset)
+		return
+	}
+	fmt.Fprintf(out, "  %sSymlinks that would dangle afterwards:%s\n", colorRed, colorReset)
+	for _, d := range dangling {
+		fmt.Fprintf(out, "    %s\n", d)
+	}
+}
+
+// missingTargetCategory classifies a missing target, separating targets that
+// only disappear because of the simulated package removal
+func missingTargetCategory(target string) string {
+	if removedFiles[target] {
+		return catDanglingAfterRemoval
+	}
+	return catMissingTarget
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// defaultSiteConfig is read when --config is not given; it may be absent
+const defaultSiteConfig = "/etc/tmpfiles-audit/config.json"
+
+// siteConfigFile names the site configuration to load
+var siteConfigFile string
+
+// severityOverride remaps the severity of findings of one code or category
+// whose path matches a glob, or lies below it if it has no glob characters
+type severityOverride struct {
+	Code     string `json:"code"`
+	Path     string `json:"path"`
+	Severity string `json:"severity"`
+}
+
+// siteConfig is the site-wide configuration of the auditor
+type siteConfig struct {
+	SeverityOverrides []severityOverride `json:"severity_overrides,omitempty"`
+	Webhooks          []webhook          `json:"webhooks,omitempty"`
+	Schedule          *auditSchedule     `json:"schedule,omitempty"`
+	FactoryRoot       string             `json:"factory_root,omitempty"`
+	FactoryRoots      []string           `json:"factory_roots,omitempty"` // searched in order, overrides factory_root
+	BaseDirs          []string           `json:"base_dirs,omitempty"`     // exempt from completeness checks, below too with a trailing slash
+	Provides          []provideMapping   `json:"provides,omitempty"`      // paths of optional packages, absent when not installed
+}
+
+// site holds the loaded site configuration
+var site siteConfig
+
+// loadSiteConfig reads the site configuration; a missing default file is not an error,
+// and the current configuration is kept if the file is invalid
+func loadSiteConfig() error {
+	path := siteConfigFile
+	if path == "" {
+		path = defaultSiteConfig
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && siteConfigFile == "" {
+		site = siteConfig{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var cfg siteConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for i, o := range cfg.SeverityOverrides {
+		if _, ok := lookupCheck(o.Code); !ok {
+			return fmt.Errorf("%s: severity override %d: unknown code %q", path, i+1, o.Code)
+		}
+		if !slices.Contains([]string{severityError, severityWarning, severityInfo}, o.Severity) {
+			return fmt.Errorf("%s: severity override %d: unknown severity %q", path, i+1, o.Severity)
+		}
+		if _, err := filepath.Match(o.Path, "/"); err != nil {
+			return fmt.Errorf("%s: severity override %d: %w", path, i+1, err)
+		}
+	}
+	for i := range cfg.Webhooks {
+		if err := cfg.Webhooks[i].validate(); err != nil {
+			return fmt.Errorf("%s: webhook %d: %w", path, i+1, err)
+		}
+	}
+	if cfg.FactoryRoot != "" {
+		if cfg.FactoryRoot, err = checkFactoryRoot(cfg.FactoryRoot); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for i := range cfg.FactoryRoots {
+		if cfg.FactoryRoots[i], err = checkFactoryRoot(cfg.FactoryRoots[i]); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for i := range cfg.BaseDirs {
+		if cfg.BaseDirs[i], err = checkBaseDir(cfg.BaseDirs[i]); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for i, m := range cfg.Provides {
+		if err := m.validate(); err != nil {
+			return fmt.Errorf("%s: provides %d: %w", path, i+1, err)
+		}
+	}
+	if cfg.Schedule != nil {
+		if err := cfg.Schedule.validate(); err != nil {
+			return fmt.Errorf("%s: schedule: %w", path, err)
+		}
+	}
+	site = cfg
+	return nil
+}
+
+// matches reports whether an override applies to a finding
+func (o severityOverride) matches(f Finding) bool {
+	c, _ := lookupCheck(o.Code)
+	if c.Category != f.Category {
+		return false
+	}
+	if o.Path == "" || underPrefix(f.Path, filepath.Clean(o.Path)) {
+		return true
+	}
+	ok, _ := filepath.Match(o.Path, f.Path)
+	return ok
+}
+
+// applySeverityOverrides sets a finding's severity from the last matching
+// site override
+func applySeverityOverrides(f *Finding) {
+	for _, o := range site.SeverityOverrides {
+		if o.matches(*f) {
+			f.Severity = o.Severity
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// slotDiff is the difference between the two slots of an A/B system
+type slotDiff struct {
+	A               string       `json:"a"`
+	B               string       `json:"b"`
+	RulesOnlyInA    []rule       `json:"rules_only_in_a"`
+	RulesOnlyInB    []rule       `json:"rules_only_in_b"`
+	ChangedRules    []ruleChange `json:"changed_rules"`
+	FactoryOnlyInA  []string     `json:"factory_only_in_a"`
+	FactoryOnlyInB  []string     `json:"factory_only_in_b"`
+	FactoryChanged  []string     `json:"factory_changed"`
+	FindingsOnlyInA []Finding    `json:"findings_only_in_a"`
+	FindingsOnlyInB []Finding    `json:"findings_only_in_b"`
+}
+
+// differs reports whether the slots disagree in anything
+func (d slotDiff) differs() bool {
+	return len(d.RulesOnlyInA)+len(d.RulesOnlyInB)+len(d.ChangedRules)+len(d.FactoryOnlyInA)+
+		len(d.FactoryOnlyInB)+len(d.FactoryChanged)+len(d.FindingsOnlyInA)+len(d.FindingsOnlyInB) > 0
+}
+
+// factoryInventory maps every file below the factory roots of the audited
+// system to its SHA-256 digest
+func factoryInventory() map[string]string {
+	files := make(map[string]string)
+	var walk func(dir string)
+	walk = func(dir string) {
+		entries, err := listDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			if e.IsDir() {
+				walk(path)
+			} else {
+				files[path] = fileDigest(path, sha256.New())
+			}
+		}
+	}
+	for _, root := range factoryRoots() {
+		walk(root)
+	}
+	return files
+}
+
+// auditSlot audits a slot, a root directory or an image, returning its
+// report and factory inventory
+func auditSlot(slot string) (Report, map[string]string, error) {
+	dir := slot
+	if fi, err := os.Stat(slot); err != nil {
+		return Report{}, nil, err
+	} else if !fi.IsDir() {
+		mnt, cleanup, err := mountImage(slot)
+		if err != nil {
+			return Report{}, nil, fmt.Errorf("cannot mount slot %s: %w", slot, err)
+		}
+		defer cleanup()
+		dir = mnt
+	}
+	savedRoot := rootDir
+	rootDir = dir
+	inventory := factoryInventory()
+	rootDir = savedRoot
+	r, err := auditRoot(dir)
+	r.Root = slot
+	return r, inventory, err
+}
+
+// diffSlots compares the reports and factory inventories of two slots
+func diffSlots(a, b Report, factoryA, factoryB map[string]string) slotDiff {
+	rd := diffReports(a, b)
+	d := slotDiff{A: a.Root, B: b.Root, RulesOnlyInA: rd.RemovedRules, RulesOnlyInB: rd.AddedRules, ChangedRules: rd.ChangedRules,
+		FindingsOnlyInA: rd.ResolvedFindings, FindingsOnlyInB: rd.AddedFindings}
+	for path, digest := range factoryA {
+		other, ok := factoryB[path]
+		switch {
+		case !ok:
+			d.FactoryOnlyInA = append(d.FactoryOnlyInA, path)
+		case other != digest:
+			d.FactoryChanged = append(d.FactoryChanged, path)
+		}
+	}
+	for path := range factoryB {
+		if _, ok := factoryA[path]; !ok {
+			d.FactoryOnlyInB = append(d.FactoryOnlyInB, path)
+		}
+	}
+	sort.Strings(d.FactoryOnlyInA)
+	sort.Strings(d.FactoryOnlyInB)
+	sort.Strings(d.FactoryChanged)
+	return d
+}
+
+// runSlotDiff implements `slot-diff A B`, failing when the slots differ
+func runSlotDiff(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: slot-diff A B (root directories, images or partitions)")
+		return 2
+	}
+	var reports [2]Report
+	var inventories [2]map[string]string
+	for i, slot := range args {
+		r, inventory, err := auditSlot(slot)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		reports[i], inventories[i] = r, inventory
+	}
+	d := diffSlots(reports[0], reports[1], inventories[0], inventories[1])
+
+	if outputFormat == "json" {
+		data, _ := json.MarshalIndent(d, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+	} else {
+		printSlotDiff(d)
+	}
+	if d.differs() {
+		return 1
+	}
+	return 0
+}
+
+// printSlotDiff renders a slot diff for humans
+func printSlotDiff(d slotDiff) {
+	fmt.Fprintf(out, "=== Slot A: %s, slot B: %s ===\n", d.A, d.B)
+	if !d.differs() {
+		fmt.Fprintf(out, "%s✓ Both slots have the same rules, factory content and findings%s\n", colorGreen, colorReset)
+		return
+	}
+	fmt.Fprintf(out, "\nRules: %d only in A, %d only in B, %d changed\n", len(d.RulesOnlyInA), len(d.RulesOnlyInB), len(d.ChangedRules))
+	for _, r := range d.RulesOnlyInA {
+		fmt.Fprintf(out, "%sA %s%s\n", colorYellow, formatRule(r), colorReset)
+	}
+	for _, r := range d.RulesOnlyInB {
+		fmt.Fprintf(out, "%sB %s%s\n", colorYellow, formatRule(r), colorReset)
+	}
+	for _, c := range d.ChangedRules {
+		fmt.Fprintf(out, "%s~ A: %s\n  B: %s%s\n", colorYellow, formatRule(c.Old), formatRule(c.New), colorReset)
+	}
+	fmt.Fprintf(out, "\nFactory content: %d only in A, %d only in B, %d changed\n", len(d.FactoryOnlyInA), len(d.FactoryOnlyInB), len(d.FactoryChanged))
+	for _, path := range d.FactoryOnlyInA {
+		fmt.Fprintf(out, "%sA %s%s\n", colorYellow, path, colorReset)
+	}
+	for _, path := range d.FactoryOnlyInB {
+		fmt.Fprintf(out, "%sB %s%s\n", colorYellow, path, colorReset)
+	}
+	for _, path := range d.FactoryChanged {
+		fmt.Fprintf(out, "%s~ %s%s\n", colorYellow, path, colorReset)
+	}
+	fmt.Fprintf(out, "\nFindings: %d only in A, %d only in B\n", len(d.FindingsOnlyInA), len(d.FindingsOnlyInB))
+	for _, f := range d.FindingsOnlyInA {
+		fmt.Fprintf(out, "%sA %s %s: %s%s\n", colorRed, f.Category, f.Path, f.Message, colorReset)
+	}
+	for _, f := range d.FindingsOnlyInB {
+		fmt.Fprintf(out, "%sB %s %s: %s%s\n", colorRed, f.Category, f.Path, f.Message, colorReset)
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// snapshotDir holds named reports saved with `snapshot save`
+func snapshotDir() string {
+	return filepath.Join(stateDir(), "snapshots")
+}
+
+// snapshotPath maps a snapshot name to its file, rejecting names that are not plain
+func snapshotPath(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\x00") {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return filepath.Join(snapshotDir(), name+".json"), nil
+}
+
+// snapshot is a report saved under a name
+type snapshot struct {
+	name   string
+	report Report
+}
+
+// listSnapshots returns the saved snapshots, oldest first
+func listSnapshots() ([]snapshot, error) {
+	matches, err := filepath.Glob(filepath.Join(snapshotDir(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var list []snapshot
+	for _, path := range matches {
+		r, err := loadReport(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping snapshot: %v\n", err)
+			continue
+		}
+		list = append(list, snapshot{strings.TrimSuffix(filepath.Base(path), ".json"), r})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].report.Created.Before(list[j].report.Created) })
+	return list, nil
+}
+
+// runSnapshot implements `snapshot save NAME` and `snapshot list`
+func runSnapshot(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: snapshot save NAME | snapshot list")
+		return 2
+	}
+	switch args[0] {
+	case "save":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: snapshot save NAME")
+			return 2
+		}
+		path, err := snapshotPath(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if err := quietAudit(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if err := os.MkdirAll(snapshotDir(), 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if err := saveReport(path, currentReport()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving snapshot: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(out, "%s✓ Saved snapshot %s: %d rules, %d finding(s)%s\n", colorGreen, args[1], len(parsedRules), len(findings), colorReset)
+	case "list":
+		list, err := listSnapshots()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, snap := range list {
+			r := snap.report
+			errors, warnings := 0, 0
+			for _, f := range r.Findings {
+				switch f.Severity {
+				case severityError:
+					errors++
+				case severityWarning:
+					warnings++
+				}
+			}
+			fmt.Fprintf(out, "%-24s %s  %s  %d rules, %d errors, %d warnings\n", snap.name, r.Created.Format("2006-01-02 15:04:05"), r.Root, len(r.Rules), errors, warnings)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown snapshot action %q\n", args[0])
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// checkSoftReboot reports which rule paths survive systemctl soft-reboot
+var checkSoftReboot bool
+
+// Where a rule path ends up after a soft-reboot
+const (
+	softPreserved  = "preserved" // volatile, but kept because the kernel keeps running
+	softRecreated  = "recreated" // unmounted and mounted afresh
+	softPersistent = "persistent"
+)
+
+// staleProneTypes create content that an existing path keeps unless the
+// rule carries the + modifier
+const staleProneTypes = "LfCcbp"
+
+// tmpIsTmpfs reports whether /tmp is a tmpfs, which soft-reboot unmounts
+// along with the other units; an image has to declare it through tmp.mount
+func tmpIsTmpfs() bool {
+	if rootDir == "" {
+		if mounts, err := readMounts(); err == nil {
+			if m, ok := mountOf(mounts, "/tmp"); ok {
+				return m.FSType == "tmpfs"
+			}
+		}
+	}
+	for _, dir := range unitDirs {
+		if types := readUnitSettings(dir+"/tmp.mount", "Type"); len(types) > 0 {
+			return types[0] == "tmpfs"
+		}
+	}
+	return false
+}
+
+// softRebootFate classifies a path: /run and /dev outlive the userspace,
+// a tmpfs /tmp is remounted, and the rest lives on persistent storage
+func softRebootFate(path string, tmpfsTmp bool) string {
+	switch {
+	case underPrefix(path, "/run/nextroot"):
+		return softRecreated
+	case underPrefix(path, "/run"), underPrefix(path, "/dev"):
+		return softPreserved
+	case underPrefix(path, "/tmp") && tmpfsTmp:
+		return softRecreated
+	}
+	return softPersistent
+}
+
+// runSoftRebootCheck reports which rule paths are preserved across
+// systemctl soft-reboot although a full reboot recreates them, and the
+// rules that would therefore keep stale content from before
+func runSoftRebootCheck(list []rule) {
+	fmt.Fprintln(out, "\n=== Soft-reboot survivability ===")
+	tmpfsTmp := tmpIsTmpfs()
+	counts := make(map[string]int)
+	stale := 0
+	for _, r := range list {
+		if !inAuditScope(r) {
+			continue
+		}
+		fate := softRebootFate(globFreeParent(r.Path), tmpfsTmp)
+		counts[fate]++
+		if fate != softPreserved || !strings.Contains(staleProneTypes, r.Type) || r.hasModifier('+') {
+			continue
+		}
+		stale++
+		what := "existing content"
+		if r.Type == "L" {
+			what = "an existing link"
+			if target, err := readlinkPath(r.Path); err == nil && target != symlinkTarget(r) {
+				what = "the existing link to " + target
+			}
+		}
+		fmt.Fprintf(out, "%s⚠ %s %s keeps %s across soft-reboot (%s:%d)%s\n", colorYellow, r.Type, r.Path, what, r.ConfFile, r.Line, colorReset)
+		fmt.Fprintf(out, "   ⤷ %s is not emptied by soft-reboot; use %s+ if the next root may declare something else\n", filepath.Dir(r.Path), r.Type)
+		addFinding(Finding{Category: catSoftRebootStale, Severity: severityWarning, Path: r.Path, Target: r.Argument, ConfFile: r.ConfFile, Line: r.Line,
+			Message: fmt.Sprintf("%s rule without + keeps %s across soft-reboot", r.Type, what)})
+	}
+	fmt.Fprintf(out, "Preserved across soft-reboot, recreated only by a full reboot: %d rule(s) under /run or /dev\n", counts[softPreserved])
+	fmt.Fprintf(out, "Recreated by soft-reboot: %d rule(s)", counts[softRecreated])
+	if !tmpfsTmp {
+		fmt.Fprint(out, " (/tmp is not a tmpfs and is kept)")
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "On persistent storage: %d rule(s)\n", counts[softPersistent])
+	if stale == 0 {
+		fmt.Fprintf(out, "%s✓ No rule keeps stale content across soft-reboot%s\n", colorGreen, colorReset)
+	}
+}
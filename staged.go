@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+var (
+	// stagedMode audits only the fragments of a git commit in preparation
+	stagedMode bool
+	// stagedSources maps each audited fragment to the repository file it came from
+	stagedSources map[string]string
+)
+
+// stagedInstallPath names the fragment a repository file installs as, or ""
+// if it is not a tmpfiles.d fragment: *.conf in a tmpfiles.d directory, or
+// debhelper's debian/<package>.tmpfiles and .tmpfile
+func stagedInstallPath(file string, explicit bool) string {
+	base := path.Base(file)
+	switch {
+	case strings.HasSuffix(base, ".conf") && (explicit || path.Base(path.Dir(file)) == "tmpfiles.d"):
+		return "/usr/lib/tmpfiles.d/" + base
+	case strings.HasSuffix(base, ".tmpfiles"):
+		return "/usr/lib/tmpfiles.d/" + strings.TrimSuffix(base, ".tmpfiles") + ".conf"
+	case strings.HasSuffix(base, ".tmpfile"):
+		return "/usr/lib/tmpfiles.d/" + strings.TrimSuffix(base, ".tmpfile") + ".conf"
+	}
+	return ""
+}
+
+// loadStagedFiles collects the fragments to audit: the given files as they
+// are in the working tree, as the pre-commit framework passes them, or else
+// the added and modified fragments in the git index, with their staged content
+func loadStagedFiles(list []string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	stagedSources = make(map[string]string)
+	add := func(file string, data []byte, explicit bool) {
+		if installed := stagedInstallPath(file, explicit); installed != "" {
+			files[installed] = data
+			stagedSources[installed] = file
+		}
+	}
+	if len(list) > 0 {
+		for _, file := range list {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			add(file, data, true)
+		}
+		return files, nil
+	}
+
+	output, err := exec.Command("git", "diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z").Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing staged files: %w", err)
+	}
+	for _, file := range strings.Split(strings.TrimRight(string(output), "\x00"), "\x00") {
+		if file == "" || stagedInstallPath(file, false) == "" {
+			continue
+		}
+		var stderr bytes.Buffer
+		cmd := exec.Command("git", "show", ":"+file)
+		cmd.Stderr = &stderr
+		data, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("reading staged %s: %s", file, strings.TrimSpace(stderr.String()))
+		}
+		add(file, data, false)
+	}
+	return files, nil
+}
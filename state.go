@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultStateDir holds persistent audit state such as the last run's findings
+const defaultStateDir = "/var/lib/tmpfiles-audit"
+
+// stateDir returns the state directory, preferring the one systemd passes
+// via StateDirectory= when running as a service
+func stateDir() string {
+	if dir := os.Getenv("STATE_DIRECTORY"); dir != "" {
+		// Several directories are separated by colons; ours is the first
+		dir, _, _ = strings.Cut(dir, ":")
+		return dir
+	}
+	return defaultStateDir
+}
+
+// findingKey identifies a finding across runs, ignoring line numbers since
+// they shift whenever a fragment is edited
+func findingKey(f Finding) string {
+	return strings.Join([]string{f.Category, f.Path, f.Target, f.ConfFile}, "\x00")
+}
+
+// loadFindings reads a findings list saved by saveFindings
+func loadFindings(path string) ([]Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []Finding
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return list, nil
+}
+
+// saveFindings atomically writes a findings list as JSON
+func saveFindings(path string, list []Finding) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// diffFindings splits findings into those absent from previous and the
+// previous ones that no longer occur
+func diffFindings(previous, current []Finding) (added, resolved []Finding) {
+	before := make(map[string]bool)
+	for _, f := range previous {
+		before[findingKey(f)] = true
+	}
+	now := make(map[string]bool)
+	for _, f := range current {
+		now[findingKey(f)] = true
+		if !before[findingKey(f)] {
+			added = append(added, f)
+		}
+	}
+	for _, f := range previous {
+		if !now[findingKey(f)] {
+			resolved = append(resolved, f)
+		}
+	}
+	return added, resolved
+}
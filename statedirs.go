@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// checkUnitDirs compares rules with the directories units manage themselves
+var checkUnitDirs bool
+
+// unitDirectorySettings are the unit settings that make systemd create a
+// directory below a fixed base when the service starts
+var unitDirectorySettings = []struct{ key, base string }{
+	{"RuntimeDirectory", "/run"},
+	{"StateDirectory", "/var/lib"},
+	{"CacheDirectory", "/var/cache"},
+	{"LogsDirectory", "/var/log"},
+	{"ConfigurationDirectory", "/etc"},
+}
+
+// managedDir is a directory, or a symlink to one, that a unit declares
+type managedDir struct {
+	Path    string
+	Unit    string
+	Setting string
+	Mode    uint32
+	User    string // "" for root, "dynamic" for DynamicUser=
+	Group   string
+	Symlink bool // created as a symlink, as with "dir:link" or DynamicUser=
+}
+
+// unitManagedDirs collects the directories that services declare through
+// StateDirectory= and friends, keyed by path
+func unitManagedDirs() map[string]managedDir {
+	dirs := make(map[string]managedDir)
+	for _, path := range serviceUnits() {
+		unit := filepath.Base(path)
+		if strings.Contains(unit, "@") {
+			continue // templates expand %i per instance
+		}
+		last := func(key string) string {
+			values := readUnitSettings(path, key)
+			if len(values) == 0 {
+				return ""
+			}
+			return values[len(values)-1]
+		}
+		user, group := last("User"), last("Group")
+		dynamic, _ := parseBool(last("DynamicUser"))
+		if dynamic {
+			user, group = "dynamic", "dynamic"
+		} else if group == "" && user != "" {
+			// The unit runs with the user's primary group
+			if a, err := lookupAccount(user); err == nil {
+				group = a.Group
+			}
+		}
+		for _, setting := range unitDirectorySettings {
+			mode := uint32(0755)
+			if m, err := strconv.ParseUint(last(setting.key+"Mode"), 8, 32); err == nil {
+				mode = uint32(m)
+			}
+			// An empty assignment resets the list
+			var names []string
+			for _, v := range readUnitSettings(path, setting.key) {
+				if v == "" {
+					names = nil
+				}
+				names = append(names, strings.Fields(v)...)
+			}
+			for _, name := range names {
+				name, link, _ := strings.Cut(name, ":")
+				if strings.Contains(name, "%") {
+					continue
+				}
+				d := managedDir{Path: filepath.Join(setting.base, name), Unit: unit, Setting: setting.key, Mode: mode, User: user, Group: group}
+				// DynamicUser= keeps the real directory in private/ and links to it
+				d.Symlink = dynamic && setting.base != "/run" && setting.base != "/etc"
+				dirs[d.Path] = d
+				if link != "" {
+					l := d
+					l.Path, l.Symlink = filepath.Join(setting.base, link), true
+					dirs[l.Path] = l
+				}
+			}
+		}
+	}
+	return dirs
+}
+
+// parseBool parses a systemd boolean
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "1", "yes", "y", "true", "t", "on":
+		return true, nil
+	case "0", "no", "n", "false", "f", "off", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+// sameAccount reports whether a rule's user or group field names an account
+func sameAccount(field, want string, lookup func(string) (uint32, bool)) bool {
+	field = strings.TrimPrefix(field, ":")
+	if field == "" || field == "-" {
+		field = "root"
+	}
+	if want == "" {
+		want = "root"
+	}
+	if field == want {
+		return true
+	}
+	a, okA := lookup(field)
+	b, okB := lookup(want)
+	return okA && okB && a == b
+}
+
+// unitDirConflict explains how a rule disagrees with what its unit sets up
+func unitDirConflict(r rule, d managedDir) string {
+	switch {
+	case d.Symlink && r.Type == "L":
+		return "systemd creates this symlink itself, pointing to the unit's directory"
+	case d.Symlink:
+		return "systemd creates a symlink here"
+	case !strings.Contains("dDvqQ", r.Type):
+		return fmt.Sprintf("%s rule claims the path for something other than a directory", r.Type)
+	}
+	if d.User == "dynamic" && (r.User != "" && r.User != "-" || r.Group != "" && r.Group != "-") {
+		return "the unit uses DynamicUser=, so no fixed owner can match"
+	}
+	if d.User != "dynamic" && !sameAccount(r.User, d.User, lookupUser) {
+		return fmt.Sprintf("the rule declares user %s, the unit runs as %s", r.User, orRoot(d.User))
+	}
+	if d.User != "dynamic" && !sameAccount(r.Group, d.Group, lookupGroup) {
+		return fmt.Sprintf("the rule declares group %s, the unit uses %s", r.Group, orRoot(d.Group))
+	}
+	if r.Mode != "" && r.Mode != "-" && !strings.ContainsAny(r.Mode, "~:") {
+		if m, err := strconv.ParseUint(r.Mode, 8, 32); err == nil && uint32(m) != d.Mode {
+			return fmt.Sprintf("the rule declares mode %04o, %sMode= is %04o", m, d.Setting, d.Mode)
+		}
+	}
+	return ""
+}
+
+// orRoot names the default account of a unit
+func orRoot(account string) string {
+	if account == "" {
+		return "root"
+	}
+	return account
+}
+
+// runUnitDirCheck reports rules for directories that units already manage
+// through StateDirectory=, RuntimeDirectory= and friends: duplicates are
+// redundant, conflicting ones are fought over on every service start
+func runUnitDirCheck(list []rule) {
+	fmt.Fprintln(out, "\n=== Directories managed by units ===")
+	dirs := unitManagedDirs()
+	problems := 0
+	for _, r := range list {
+		d, ok := dirs[r.Path]
+		if !ok || !inAuditScope(r) || !strings.Contains(ownershipTypes, r.Type) {
+			continue
+		}
+		problems++
+		setting := fmt.Sprintf("%s= of %s", d.Setting, d.Unit)
+		if conflict := unitDirConflict(r, d); conflict != "" {
+			fmt.Fprintf(out, "%s✗ %s %s conflicts with %s (%s:%d)%s\n", colorRed, r.Type, r.Path, setting, r.ConfFile, r.Line, colorReset)
+			fmt.Fprintf(out, "   ⤷ %s\n", conflict)
+			addFinding(Finding{Category: catUnitManagedDir, Severity: severityError, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+				Message: fmt.Sprintf("conflicts with %s: %s", setting, conflict)})
+			continue
+		}
+		fmt.Fprintf(out, "%s⚠ %s %s duplicates %s (%s:%d)%s\n", colorYellow, r.Type, r.Path, setting, r.ConfFile, r.Line, colorReset)
+		if d.Setting == "RuntimeDirectory" {
+			fmt.Fprintf(out, "   ⤷ systemd removes it when %s stops unless RuntimeDirectoryPreserve= is set\n", d.Unit)
+		}
+		fmt.Fprintf(out, "   ⤷ Drop the rule and let the unit create the directory when it starts\n")
+		addFinding(Finding{Category: catUnitManagedDir, Severity: severityWarning, Path: r.Path, ConfFile: r.ConfFile, Line: r.Line,
+			Message: "duplicates " + setting})
+	}
+	if problems == 0 {
+		fmt.Fprintf(out, "%s✓ No rule duplicates a directory managed by a unit (%d managed directories)%s\n", colorGreen, len(dirs), colorReset)
+	}
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// storePrefixes are the content-addressed stores of NixOS and Guix System
+var storePrefixes = []string{"/nix/store/", "/gnu/store/"}
+
+// contentAddressedStore makes store paths count as present whenever their
+// store object exists, and exempts store directories from completeness checks
+var contentAddressedStore bool
+
+// storeObject returns the top-level store object (/nix/store/<hash>-<name>)
+// containing path, or "" if path is not inside a store
+func storeObject(path string) string {
+	for _, prefix := range storePrefixes {
+		if rest, ok := strings.CutPrefix(path, prefix); ok && rest != "" {
+			name, _, _ := strings.Cut(rest, "/")
+			return prefix + name
+		}
+	}
+	return ""
+}
+
+// resolveStorePath follows profile indirections such as /run/current-system
+// or /nix/var/nix/profiles/* and returns the store path they end up at, or
+// path itself if it does not lead into a store
+func resolveStorePath(path string) string {
+	var resolved string
+	var err error
+	if rootDir == "" {
+		resolved, err = filepath.EvalSymlinks(path)
+	} else {
+		resolved, err = resolveInRoot(path)
+	}
+	if err != nil {
+		// Resolve the parent instead, so a missing file still maps into its store object
+		if parent := filepath.Dir(path); parent != path {
+			if p := resolveStorePath(parent); p != parent {
+				return filepath.Join(p, filepath.Base(path))
+			}
+		}
+		return path
+	}
+	if storeObject(resolved) == "" {
+		return path
+	}
+	return resolved
+}
+
+// targetExists checks a symlink target, honoring content-addressed store mode
+func targetExists(target string) bool {
+	return statTarget(target) == nil
+}
+
+// statTarget is targetExists returning why the target could not be found
+func statTarget(target string) error {
+	if contentAddressedStore {
+		if object := storeObject(resolveStorePath(target)); object != "" {
+			_, err := statPath(object)
+			return err
+		}
+	}
+	_, err := statPath(target)
+	return err
+}
+
+// trackedDir reports whether a target directory takes part in completeness
+// checks; store objects are immutable and never need per-file link rules
+func trackedDir(dir string) bool {
+	if isBaseDir(dir) {
+		return false
+	}
+	return !contentAddressedStore || storeObject(dir) == ""
+}
@@ -0,0 +1,349 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sysupdateDirs are systemd-sysupdate's transfer directories, highest precedence first
+var sysupdateDirs = []string{"/etc/sysupdate.d", "/run/sysupdate.d", "/usr/local/lib/sysupdate.d", "/usr/lib/sysupdate.d"}
+
+// partLabelDir holds a symlink to every GPT partition by its label
+const partLabelDir = "/dev/disk/by-partlabel"
+
+// sysupdateTransfer is the [Target] section of a .transfer file
+type sysupdateTransfer struct {
+	File          string
+	Type          string // partition, regular-file, directory or subvolume
+	Path          string
+	PartitionType string
+	Patterns      []*regexp.Regexp
+}
+
+// pendingVersion is an installed but not yet activated version of a transfer
+type pendingVersion struct {
+	Transfer string `json:"transfer"`
+	Version  string `json:"version"`
+	Path     string `json:"path"`
+	Report   Report `json:"report"`
+	Skipped  string `json:"skipped,omitempty"` // why it could not be audited
+}
+
+// sysupdatePattern compiles a MatchPattern=; @v captures the version and
+// the other @ specifiers match anything
+func sysupdatePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '@' || i+1 == len(pattern) {
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'v':
+			b.WriteString(`(?P<v>[A-Za-z0-9._+~^-]+?)`)
+		case '@':
+			b.WriteString("@")
+		default:
+			b.WriteString(".*?")
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// readTransfer parses the [Target] section of a .transfer file
+func readTransfer(path string) (sysupdateTransfer, error) {
+	t := sysupdateTransfer{File: path}
+	section := ""
+	for _, raw := range readLines(path) {
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "[") {
+			section = line
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section != "[Target]" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Type":
+			t.Type = value
+		case "Path":
+			t.Path = value
+		case "PartitionType":
+			t.PartitionType = value
+		case "MatchPattern":
+			for _, p := range strings.Fields(value) {
+				re, err := sysupdatePattern(p)
+				if err != nil {
+					return t, fmt.Errorf("%s: MatchPattern %q: %w", path, p, err)
+				}
+				t.Patterns = append(t.Patterns, re)
+			}
+		}
+	}
+	return t, nil
+}
+
+// transferFiles returns the effective .transfer files, selected by name
+// like tmpfiles.d fragments
+func transferFiles() []string {
+	byName := make(map[string]string)
+	for _, dir := range sysupdateDirs {
+		matches, _ := globPath(dir + "/*.transfer")
+		for _, path := range matches {
+			if _, ok := byName[filepath.Base(path)]; !ok && !isMasked(path) {
+				byName[filepath.Base(path)] = path
+			}
+		}
+	}
+	files := make([]string, 0, len(byName))
+	for _, path := range byName {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// matchVersion returns the version a name carries if it matches the transfer
+func (t sysupdateTransfer) matchVersion(name string) (string, bool) {
+	for _, re := range t.Patterns {
+		if m := re.FindStringSubmatch(name); m != nil {
+			return m[re.SubexpIndex("v")], true
+		}
+	}
+	return "", false
+}
+
+// instances returns the versions of a transfer present on the target, by
+// the host path holding each
+func (t sysupdateTransfer) instances() map[string]string {
+	found := make(map[string]string)
+	if t.Type == "partition" {
+		entries, _ := os.ReadDir(partLabelDir)
+		for _, e := range entries {
+			label := unescapeMountPath(e.Name())
+			if v, ok := t.matchVersion(label); ok {
+				found[v] = filepath.Join(partLabelDir, e.Name())
+			}
+		}
+		return found
+	}
+	entries, _ := listDir(t.Path)
+	for _, e := range entries {
+		if v, ok := t.matchVersion(e.Name()); ok {
+			found[v] = rootPath(filepath.Join(t.Path, e.Name()))
+		}
+	}
+	return found
+}
+
+// compareVersions orders versions like systemd's strverscmp_improved():
+// runs of digits compare numerically, '~' sorts before anything else
+func compareVersions(a, b string) int {
+	for a != "" || b != "" {
+		switch {
+		case strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~"):
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		case a == "":
+			return -1
+		case b == "":
+			return 1
+		}
+		ra, rb := versionRun(a), versionRun(b)
+		na, errA := strconv.ParseUint(ra, 10, 64)
+		nb, errB := strconv.ParseUint(rb, 10, 64)
+		switch {
+		case errA == nil && errB == nil && na != nb:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case (errA == nil) != (errB == nil):
+			// Numbers sort after letters
+			if errA == nil {
+				return 1
+			}
+			return -1
+		case errA != nil && ra != rb:
+			return strings.Compare(ra, rb)
+		}
+		a, b = a[len(ra):], b[len(rb):]
+	}
+	return 0
+}
+
+// versionRun returns the leading run of digits or of other characters
+func versionRun(s string) string {
+	digit := unicode.IsDigit(rune(s[0]))
+	for i, c := range s {
+		if unicode.IsDigit(c) != digit || c == '~' && i > 0 {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// pendingVersions returns the versions newer than the booted one; without
+// an IMAGE_VERSION only the newest version counts as pending
+func (t sysupdateTransfer) pendingVersions(current string) map[string]string {
+	all := t.instances()
+	pending := make(map[string]string)
+	newest := ""
+	for v, path := range all {
+		if current != "" && compareVersions(v, current) > 0 {
+			pending[v] = path
+		}
+		if newest == "" || compareVersions(v, newest) > 0 {
+			newest = v
+		}
+	}
+	if current == "" && newest != "" {
+		pending[newest] = all[newest]
+	}
+	return pending
+}
+
+// auditPending audits one pending version: a /usr partition on top of the
+// running root, or a root partition, directory or image of its own
+func auditPending(t sysupdateTransfer, path string) (Report, error) {
+	replacesUsr := t.Type == "partition" && strings.Contains(t.PartitionType, "usr")
+	dir := path
+	if fi, err := os.Stat(path); err != nil {
+		return Report{}, err
+	} else if !fi.IsDir() {
+		mnt, cleanup, err := mountImage(path)
+		if err != nil {
+			return Report{}, err
+		}
+		defer cleanup()
+		dir = mnt
+	}
+	if !replacesUsr {
+		r, err := auditRoot(dir)
+		r.Root = path
+		return r, err
+	}
+	saved := usrOverride
+	usrOverride = canonicalHostPath(dir)
+	defer func() { usrOverride = saved }()
+	if err := quietAudit(); err != nil {
+		return Report{}, err
+	}
+	r := currentReport()
+	r.Root = describeRoot() + " with /usr from " + path
+	return r, nil
+}
+
+// runSysupdate implements `sysupdate [TRANSFER...]`: every transfer with a
+// version newer than the booted IMAGE_VERSION is audited before it is
+// activated, failing if one has blocking findings
+func runSysupdate(args []string) int {
+	files := transferFiles()
+	if len(args) > 0 {
+		files = nil
+		for _, a := range args {
+			if !strings.Contains(a, "/") {
+				a = filepath.Join("/usr/lib/sysupdate.d", strings.TrimSuffix(a, ".transfer")+".transfer")
+			}
+			files = append(files, a)
+		}
+	}
+	current := readOSRelease()["IMAGE_VERSION"]
+	var results []pendingVersion
+	for _, file := range files {
+		t, err := readTransfer(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		pending := t.pendingVersions(current)
+		versions := make([]string, 0, len(pending))
+		for v := range pending {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) < 0 })
+		for _, v := range versions {
+			p := pendingVersion{Transfer: file, Version: v, Path: pending[v]}
+			if t.Type == "regular-file" && !strings.HasSuffix(p.Path, ".raw") && !strings.HasSuffix(p.Path, ".img") {
+				p.Skipped = "not a disk image"
+			} else if r, err := auditPending(t, p.Path); err != nil {
+				p.Skipped = err.Error()
+			} else {
+				p.Report = r
+			}
+			results = append(results, p)
+		}
+	}
+
+	failed := false
+	for _, p := range results {
+		for _, f := range p.Report.Findings {
+			failed = failed || blocksRun(f)
+		}
+	}
+	if outputFormat == "json" {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+	} else {
+		printPendingVersions(results, current)
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// printPendingVersions renders the audits of pending versions for humans
+func printPendingVersions(results []pendingVersion, current string) {
+	if current == "" {
+		current = "unknown"
+	}
+	fmt.Fprintf(out, "=== Pending sysupdate versions (booted: %s) ===\n", current)
+	if len(results) == 0 {
+		fmt.Fprintf(out, "%s✓ No version is pending activation%s\n", colorGreen, colorReset)
+		return
+	}
+	for _, p := range results {
+		fmt.Fprintf(out, "\n%s %s: %s\n", filepath.Base(p.Transfer), p.Version, p.Path)
+		if p.Skipped != "" {
+			fmt.Fprintf(out, "  %s⚠ Not audited: %s%s\n", colorYellow, p.Skipped, colorReset)
+			continue
+		}
+		problems := 0
+		for _, f := range p.Report.Findings {
+			if f.Severity == severityInfo {
+				continue
+			}
+			problems++
+			color := colorYellow
+			if blocksRun(f) {
+				color = colorRed
+			}
+			fmt.Fprintf(out, "  %s%s %s %s: %s%s\n", color, f.Code, f.Category, f.Path, f.Message, colorReset)
+		}
+		if problems == 0 {
+			fmt.Fprintf(out, "  %s✓ Safe to activate%s\n", colorGreen, colorReset)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// checkSysusers reports accounts rules rely on that only exist locally
+var checkSysusers bool
+
+// sysusersDirs are systemd's sysusers.d search directories, highest precedence first
+var sysusersDirs = []string{"/etc/sysusers.d", "/run/sysusers.d", "/usr/local/lib/sysusers.d", "/usr/lib/sysusers.d"}
+
+// baseAccountFiles ship accounts in /usr that exist before sysusers runs,
+// through nss-altfiles or a factory copy of /etc
+var baseAccountFiles = map[string][]string{
+	"user":  {"/usr/lib/passwd", "/usr/share/factory/etc/passwd", usrEtcDir + "/passwd"},
+	"group": {"/usr/lib/group", "/usr/share/factory/etc/group", usrEtcDir + "/group"},
+}
+
+// accountDecl is where sysusers.d declares a user or group
+type accountDecl struct {
+	ConfFile string
+	Line     int
+}
+
+// declaredAccounts holds the sysusers.d declarations by kind and name;
+// nil until first used in an audit
+var declaredAccounts map[string]map[string]accountDecl
+
+// loadSysusers parses the effective sysusers.d config: u declares a user
+// and its same-named group unless it names an existing one, g a group,
+// and m implicitly creates both sides of the membership
+func loadSysusers() map[string]map[string]accountDecl {
+	decls := map[string]map[string]accountDecl{"user": {}, "group": {}}
+	declare := func(kind, name string, d accountDecl) {
+		if _, ok := decls[kind][name]; !ok && name != "" && !strings.Contains(name, "%") {
+			decls[kind][name] = d
+		}
+	}
+	for _, frag := range confFragments(sysusersDirs) {
+		for i, raw := range readLines(frag.Path) {
+			line := strings.TrimSpace(raw)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			typ, rest, _ := extractWord(line)
+			name, rest, _ := extractWord(rest)
+			id, _, _ := extractWord(rest)
+			d := accountDecl{ConfFile: frag.Path, Line: i + 1}
+			switch strings.TrimRight(typ, "!") {
+			case "u":
+				declare("user", name, d)
+				// "uid:groupname" joins a group that must come from elsewhere
+				if _, group, ok := strings.Cut(id, ":"); !ok || isNumericID(group) {
+					declare("group", name, d)
+				}
+			case "g":
+				declare("group", name, d)
+			case "m":
+				declare("user", name, d)
+				declare("group", id, d)
+			}
+		}
+	}
+	return decls
+}
+
+// isNumericID reports whether s is a numeric UID or GID
+func isNumericID(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 32)
+	return err == nil
+}
+
+// declaredBySysusers returns the sysusers.d entry creating an account
+func declaredBySysusers(kind, name string) (accountDecl, bool) {
+	if declaredAccounts == nil {
+		declaredAccounts = loadSysusers()
+	}
+	d, ok := declaredAccounts[kind][name]
+	return d, ok
+}
+
+// inBaseAccounts reports whether an account is shipped in /usr, so even a
+// freshly provisioned system has it before sysusers runs
+func inBaseAccounts(kind, name string) bool {
+	if name == "root" {
+		return true
+	}
+	for _, file := range baseAccountFiles[kind] {
+		if _, ok := loadAccounts(file).ids[name]; ok {
+			return true
+		}
+	}
+	return false
+}
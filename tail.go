@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+var (
+	// tailFollow keeps streaming events after the recent ones
+	tailFollow bool
+	// tailLines is how many recent events tail prints
+	tailLines int
+)
+
+func tailFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&tailFollow, "f", false, "keep printing events as the daemon audits")
+	fs.IntVar(&tailLines, "n", 10, "number of recent events to print")
+}
+
+// varlinkClient is a connection to the daemon's Varlink socket
+type varlinkClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// call sends a method call; replies are read with reply
+func (c *varlinkClient) call(method string, parameters any, more bool) error {
+	data, err := json.Marshal(map[string]any{"method": method, "parameters": parameters, "more": more})
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Write(append(data, 0))
+	return err
+}
+
+// reply reads the next reply into parameters and reports whether more follow
+func (c *varlinkClient) reply(parameters any) (bool, error) {
+	msg, err := c.r.ReadBytes(0)
+	if err != nil {
+		return false, err
+	}
+	var reply struct {
+		Parameters json.RawMessage `json:"parameters"`
+		Continues  bool            `json:"continues"`
+		Error      string          `json:"error"`
+	}
+	if err := json.Unmarshal(msg[:len(msg)-1], &reply); err != nil {
+		return false, err
+	}
+	if reply.Error != "" {
+		return false, fmt.Errorf("%s %s", reply.Error, reply.Parameters)
+	}
+	return reply.Continues, json.Unmarshal(reply.Parameters, parameters)
+}
+
+// printEvent writes one daemon event as a line
+func printEvent(w io.Writer, e watchEvent) {
+	stamp := e.Time.Local().Format(time.DateTime)
+	switch e.Event {
+	case "added":
+		f := e.Finding
+		color := colorYellow
+		if f.Severity == severityError {
+			color = colorRed
+		}
+		fmt.Fprintf(w, "%s %s+ %s %s %s: %s%s", stamp, color, f.Severity, f.Code, f.Path, f.Message, colorReset)
+		if f.ConfFile != "" {
+			fmt.Fprintf(w, " (%s:%d)", f.ConfFile, f.Line)
+		}
+		fmt.Fprintln(w)
+	case "resolved":
+		fmt.Fprintf(w, "%s %s✓ resolved %s %s: %s%s\n", stamp, colorGreen, e.Finding.Code, e.Finding.Path, e.Finding.Message, colorReset)
+	case "error":
+		fmt.Fprintf(w, "%s %s✗ audit failed: %s%s\n", stamp, colorRed, e.Error, colorReset)
+	default:
+		fmt.Fprintf(w, "%s %s\n", stamp, e.Summary)
+	}
+}
+
+// runTail prints the daemon's recent events and, with -f, follows new ones
+func runTail(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: tail [-f] [-n N]")
+		return 2
+	}
+	socket := varlinkSocket
+	if socket == "" {
+		socket = defaultVarlinkSocket
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot reach the daemon: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+	c := &varlinkClient{conn: conn, r: bufio.NewReader(conn)}
+
+	// Subscribe first so no event falls between the two calls; the log is
+	// read on a second connection since a subscription occupies this one
+	if tailFollow {
+		if err := c.call(varlinkInterface+".Subscribe", nil, true); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	logConn, err := net.Dial("unix", socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot reach the daemon: %v\n", err)
+		return 1
+	}
+	defer logConn.Close()
+	lc := &varlinkClient{conn: logConn, r: bufio.NewReader(logConn)}
+	var recent struct {
+		Events []watchEvent `json:"events"`
+	}
+	err = lc.call(varlinkInterface+".GetEvents", map[string]int{"limit": tailLines}, false)
+	if err == nil {
+		_, err = lc.reply(&recent)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, e := range recent.Events {
+		printEvent(os.Stdout, e)
+	}
+	if !tailFollow {
+		return 0
+	}
+	for {
+		var e watchEvent
+		more, err := c.reply(&e)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		printEvent(os.Stdout, e)
+		if !more {
+			return 0
+		}
+	}
+}
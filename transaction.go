@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// transactionPre snapshots the system before a package transaction
+	transactionPre bool
+	// transactionPost reports what changed since the --pre snapshot
+	transactionPost bool
+)
+
+// transactionStatePath is where --pre leaves its report for --post
+func transactionStatePath() string {
+	return filepath.Join(stateDir(), "pre-transaction.json")
+}
+
+// runTransactionHook implements the --pre and --post phases run by package
+// manager hooks; --post fails only if the transaction introduced findings
+// that block the run, after --fail-on and the baseline's waivers
+func runTransactionHook() int {
+	if err := quietAudit(); err != nil {
+		fmt.Fprintf(os.Stderr, "tmpfiles-audit: %v\n", err)
+		return 1
+	}
+	if baselineFile != "" {
+		report := out
+		out = io.Discard
+		err := applyBaseline()
+		out = report
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tmpfiles-audit: %v\n", err)
+			return 1
+		}
+	}
+	if transactionPre {
+		if err := os.MkdirAll(stateDir(), 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "tmpfiles-audit: %v\n", err)
+			return 1
+		}
+		if err := saveReport(transactionStatePath(), currentReport()); err != nil {
+			fmt.Fprintf(os.Stderr, "tmpfiles-audit: saving pre-transaction state: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	before, err := loadReport(transactionStatePath())
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "tmpfiles-audit: no pre-transaction state, was --pre run?")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmpfiles-audit: %v\n", err)
+		return 1
+	}
+	os.Remove(transactionStatePath())
+
+	d := diffReports(before, currentReport())
+	d.From, d.To = "before transaction", "after transaction"
+	if len(d.AddedFindings) == 0 && len(d.ResolvedFindings) == 0 {
+		fmt.Fprintf(out, "tmpfiles-audit: %d rule(s) changed, no new findings\n", len(d.AddedRules)+len(d.RemovedRules)+len(d.ChangedRules))
+		return 0
+	}
+	printDiff(d)
+	for _, f := range d.AddedFindings {
+		if blocksRun(f) {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// auditUser names the account whose per-user rules are audited, like
+	// systemd-tmpfiles --user run by that user; "" audits the system rules
+	auditUser string
+
+	// specifiers maps the specifiers expanded before rules are parsed; nil
+	// leaves every specifier as written
+	specifiers map[byte]string
+)
+
+// userAccount is a passwd entry of the audited system
+type userAccount struct {
+	Name  string
+	UID   uint32
+	GID   uint32
+	Group string
+	Home  string
+}
+
+// lookupAccount finds a user by name or UID in the audited system's /etc/passwd
+func lookupAccount(name string) (userAccount, error) {
+	for _, line := range readLines("/etc/passwd") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 6 || strings.HasPrefix(fields[0], "#") || (fields[0] != name && fields[2] != name) {
+			continue
+		}
+		uid, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		gid, err := strconv.ParseUint(fields[3], 10, 32)
+		if err != nil {
+			continue
+		}
+		return userAccount{Name: fields[0], UID: uint32(uid), GID: uint32(gid), Group: groupName(uint32(gid)), Home: fields[5]}, nil
+	}
+	return userAccount{}, fmt.Errorf("no user %s in /etc/passwd", name)
+}
+
+// runtimeDir is the account's $XDG_RUNTIME_DIR as pam_systemd sets it up
+func (a userAccount) runtimeDir() string {
+	return fmt.Sprintf("/run/user/%d", a.UID)
+}
+
+// userTmpfilesDirs are the user-tmpfiles.d search directories of systemd-tmpfiles
+// --user with default XDG variables, highest precedence first, in the order
+// of systemd's user_config_paths()
+func userTmpfilesDirs(a userAccount) []string {
+	return []string{
+		"/etc/xdg/user-tmpfiles.d",
+		a.Home + "/.config/user-tmpfiles.d",
+		a.runtimeDir() + "/user-tmpfiles.d",
+		a.Home + "/.local/share/user-tmpfiles.d",
+		"/usr/local/share/user-tmpfiles.d",
+		"/usr/share/user-tmpfiles.d",
+	}
+}
+
+// userSpecifiers are the specifiers whose expansion depends on the account
+// in --user mode, see the table in tmpfiles.d(5)
+func userSpecifiers(a userAccount) map[byte]string {
+	return map[byte]string{
+		'h': a.Home,
+		'u': a.Name,
+		'U': strconv.FormatUint(uint64(a.UID), 10),
+		'g': a.Group,
+		'G': strconv.FormatUint(uint64(a.GID), 10),
+		't': a.runtimeDir(),
+		'S': a.Home + "/.local/state",
+		'C': a.Home + "/.cache",
+		'L': a.Home + "/.local/state/log",
+		'T': "/tmp",
+		'V': "/var/tmp",
+		'%': "%",
+	}
+}
+
+// setupUserMode switches the audit to the per-user rules of auditUser
+func setupUserMode() error {
+	a, err := lookupAccount(auditUser)
+	if err != nil {
+		return err
+	}
+	auditUser = a.Name
+	tmpfilesDirs = userTmpfilesDirs(a)
+	specifiers = userSpecifiers(a)
+	return nil
+}
+
+// expandSpecifiers replaces the known specifiers in a line; unknown ones
+// are kept so the specifier lint still reports them
+func expandSpecifiers(line string) string {
+	if specifiers == nil || !strings.Contains(line, "%") {
+		return line
+	}
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '%' && i+1 < len(line) {
+			if v, ok := specifiers[line[i+1]]; ok {
+				b.WriteString(v)
+				i++
+				continue
+			}
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String()
+}
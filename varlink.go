@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+)
+
+// varlinkInterface is the name of the audit daemon's Varlink interface
+const varlinkInterface = "org.tmpfiles.audit"
+
+// defaultVarlinkSocket is where clients such as tail look for the daemon
+const defaultVarlinkSocket = "/run/" + varlinkInterface
+
+// varlinkSocket is where the daemon serves Varlink; "" disables it
+var varlinkSocket string
+
+// varlinkDescription is the interface definition returned by
+// org.varlink.service.GetInterfaceDescription
+const varlinkDescription = `# Audit results of tmpfiles-audit
+interface org.tmpfiles.audit
+
+type Finding (
+  code: ?string,
+  category: string,
+  severity: string,
+  path: string,
+  target: ?string,
+  conf_file: ?string,
+  line: ?int,
+  package: ?string,
+  runtime: ?string,
+  scope: ?string,
+  message: string
+)
+
+type Event (
+  event: string,
+  time: string,
+  finding: ?Finding,
+  error: ?string,
+  summary: ?string
+)
+
+# Runs an audit and returns what changed since the previous one; errors
+# counts the findings that fail the run, warnings the others
+method Audit() -> (errors: int, warnings: int, added: int, resolved: int)
+
+# Returns the findings of the latest completed audit
+method GetFindings() -> (findings: []Finding)
+
+# Returns up to limit of the latest logged events, oldest first; all by default
+method GetEvents(limit: ?int) -> (events: []Event)
+
+# Streams the events of later audits; needs more
+method Subscribe() -> (event: string, time: string, finding: ?Finding, error: ?string, summary: ?string)
+
+error AuditFailed (message: string)
+`
+
+// varlinkCall is a method call received from a client
+type varlinkCall struct {
+	Method     string          `json:"method"`
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	More       bool            `json:"more,omitempty"`
+	Oneway     bool            `json:"oneway,omitempty"`
+}
+
+// varlinkReply is a reply or error sent to a client
+type varlinkReply struct {
+	Parameters any    `json:"parameters,omitempty"`
+	Continues  bool   `json:"continues,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// startVarlink serves the Varlink interface on varlinkSocket in the background
+func startVarlink() error {
+	if varlinkSocket == "" {
+		return nil
+	}
+	// A socket left behind by a previous instance would make Listen fail
+	if err := os.Remove(varlinkSocket); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	listener, err := net.Listen("unix", varlinkSocket)
+	if err != nil {
+		return err
+	}
+	// Audits are expensive, so only root may talk to the daemon
+	if err := os.Chmod(varlinkSocket, 0600); err != nil {
+		return err
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Varlink: %v\n", err)
+				os.Exit(1)
+			}
+			go serveVarlinkConn(conn)
+		}
+	}()
+	return nil
+}
+
+// serveVarlinkConn answers the calls of one client in order; messages are
+// JSON objects terminated by a NUL byte
+func serveVarlinkConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	send := func(reply varlinkReply) error {
+		data, err := json.Marshal(reply)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(append(data, 0))
+		return err
+	}
+	for {
+		msg, err := r.ReadBytes(0)
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(os.Stderr, "Warning: Varlink: %v\n", err)
+			}
+			return
+		}
+		var call varlinkCall
+		if err := json.Unmarshal(msg[:len(msg)-1], &call); err != nil {
+			return
+		}
+		if call.Method == varlinkInterface+".Subscribe" && call.More {
+			// A subscription occupies the connection until the client leaves
+			varlinkSubscribe(r, send)
+			return
+		}
+		reply := varlinkDispatch(call, send)
+		if call.Oneway || reply == nil {
+			continue
+		}
+		if err := send(*reply); err != nil {
+			return
+		}
+	}
+}
+
+// varlinkDispatch runs one call; streaming methods send their replies
+// themselves and return nil
+func varlinkDispatch(call varlinkCall, send func(varlinkReply) error) *varlinkReply {
+	switch call.Method {
+	case "org.varlink.service.GetInfo":
+		return &varlinkReply{Parameters: map[string]any{
+			"vendor":     "KDE",
+			"product":    "tmpfiles-audit",
+			"version":    toolVersion(),
+			"url":        "https://github.com/silverhadch/tmpfiles-audit",
+			"interfaces": []string{"org.varlink.service", varlinkInterface},
+		}}
+	case "org.varlink.service.GetInterfaceDescription":
+		var p struct {
+			Interface string `json:"interface"`
+		}
+		json.Unmarshal(call.Parameters, &p)
+		if p.Interface != varlinkInterface {
+			return &varlinkReply{Error: "org.varlink.service.InterfaceNotFound", Parameters: map[string]string{"interface": p.Interface}}
+		}
+		return &varlinkReply{Parameters: map[string]string{"description": varlinkDescription}}
+	case varlinkInterface + ".Audit":
+		added, resolved, err := daemonAudit()
+		if err != nil {
+			return &varlinkReply{Error: varlinkInterface + ".AuditFailed", Parameters: map[string]string{"message": err.Error()}}
+		}
+		errors, warnings := countBlocking(daemon.snapshot().Report.Findings)
+		return &varlinkReply{Parameters: map[string]int{"errors": errors, "warnings": warnings, "added": len(added), "resolved": len(resolved)}}
+	case varlinkInterface + ".GetFindings":
+		list := daemon.snapshot().Report.Findings
+		if list == nil {
+			list = []Finding{}
+		}
+		return &varlinkReply{Parameters: map[string][]Finding{"findings": list}}
+	case varlinkInterface + ".GetEvents":
+		p := struct {
+			Limit *int `json:"limit"`
+		}{}
+		json.Unmarshal(call.Parameters, &p)
+		limit := -1
+		if p.Limit != nil {
+			limit = *p.Limit
+		}
+		return &varlinkReply{Parameters: map[string][]watchEvent{"events": daemon.recentEvents(limit)}}
+	case varlinkInterface + ".Subscribe":
+		return &varlinkReply{Error: "org.varlink.service.ExpectedMore"}
+	}
+	return &varlinkReply{Error: "org.varlink.service.MethodNotFound", Parameters: map[string]string{"method": call.Method}}
+}
+
+// varlinkSubscribe streams daemon events to a client until it hangs up or
+// a send fails; the client sends nothing more, so reading the connection
+// to its end tells when it is gone
+func varlinkSubscribe(r io.Reader, send func(varlinkReply) error) {
+	events, cancel := daemon.subscribe()
+	defer cancel()
+	hangup := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, r)
+		close(hangup)
+	}()
+	for {
+		select {
+		case e := <-events:
+			if err := send(varlinkReply{Parameters: e, Continues: true}); err != nil {
+				return
+			}
+		case <-hangup:
+			return
+		}
+	}
+}
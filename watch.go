@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	// watchMode keeps the process resident, re-auditing whenever the
+	// watched directories change
+	watchMode bool
+	// watchDelay is how long changes must settle before a re-audit, so a
+	// package upgrade touching many files triggers a single audit
+	watchDelay = 500 * time.Millisecond
+)
+
+// watchMask selects the inotify events that can change audit results
+const watchMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_ATTRIB |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF | unix.IN_DONT_FOLLOW
+
+// watchedDirs returns the tmpfiles.d directories and every directory
+// below the factory tree; a missing directory is replaced by its closest
+// existing parent so its creation is noticed
+func watchedDirs() []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		for dir != "/" {
+			if fi, err := os.Stat(rootPath(dir)); err == nil && fi.IsDir() {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range tmpfilesDirs {
+		add(dir)
+	}
+	for _, root := range factoryRoots() {
+		add(root)
+		filepath.WalkDir(rootPath(root), func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				dir := path
+				if rootDir != "" {
+					dir = "/" + strings.TrimPrefix(strings.TrimPrefix(path, filepath.Clean(rootDir)), "/")
+				}
+				add(filepath.Clean(dir))
+			}
+			return nil
+		})
+	}
+	return dirs
+}
+
+// watchEvent is one line of the --watch output when findings are not
+// sent to the journal, and an entry of the daemon's event log
+type watchEvent struct {
+	Event   string    `json:"event"` // "added", "resolved", "error" or "audit"
+	Time    time.Time `json:"time"`
+	Finding *Finding  `json:"finding,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Summary string    `json:"summary,omitempty"` // outcome of an audit
+}
+
+// publishDelta emits the findings a re-audit added and resolved, to the
+// journal if enabled and as JSON lines on stdout otherwise, which a
+// StandardOutput=socket unit can forward to a listener
+func publishDelta(w io.Writer, added, resolved []Finding) {
+	if journalEnabled() {
+		if err := logToJournal(added); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		for _, f := range resolved {
+			logMessageToJournal(6, "resolved: "+f.Message+": "+f.Path)
+		}
+		return
+	}
+	enc := json.NewEncoder(w)
+	now := time.Now()
+	for i := range added {
+		enc.Encode(watchEvent{Event: "added", Time: now, Finding: &added[i]})
+	}
+	for i := range resolved {
+		enc.Encode(watchEvent{Event: "resolved", Time: now, Finding: &resolved[i]})
+	}
+}
+
+// addWatches (re)registers the watched directories; directories that
+// appeared since the last audit are picked up, vanished ones are dropped by
+// the kernel
+func addWatches(fd int) {
+	for _, dir := range watchedDirs() {
+		if _, err := unix.InotifyAddWatch(fd, rootPath(dir), watchMask); err != nil && !errors.Is(err, unix.ENOENT) {
+			fmt.Fprintf(os.Stderr, "Warning: cannot watch %s: %v\n", dir, err)
+		}
+	}
+}
+
+// runWatch audits once, then re-audits whenever the watched directories
+// change, publishing only what changed
+func runWatch() int {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: inotify: %v\n", err)
+		return 1
+	}
+	defer unix.Close(fd)
+
+	// Results are only published as deltas
+	out = io.Discard
+
+	if err := startListeners(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	addWatches(fd)
+	daemonAudit()
+	startScheduler()
+	handleReloads()
+	sdNotify("READY=1")
+
+	buf := make([]byte, 64*1024)
+	pending := false
+	var deadline time.Time
+	for {
+		timeout := -1
+		if interval := watchdogInterval(); interval > 0 {
+			timeout = int(interval / 2 / time.Millisecond)
+		}
+		if pending {
+			if wait := int(time.Until(deadline) / time.Millisecond); timeout < 0 || wait < timeout {
+				timeout = max(wait, 0)
+			}
+		}
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, timeout)
+		if err != nil && !errors.Is(err, unix.EINTR) {
+			fmt.Fprintf(os.Stderr, "Error: poll: %v\n", err)
+			return 1
+		}
+		notifyWatchdog()
+		if n > 0 {
+			// The events themselves don't matter, only that something changed
+			for {
+				if _, err := unix.Read(fd, buf); err != nil {
+					break
+				}
+			}
+			pending = true
+			deadline = time.Now().Add(watchDelay)
+			continue
+		}
+		if pending && !time.Now().Before(deadline) {
+			pending = false
+			addWatches(fd)
+			daemonAudit()
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// webhook posts the findings a daemon audit newly detected to a URL
+type webhook struct {
+	URL         string `json:"url"`
+	Format      string `json:"format,omitempty"`       // "json" (default), "slack" or "matrix"
+	Token       string `json:"token,omitempty"`        // sent as a bearer token, e.g. a Matrix access token
+	MinSeverity string `json:"min_severity,omitempty"` // least severity that is posted, default warning
+	MinInterval string `json:"min_interval,omitempty"` // least time between two posts, default 5m
+	DedupWindow string `json:"dedup_window,omitempty"` // time a posted finding is not posted again, default 24h
+
+	interval, dedup time.Duration
+	mu              sync.Mutex
+	lastSent        time.Time
+	pending         []Finding
+	flushTimer      *time.Timer
+	posted          map[string]time.Time
+}
+
+// webhookFormats are the payload formats a webhook can use
+var webhookFormats = []string{"json", "slack", "matrix"}
+
+// severityRank orders severities for thresholds
+var severityRank = map[string]int{severityInfo: 0, severityWarning: 1, severityError: 2}
+
+// webhookClient posts webhooks; a hanging endpoint must not pile up goroutines
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// validate checks a webhook from the site config and fills in defaults
+func (h *webhook) validate() error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q", h.URL)
+	}
+	if h.Format == "" {
+		h.Format = "json"
+	}
+	if !slices.Contains(webhookFormats, h.Format) {
+		return fmt.Errorf("unknown format %q", h.Format)
+	}
+	if h.MinSeverity == "" {
+		h.MinSeverity = severityWarning
+	}
+	if _, ok := severityRank[h.MinSeverity]; !ok {
+		return fmt.Errorf("unknown severity %q", h.MinSeverity)
+	}
+	for _, d := range []struct {
+		value    string
+		fallback time.Duration
+		into     *time.Duration
+	}{{h.MinInterval, 5 * time.Minute, &h.interval}, {h.DedupWindow, 24 * time.Hour, &h.dedup}} {
+		*d.into = d.fallback
+		if d.value != "" {
+			if *d.into, err = time.ParseDuration(d.value); err != nil {
+				return err
+			}
+		}
+	}
+	h.posted = make(map[string]time.Time)
+	return nil
+}
+
+// notifyWebhooks hands newly detected findings to every configured webhook;
+// the list is taken under the daemon lock as a reload may replace it
+func notifyWebhooks(added []Finding) {
+	daemon.mu.Lock()
+	hooks := site.Webhooks
+	daemon.mu.Unlock()
+	for i := range hooks {
+		hooks[i].queue(added)
+	}
+}
+
+// queue adds findings above the threshold that were not posted within the
+// dedup window, and posts them now or once the rate limit allows
+func (h *webhook) queue(added []Finding) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for _, f := range added {
+		key := findingKey(f)
+		if severityRank[f.Severity] < severityRank[h.MinSeverity] || now.Sub(h.posted[key]) < h.dedup {
+			continue
+		}
+		h.posted[key] = now
+		h.pending = append(h.pending, f)
+	}
+	if len(h.pending) == 0 || h.flushTimer != nil {
+		return
+	}
+	wait := h.interval - now.Sub(h.lastSent)
+	if wait <= 0 {
+		go h.flush()
+		return
+	}
+	h.flushTimer = time.AfterFunc(wait, h.flush)
+}
+
+// flush posts the queued findings in one request
+func (h *webhook) flush() {
+	h.mu.Lock()
+	list := h.pending
+	h.pending = nil
+	h.flushTimer = nil
+	h.lastSent = time.Now()
+	h.mu.Unlock()
+	if len(list) == 0 {
+		return
+	}
+	if err := h.post(list); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: webhook %s: %v\n", h.URL, err)
+	}
+}
+
+// webhookSummary is the plain-text form of a batch of findings
+func webhookSummary(list []Finding) (title string, lines []string) {
+	host := auditedHostname()
+	if host == "" {
+		host = describeRoot()
+	}
+	title = fmt.Sprintf("tmpfiles-audit: %d new finding(s) on %s", len(list), host)
+	for _, f := range list {
+		line := fmt.Sprintf("%s %s %s: %s", strings.ToUpper(f.Severity), f.Code, f.Path, f.Message)
+		if f.ConfFile != "" {
+			line += fmt.Sprintf(" (%s:%d)", f.ConfFile, f.Line)
+		}
+		lines = append(lines, line)
+	}
+	return title, lines
+}
+
+// webhookPayload renders a batch of findings in the webhook's format
+func (h *webhook) payload(list []Finding) any {
+	title, lines := webhookSummary(list)
+	switch h.Format {
+	case "slack":
+		return map[string]string{"text": "*" + title + "*\n" + strings.Join(lines, "\n")}
+	case "matrix":
+		var b strings.Builder
+		b.WriteString("<p><strong>" + html.EscapeString(title) + "</strong></p><ul>")
+		for _, line := range lines {
+			b.WriteString("<li>" + html.EscapeString(line) + "</li>")
+		}
+		b.WriteString("</ul>")
+		return map[string]string{"msgtype": "m.notice", "body": title + "\n" + strings.Join(lines, "\n"),
+			"format": "org.matrix.custom.html", "formatted_body": b.String()}
+	}
+	return struct {
+		Title    string    `json:"title"`
+		Host     string    `json:"host"`
+		Time     time.Time `json:"time"`
+		Findings []Finding `json:"findings"`
+	}{title, auditedHostname(), time.Now().UTC(), list}
+}
+
+// post delivers a batch of findings; Matrix rooms take events by PUT with
+// a transaction ID below .../send/m.room.message
+func (h *webhook) post(list []Finding) error {
+	body, err := json.Marshal(h.payload(list))
+	if err != nil {
+		return err
+	}
+	method, target := http.MethodPost, h.URL
+	if h.Format == "matrix" {
+		method = http.MethodPut
+		target = strings.TrimSuffix(h.URL, "/") + "/tmpfiles-audit-" + fmt.Sprint(time.Now().UnixNano())
+	}
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: GPL-2.0-only OR GPL-3.0-only OR LicenseRef-KDE-Accepted-GPL
+// SPDX-FileCopyrightText: 2025 Hadi Chokr hadichokr@icloud.com
+
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// webUI is a read-only page rendering /report with filters by severity,
+// fragment and directory
+//
+//go:embed webui.html
+var webUI []byte
+
+// serveWebUI returns the embedded page
+func serveWebUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	w.Write(webUI)
+}